@@ -2,12 +2,23 @@ package main
 
 import (
 	"alert-webhooks/config"
+	"alert-webhooks/pkg/actions"
+	"alert-webhooks/pkg/dedup"
+	"alert-webhooks/pkg/inhibit"
 	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/msgref"
+	"alert-webhooks/pkg/notification"
+	"alert-webhooks/pkg/notification/providers"
+	"alert-webhooks/pkg/queue"
+	"alert-webhooks/pkg/routing"
 	"alert-webhooks/pkg/service"
+	"alert-webhooks/pkg/silence"
+	"alert-webhooks/pkg/template"
 	"alert-webhooks/pkg/trace"
 	"alert-webhooks/pkg/watcher"
 	"alert-webhooks/routes"
 	"context"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
@@ -15,11 +26,16 @@ import (
 	//"strings"
 	"syscall"
 	"time"
+
 	//"github.com/gin-gonic/gin"
+	"github.com/spf13/pflag"
 )
 
 const (
 	shutdownTimeout = 5 * time.Second
+
+	// validateTemplatesCommand 驗證模板的子命令名稱
+	validateTemplatesCommand = "validate-templates"
 )
 
 var mainString = "main"
@@ -63,6 +79,12 @@ func main() {
 	// 初始化日誌系統
 	logger.InitLogger(config.Log.Level, config.IsDevelopment())
 
+	// validate-templates 子命令：驗證模板後結束，不啟動服務
+	if pflag.Arg(0) == validateTemplatesCommand {
+		logger.SetLevel("error")
+		os.Exit(runValidateTemplates())
+	}
+
 	// 初始化 OpenTelemetry TracerProvider
 	traceShutdown, err := trace.InitTracerProvider(
 		context.Background(),
@@ -86,6 +108,22 @@ func main() {
 		logger.Warn("Failed to initialize services, some features may not be available", mainString, logger.Err(err))
 	}
 
+	// 載入路由樹
+	if err := routing.Load(config.Routing); err != nil {
+		logger.Error("Failed to load routing tree", mainString, logger.Err(err))
+	}
+
+	// 載入通知去重窗口
+	dedup.Load(config.Dedup)
+
+	// 載入抑制規則
+	if err := inhibit.Load(config.Inhibit); err != nil {
+		logger.Error("Failed to load inhibit rules", mainString, logger.Err(err))
+	}
+
+	// 載入訊息參照配置
+	msgref.Load(config.MessageRef)
+
 	// 啟動配置檔案監控器
 	configWatcher := watcher.NewConfigWatcher()
 	ctx, cancel := context.WithCancel(context.Background())
@@ -97,6 +135,41 @@ func main() {
 		defer configWatcher.Stop()
 	}
 
+	// 啟動持久化投遞佇列（可選）
+	if config.Queue.Enable {
+		deliveryQueue, err := queue.Open(config.Queue, deliverQueuedNotification)
+		if err != nil {
+			logger.Error("Failed to open delivery queue, async delivery disabled", mainString, logger.Err(err))
+		} else {
+			deliveryQueue.Start(ctx)
+			queue.SetQueue(deliveryQueue)
+			defer deliveryQueue.Stop()
+		}
+	}
+
+	// 開啟本地靜音存放區（可選）
+	if config.Silence.Enable {
+		silenceStore, err := silence.Open(config.Silence)
+		if err != nil {
+			logger.Error("Failed to open silence store, local silences disabled", mainString, logger.Err(err))
+		} else {
+			silence.SetStore(silenceStore)
+			defer silenceStore.Close()
+		}
+	}
+
+	// 開啟互動按鈕目標與訊息參照存放區（actions.db），重啟後已發送的按鈕、resolved 編輯與討論串仍可使用
+	if err := actions.Open(config.Actions); err != nil {
+		logger.Error("Failed to open action store, buttons and message references will not survive a restart", mainString, logger.Err(err))
+	} else {
+		defer actions.Close()
+		if err := msgref.Open(actions.DB()); err != nil {
+			logger.Error("Failed to open message reference store, references will not survive a restart", mainString, logger.Err(err))
+		} else {
+			defer msgref.Close()
+		}
+	}
+
 	// 記錄應用啟動信息
 	logger.Info("Starting application...", mainString,
 		logger.String("mode", config.App.Mode),
@@ -107,6 +180,36 @@ func main() {
 	startHTTPServer()
 }
 
+// deliverQueuedNotification 透過 NotificationManager 投遞佇列中的任務，並回填渲染後的訊息供死信紀錄使用
+func deliverQueuedNotification(ctx context.Context, job *queue.Job) error {
+	req := job.Request()
+	_, err := notification.GetNotificationManager().SendNotification(ctx, job.Provider, req)
+	job.RenderedMessage = req.Message
+	return err
+}
+
+// runValidateTemplates 載入並驗證所有模板，將問題輸出到 stdout；
+// 有 error 等級的問題時返回 1，只有警告時返回 0
+func runValidateTemplates() int {
+	templateEngine, loaded := service.LoadTemplateEngine()
+	if !loaded {
+		fmt.Println("ERROR: no templates could be loaded from templates/alerts")
+		return 1
+	}
+
+	report := templateEngine.ValidateTemplates(providers.MessageLengthLimits())
+	for _, issue := range report.Issues {
+		fmt.Println(issue.String())
+	}
+	fmt.Printf("Checked %d templates with %d renders (%d fixtures): %d errors, %d warnings\n",
+		report.Templates, report.Renders, len(template.ValidationFixtures()), report.Errors(), report.Warnings())
+
+	if report.Errors() > 0 {
+		return 1
+	}
+	return 0
+}
+
 // startHTTPServer 啟動HTTP服務並處理優雅關閉
 func startHTTPServer() {
 	port := config.App.Port
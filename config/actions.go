@@ -0,0 +1,47 @@
+package config
+
+import "time"
+
+// ActionsConf 通知互動按鈕配置（Ack / Silence / Runbook）
+type ActionsConf struct {
+	Alertmanager      AlertmanagerAPIConf `mapstructure:"alertmanager" json:"alertmanager"`             // 建立靜音使用的 Alertmanager v2 API，未設定 url 時使用本地靜音
+	RunbookAnnotation string              `mapstructure:"runbook_annotation" json:"runbook_annotation"` // Runbook 按鈕使用的 annotation，預設 runbook_url
+	TargetTTL         time.Duration       `mapstructure:"target_ttl" json:"target_ttl"`                 // 按鈕對應的警報資訊保留時間，預設 72h
+	DataDir           string              `mapstructure:"data_dir" json:"data_dir"`                     // 按鈕目標與訊息參照資料目錄（bbolt 檔案 actions.db），預設 ./data
+	Telegram          TelegramActionsConf `mapstructure:"telegram" json:"telegram"`
+	Slack             SlackActionsConf    `mapstructure:"slack" json:"slack"`
+	Discord           DiscordActionsConf  `mapstructure:"discord" json:"discord"`
+}
+
+// AlertmanagerAPIConf Alertmanager API 連線配置
+type AlertmanagerAPIConf struct {
+	URL      string        `mapstructure:"url" json:"url"` // 例如 http://alertmanager:9093
+	Username string        `mapstructure:"username" json:"username"`
+	Password string        `mapstructure:"password" json:"password"`
+	Timeout  time.Duration `mapstructure:"timeout" json:"timeout"` // 預設 10s
+}
+
+// TelegramActionsConf Telegram inline keyboard 配置
+type TelegramActionsConf struct {
+	Enable        bool   `mapstructure:"enable" json:"enable"`                 // 是否在觸發中的警報附加 inline keyboard
+	Mode          string `mapstructure:"mode" json:"mode"`                     // 接收 callback 的方式：polling（預設）或 webhook
+	WebhookURL    string `mapstructure:"webhook_url" json:"webhook_url"`       // webhook 模式下向 Telegram 註冊的 URL（指向 /api/v1/telegram/updates），留空則不自動註冊
+	WebhookSecret string `mapstructure:"webhook_secret" json:"webhook_secret"` // webhook 模式必填，驗證 X-Telegram-Bot-Api-Secret-Token 標頭
+}
+
+// SlackActionsConf Slack Block Kit 按鈕配置
+type SlackActionsConf struct {
+	Enable          bool          `mapstructure:"enable" json:"enable"`                     // 是否在觸發中的警報附加 Block Kit 按鈕
+	SigningSecret   string        `mapstructure:"signing_secret" json:"signing_secret"`     // Slack App 的 Signing Secret，用於驗證 /api/v1/slack/interactions
+	TimestampWindow time.Duration `mapstructure:"timestamp_window" json:"timestamp_window"` // 允許的 X-Slack-Request-Timestamp 誤差，預設 5m，防止重放
+}
+
+// DiscordActionsConf Discord 訊息元件（按鈕）配置
+type DiscordActionsConf struct {
+	Enable          bool          `mapstructure:"enable" json:"enable"`                     // 是否在觸發中的警報附加按鈕元件
+	PublicKey       string        `mapstructure:"public_key" json:"public_key"`             // Discord 應用程式的 Public Key（hex），用於驗證 /api/v1/discord/interactions 的 Ed25519 簽章
+	TimestampWindow time.Duration `mapstructure:"timestamp_window" json:"timestamp_window"` // 允許的 X-Signature-Timestamp 誤差，預設 5m，防止重放
+}
+
+// Actions 是全局互動按鈕配置
+var Actions ActionsConf
@@ -18,26 +18,44 @@ var (
 
 // Conf 是全局配置的容器，為了保持向後兼容
 var Conf struct {
-	App      AppConf
-	Metric   MetricConf
-	Trace    TraceConf
-	Log      LogConf
-	Telegram TelegramConf
-	Webhooks WebhooksConf
-	Slack    SlackConf
-	Discord  DiscordConf
+	App        AppConf
+	Metric     MetricConf
+	Trace      TraceConf
+	Log        LogConf
+	Telegram   TelegramConf
+	Webhooks   WebhooksConf
+	Slack      SlackConf
+	Discord    DiscordConf
+	Routing    RoutingConf
+	Queue      QueueConf
+	RateLimit  RateLimitConf
+	Dedup      DedupConf
+	Silence    SilenceConf
+	Inhibit    InhibitConf
+	Actions    ActionsConf
+	MessageRef MessageRefConf
+	TimeFormat TimeFormatConf
 }
 
 // 內部使用的配置結構體
 type configStruct struct {
-	App      AppConf      `mapstructure:"app" json:"app"`
-	Metric   MetricConf   `mapstructure:"metric" json:"metric"`
-	Trace    TraceConf    `mapstructure:"trace" json:"trace"`
-	Log      LogConf      `mapstructure:"log" json:"log"`
-	Telegram TelegramConf `mapstructure:"telegram" json:"telegram"`
-	Webhooks WebhooksConf `mapstructure:"webhooks" json:"webhooks"`
-	Slack    SlackConf    `mapstructure:"slack" json:"slack"`
-	Discord  DiscordConf  `mapstructure:"discord" json:"discord"`
+	App        AppConf        `mapstructure:"app" json:"app"`
+	Metric     MetricConf     `mapstructure:"metric" json:"metric"`
+	Trace      TraceConf      `mapstructure:"trace" json:"trace"`
+	Log        LogConf        `mapstructure:"log" json:"log"`
+	Telegram   TelegramConf   `mapstructure:"telegram" json:"telegram"`
+	Webhooks   WebhooksConf   `mapstructure:"webhooks" json:"webhooks"`
+	Slack      SlackConf      `mapstructure:"slack" json:"slack"`
+	Discord    DiscordConf    `mapstructure:"discord" json:"discord"`
+	Routing    RoutingConf    `mapstructure:"routing" json:"routing"`
+	Queue      QueueConf      `mapstructure:"queue" json:"queue"`
+	RateLimit  RateLimitConf  `mapstructure:"rate_limit" json:"rate_limit"`
+	Dedup      DedupConf      `mapstructure:"dedup" json:"dedup"`
+	Silence    SilenceConf    `mapstructure:"silence" json:"silence"`
+	Inhibit    InhibitConf    `mapstructure:"inhibit" json:"inhibit"`
+	Actions    ActionsConf    `mapstructure:"actions" json:"actions"`
+	MessageRef MessageRefConf `mapstructure:"message_ref" json:"message_ref"`
+	TimeFormat TimeFormatConf `mapstructure:"time_format" json:"time_format"`
 }
 
 type TraceConf struct {
@@ -240,6 +258,15 @@ func updateGlobalConfigs() {
 	Telegram = confInternal.Telegram
 	Webhooks = confInternal.Webhooks
 	Slack = confInternal.Slack
+	Routing = confInternal.Routing
+	Queue = confInternal.Queue
+	RateLimit = confInternal.RateLimit
+	Dedup = confInternal.Dedup
+	Silence = confInternal.Silence
+	Inhibit = confInternal.Inhibit
+	Actions = confInternal.Actions
+	MessageRef = confInternal.MessageRef
+	TimeFormat = confInternal.TimeFormat
 
 	// 更新 Conf 結構體
 	Conf.App = confInternal.App
@@ -250,6 +277,15 @@ func updateGlobalConfigs() {
 	Conf.Webhooks = confInternal.Webhooks
 	Conf.Slack = confInternal.Slack
 	Conf.Discord = confInternal.Discord
+	Conf.Routing = confInternal.Routing
+	Conf.Queue = confInternal.Queue
+	Conf.RateLimit = confInternal.RateLimit
+	Conf.Dedup = confInternal.Dedup
+	Conf.Silence = confInternal.Silence
+	Conf.Inhibit = confInternal.Inhibit
+	Conf.Actions = confInternal.Actions
+	Conf.MessageRef = confInternal.MessageRef
+	Conf.TimeFormat = confInternal.TimeFormat
 }
 
 // GetFullConfig 返回完整配置，對於需要訪問完整配置的情況
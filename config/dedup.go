@@ -0,0 +1,12 @@
+package config
+
+import "time"
+
+// DedupConf 通知去重配置，抑制 Alertmanager repeat_interval 重送與 HA 成對投遞造成的重複通知
+type DedupConf struct {
+	Enable bool          `mapstructure:"enable" json:"enable"` // 是否啟用去重
+	Window time.Duration `mapstructure:"window" json:"window"` // 去重時間窗口，窗口內相同鍵值的通知只發送一次（預設 5m）
+}
+
+// Dedup 是全局去重配置
+var Dedup DedupConf
@@ -11,10 +11,15 @@ type DiscordConf struct {
 	Channels map[string]string `json:"channels" yaml:"channels" mapstructure:"channels"`
 	
 	// Discord specific options
-	MessageFormat string   `json:"message_format" yaml:"message_format" mapstructure:"message_format"` // Message format (markdown/text)
+	MessageFormat string   `json:"message_format" yaml:"message_format" mapstructure:"message_format"` // Message format (markdown/embed)
 	MentionRoles  []string `json:"mention_roles" yaml:"mention_roles" mapstructure:"mention_roles"`    // Role IDs to mention
+	EmbedMode     string   `json:"embed_mode" yaml:"embed_mode" mapstructure:"embed_mode"`             // Embed layout: alert (one embed per alert) or group
+	EmbedFields   []string `json:"embed_fields" yaml:"embed_fields" mapstructure:"embed_fields"`       // Labels shown as inline embed fields (empty: all labels)
 	
 	// Template configuration
 	TemplateMode     string `json:"template_mode" yaml:"template_mode" mapstructure:"template_mode"`           // Template mode (minimal/full)
 	TemplateLanguage string `json:"template_language" yaml:"template_language" mapstructure:"template_language"` // Template language (eng/tw/zh/ja/ko)
+	TemplateName     string `json:"template_name" yaml:"template_name" mapstructure:"template_name"`             // Named template set (templates/alerts subdirectory), empty for the default set
+	LevelTemplates   map[string]string `json:"level_templates" yaml:"level_templates" mapstructure:"level_templates"` // Level -> named template set, overrides template_name
+	TimeFormat       TimeFormatConf    `json:"time_format" yaml:"time_format" mapstructure:"time_format"`             // Template time zone and layout, overrides the global time_format
 }
\ No newline at end of file
@@ -0,0 +1,19 @@
+package config
+
+import "time"
+
+// InhibitConf 抑制規則配置，與 Alertmanager 的 inhibit_rules 相同語意
+type InhibitConf struct {
+	Rules     []InhibitRuleConf `mapstructure:"rules" json:"rules"`           // 抑制規則
+	FiringTTL time.Duration     `mapstructure:"firing_ttl" json:"firing_ttl"` // 未收到 resolved 時觸發中警報在索引中的保留時間，預設 4h（建議不小於 repeat_interval）
+}
+
+// InhibitRuleConf 單一抑制規則：來源警報觸發時，抑制 equal 標籤相同的目標警報
+type InhibitRuleConf struct {
+	SourceMatchers []string `mapstructure:"source_matchers" json:"source_matchers"` // 來源警報匹配器，例如 severity="critical"
+	TargetMatchers []string `mapstructure:"target_matchers" json:"target_matchers"` // 目標警報匹配器，例如 severity="warning"
+	Equal          []string `mapstructure:"equal" json:"equal"`                     // 來源與目標必須相同的標籤，例如 instance
+}
+
+// Inhibit 是全局抑制配置
+var Inhibit InhibitConf
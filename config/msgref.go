@@ -0,0 +1,12 @@
+package config
+
+import "time"
+
+// MessageRefConf 訊息參照配置：記錄每個警報群組發送到各提供者的訊息 ID
+type MessageRefConf struct {
+	EditOnResolve bool          `mapstructure:"edit_on_resolve" json:"edit_on_resolve"` // resolved 時編輯原本的 firing 訊息，而不是發送新訊息
+	TTL           time.Duration `mapstructure:"ttl" json:"ttl"`                         // 訊息參照保留時間，預設 24h，超過後 resolved 會發送新訊息
+}
+
+// MessageRef 是全局訊息參照配置
+var MessageRef MessageRefConf
@@ -0,0 +1,18 @@
+package config
+
+import "time"
+
+// QueueConf 持久化投遞佇列配置
+type QueueConf struct {
+	Enable         bool          `mapstructure:"enable" json:"enable"`                   // 是否啟用持久化佇列（啟用後發送端點支援 async=true）
+	DataDir        string        `mapstructure:"data_dir" json:"data_dir"`               // 佇列資料目錄，預設 ./data
+	Workers        int           `mapstructure:"workers" json:"workers"`                 // 投遞 worker 數量，預設 4
+	MaxAttempts    int           `mapstructure:"max_attempts" json:"max_attempts"`       // 最大嘗試次數，預設 8
+	MaxDeferrals   int           `mapstructure:"max_deferrals" json:"max_deferrals"`     // 因提供者速率限制延後的最大次數（不計入嘗試次數），預設 20
+	InitialBackoff time.Duration `mapstructure:"initial_backoff" json:"initial_backoff"` // 第一次重試的等待時間，預設 2s
+	MaxBackoff     time.Duration `mapstructure:"max_backoff" json:"max_backoff"`         // 重試等待時間上限，預設 5m
+	SendTimeout    time.Duration `mapstructure:"send_timeout" json:"send_timeout"`       // 單次投遞逾時，預設 30s
+}
+
+// Queue 是全局佇列配置
+var Queue QueueConf
@@ -0,0 +1,20 @@
+package config
+
+import "time"
+
+// RateLimitConf 提供者速率限制配置
+type RateLimitConf struct {
+	MaxWait  time.Duration `mapstructure:"max_wait" json:"max_wait"` // 發送前最多同步等待的時間，超過則回傳速率限制錯誤由佇列延後重試，預設 10s
+	Telegram BucketConf    `mapstructure:"telegram" json:"telegram"` // 每個 chat 的 token bucket，預設 20 則/分鐘
+	Slack    BucketConf    `mapstructure:"slack" json:"slack"`       // 每個頻道的 token bucket，預設 60 則/分鐘
+	Discord  BucketConf    `mapstructure:"discord" json:"discord"`   // 每個頻道的 token bucket，預設 60 則/分鐘
+}
+
+// BucketConf token bucket 配置
+type BucketConf struct {
+	PerMinute int `mapstructure:"per_minute" json:"per_minute"` // 每分鐘補充的 token 數量，0 使用預設值，負數停用
+	Burst     int `mapstructure:"burst" json:"burst"`           // 可累積的 token 上限（突發量），0 使用預設值
+}
+
+// RateLimit 是全局速率限制配置
+var RateLimit RateLimitConf
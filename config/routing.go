@@ -0,0 +1,26 @@
+package config
+
+// RoutingConf 路由樹配置，結構與 Alertmanager 的 route 類似
+type RoutingConf struct {
+	Enable bool      `mapstructure:"enable" json:"enable"` // 是否啟用路由樹（啟用後 /alertmanager 依路由決定目的地）
+	Route  RouteConf `mapstructure:"route" json:"route"`   // 根路由，匹配所有警報群組
+}
+
+// RouteConf 單一路由節點
+type RouteConf struct {
+	Matchers  []string       `mapstructure:"matchers" json:"matchers"`   // 標籤匹配器，支援 =、!=、=~、!~
+	Receivers []ReceiverConf `mapstructure:"receivers" json:"receivers"` // 此節點的接收者，未設定時沿用父節點
+	Continue  bool           `mapstructure:"continue" json:"continue"`   // 匹配後是否繼續比對後續的兄弟節點
+	Routes    []RouteConf    `mapstructure:"routes" json:"routes"`       // 子路由
+}
+
+// ReceiverConf 路由接收者（提供者 + 等級或頻道）
+type ReceiverConf struct {
+	Provider string `mapstructure:"provider" json:"provider"` // telegram, slack, discord
+	Level    string `mapstructure:"level" json:"level"`       // 例如 L0、L2
+	Channel  string `mapstructure:"channel" json:"channel"`   // Slack 頻道或 Discord 頻道 ID（優先於 level）
+	Template string `mapstructure:"template" json:"template"` // 具名模板集，優先於提供者與 level 的模板設定
+}
+
+// Routing 是全局路由配置
+var Routing RoutingConf
@@ -0,0 +1,13 @@
+package config
+
+import "time"
+
+// SilenceConf 本地靜音配置，靜音以 bbolt 持久化
+type SilenceConf struct {
+	Enable    bool          `mapstructure:"enable" json:"enable"`       // 是否啟用本地靜音（啟用後提供 /api/v1/silences）
+	DataDir   string        `mapstructure:"data_dir" json:"data_dir"`   // 靜音資料目錄，預設 ./data
+	Retention time.Duration `mapstructure:"retention" json:"retention"` // 過期靜音保留時間，預設 120h
+}
+
+// Silence 是全局靜音配置
+var Silence SilenceConf
@@ -8,12 +8,17 @@ type SlackConf struct {
 	IconURL       string            `mapstructure:"icon_url" json:"icon_url"`           // Bot 頭像 URL
 	IconEmoji     string            `mapstructure:"icon_emoji" json:"icon_emoji"`       // Bot 表情符號
 	Channels      map[string]string `mapstructure:"channels" json:"channels"`          // 多頻道支持 (level -> channel)
-	ThreadTS      bool              `mapstructure:"thread_ts" json:"thread_ts"`        // 是否使用線程回覆
+	ThreadTS      bool              `mapstructure:"thread_ts" json:"thread_ts"`        // 是否使用線程回覆：同一警報群組的後續通知回覆到 firing 訊息的 thread
+	ReplyBroadcast bool             `mapstructure:"reply_broadcast" json:"reply_broadcast"` // thread 回覆是否同時發送到頻道
 	LinkNames     bool              `mapstructure:"link_names" json:"link_names"`      // 是否連結 @mentions
 	UnfurlLinks   bool              `mapstructure:"unfurl_links" json:"unfurl_links"`  // 是否展開連結預覽
 	UnfurlMedia   bool              `mapstructure:"unfurl_media" json:"unfurl_media"`  // 是否展開媒體預覽
-	TemplateMode  string            `mapstructure:"template_mode" json:"template_mode"`  // 模板模式 (minimal, full)
+	TemplateMode  string            `mapstructure:"template_mode" json:"template_mode"`  // 模板模式 (minimal, full, blocks)
+	BlockFields   []string          `mapstructure:"block_fields" json:"block_fields"`    // blocks 模式下 fields 欄位格顯示的標籤
 	TemplateLanguage string            `mapstructure:"template_language" json:"template_language"` // 模板語言 (eng, tw, zh, ja, ko)	
+	TemplateName     string            `mapstructure:"template_name" json:"template_name"`         // 具名模板集（templates/alerts 子目錄），空值使用預設模板
+	LevelTemplates   map[string]string `mapstructure:"level_templates" json:"level_templates"`     // level -> 具名模板集，優先於 template_name
+	TimeFormat       TimeFormatConf    `mapstructure:"time_format" json:"time_format"`             // 模板時間格式，優先於全局 time_format
 }
 
 var Slack SlackConf
\ No newline at end of file
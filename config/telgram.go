@@ -15,6 +15,10 @@ type TelegramConf struct {
 	ChatIDs6 string `mapstructure:"chat_ids6" json:"chat_ids6"`
 	TemplateMode string `mapstructure:"template_mode" json:"template_mode"`
 	TemplateLanguage string `mapstructure:"template_language" json:"template_language"`
+	NamespaceTopics map[string]int `mapstructure:"namespace_topics" json:"namespace_topics"` // namespace -> forum topic ID，優先於 chat_ids 中的 topic
+	TemplateName string `mapstructure:"template_name" json:"template_name"` // 具名模板集（templates/alerts 子目錄），空值使用預設模板
+	LevelTemplates map[string]string `mapstructure:"level_templates" json:"level_templates"` // level（L0-L6）-> 具名模板集，優先於 template_name
+	TimeFormat TimeFormatConf `mapstructure:"time_format" json:"time_format"` // 模板時間格式，優先於全局 time_format
 }
 
 
@@ -0,0 +1,19 @@
+package config
+
+// TimeFormatConf 模板時間格式配置：format_time 使用的時區與時間 layout，可依 level 與語言覆寫。
+// 全局的 time_format 適用於所有提供者，telegram / slack / discord 區塊中的 time_format 優先於全局配置
+type TimeFormatConf struct {
+	Timezone  string                    `mapstructure:"timezone" json:"timezone"`   // IANA 時區，例如 Asia/Taipei；空值保留警報時間本身的時區
+	Layout    string                    `mapstructure:"layout" json:"layout"`       // Go 時間 layout，預設 2006-01-02 15:04:05
+	Languages map[string]TimeFormatRule `mapstructure:"languages" json:"languages"` // 語言（eng, tw, zh, ja, ko）-> 時區與 layout
+	Levels    map[string]TimeFormatRule `mapstructure:"levels" json:"levels"`       // level（L0-L6）-> 時區與 layout，優先於 languages
+}
+
+// TimeFormatRule 單一語言或 level 的時區與 layout，空值沿用下一個優先順序的設定
+type TimeFormatRule struct {
+	Timezone string `mapstructure:"timezone" json:"timezone"`
+	Layout   string `mapstructure:"layout" json:"layout"`
+}
+
+// TimeFormat 是全局模板時間格式配置
+var TimeFormat TimeFormatConf
@@ -0,0 +1,317 @@
+// Package actions 提供通知互動按鈕（Ack、Silence、Runbook）的共用邏輯：
+// 記錄按鈕對應的警報群組（以 bbolt 持久化，重啟後已發送的按鈕仍可使用），並在按下靜音時透過 Alertmanager v2 API 或本地靜音建立靜音
+package actions
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/matcher"
+	"alert-webhooks/pkg/notification/types"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	dbFileName = "actions.db"
+
+	defaultDataDir           = "./data"
+	defaultTargetTTL         = 72 * time.Hour
+	defaultRunbookAnnotation = "runbook_url"
+)
+
+var targetBucket = []byte("targets")
+
+// Action 按鈕動作
+type Action string
+
+// 支援的按鈕動作
+const (
+	ActionAck       Action = "ack"
+	ActionSilence1h Action = "s1h"
+	ActionSilence1d Action = "s24h"
+)
+
+// Duration 返回靜音動作的持續時間，非靜音動作返回 0
+func (a Action) Duration() time.Duration {
+	switch a {
+	case ActionSilence1h:
+		return time.Hour
+	case ActionSilence1d:
+		return 24 * time.Hour
+	}
+	return 0
+}
+
+// Label 返回按鈕顯示文字
+func (a Action) Label() string {
+	switch a {
+	case ActionAck:
+		return "✅ Ack"
+	case ActionSilence1h:
+		return "🔕 Silence 1h"
+	case ActionSilence1d:
+		return "🔕 Silence 24h"
+	}
+	return string(a)
+}
+
+// Target 按鈕對應的警報群組
+type Target struct {
+	Token      string            `json:"token"`
+	GroupKey   string            `json:"group_key"`
+	AlertName  string            `json:"alert_name"`
+	Labels     map[string]string `json:"labels"` // 建立靜音時使用的標籤（群組共同標籤）
+	RunbookURL string            `json:"runbook_url,omitempty"`
+	AckedBy    string            `json:"acked_by,omitempty"`
+	SilencedBy string            `json:"silenced_by,omitempty"`
+	SilenceID  string            `json:"silence_id,omitempty"`
+	ExpiresAt  time.Time         `json:"expires_at"`
+}
+
+// Actions 返回目前仍可使用的按鈕動作：Ack 後不再顯示 Ack，靜音後不再顯示任何動作
+func (t *Target) Actions() []Action {
+	if t.SilencedBy != "" {
+		return nil
+	}
+	var actions []Action
+	if t.AckedBy == "" {
+		actions = append(actions, ActionAck)
+	}
+	if len(t.Labels) > 0 {
+		actions = append(actions, ActionSilence1h, ActionSilence1d)
+	}
+	return actions
+}
+
+var (
+	mu      sync.Mutex
+	targets = make(map[string]*Target)
+	db      *bolt.DB // 未開啟時按鈕目標只保存在記憶體中
+	lastGC  time.Time
+)
+
+// Open 開啟（或建立）資料目錄下的按鈕目標資料庫並載入未過期的目標
+func Open(conf config.ActionsConf) error {
+	dataDir := conf.DataDir
+	if dataDir == "" {
+		dataDir = defaultDataDir
+	}
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create actions data dir: %v", err)
+	}
+
+	store, err := bolt.Open(filepath.Join(dataDir, dbFileName), 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return fmt.Errorf("failed to open actions database: %v", err)
+	}
+
+	now := time.Now()
+	loaded := make(map[string]*Target)
+	if err := store.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(targetBucket)
+		if err != nil {
+			return err
+		}
+		var expired [][]byte
+		err = bucket.ForEach(func(k, v []byte) error {
+			target := &Target{}
+			if err := json.Unmarshal(v, target); err != nil || now.After(target.ExpiresAt) {
+				expired = append(expired, append([]byte(nil), k...))
+				return nil
+			}
+			loaded[target.Token] = target
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		store.Close()
+		return fmt.Errorf("failed to load action targets: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	db = store
+	targets = loaded
+	lastGC = now
+
+	logger.Info("Action target store opened", "actions",
+		logger.String("path", filepath.Join(dataDir, dbFileName)),
+		logger.Int("targets", len(loaded)))
+	return nil
+}
+
+// Close 關閉按鈕目標資料庫，之後的目標只保存在記憶體中
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+	if db == nil {
+		return nil
+	}
+	err := db.Close()
+	db = nil
+	return err
+}
+
+// DB 返回已開啟的資料庫，未開啟時返回 nil；訊息參照（msgref）也儲存在此資料庫
+func DB() *bolt.DB {
+	mu.Lock()
+	defer mu.Unlock()
+	return db
+}
+
+// save 將按鈕目標寫入資料庫（需持有鎖），資料庫未開啟時不做任何事
+func save(target *Target) {
+	if db == nil {
+		return
+	}
+	data, err := json.Marshal(target)
+	if err == nil {
+		err = db.Update(func(tx *bolt.Tx) error {
+			return tx.Bucket(targetBucket).Put([]byte(target.Token), data)
+		})
+	}
+	if err != nil {
+		logger.Error("Failed to persist action target", "actions",
+			logger.String("token", target.Token),
+			logger.Err(err))
+	}
+}
+
+// Register 為觸發中的警報群組登記按鈕目標，未觸發或沒有數據時返回 nil
+func Register(data *types.AlertManagerData) *Target {
+	if data == nil || data.Status != "firing" || len(data.Alerts) == 0 {
+		return nil
+	}
+
+	labels := matcher.LabelsFromMap(data.CommonLabels)
+	if len(labels) == 0 {
+		labels = matcher.LabelsFromMap(data.GroupLabels)
+	}
+
+	target := &Target{
+		Token:      newToken(),
+		GroupKey:   data.GroupKey,
+		AlertName:  labels["alertname"],
+		Labels:     labels,
+		RunbookURL: runbookURL(data),
+		ExpiresAt:  time.Now().Add(targetTTL()),
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	gc(time.Now())
+	targets[target.Token] = target
+	save(target)
+
+	return target
+}
+
+// Lookup 依 token 查詢按鈕目標，返回副本
+func Lookup(token string) (*Target, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	target, ok := targets[token]
+	if !ok || time.Now().After(target.ExpiresAt) {
+		return nil, false
+	}
+	c := *target
+	return &c, true
+}
+
+// update 在鎖內修改按鈕目標
+func update(token string, fn func(t *Target)) (*Target, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	target, ok := targets[token]
+	if !ok || time.Now().After(target.ExpiresAt) {
+		return nil, false
+	}
+	fn(target)
+	save(target)
+	c := *target
+	return &c, true
+}
+
+// gc 清除過期的按鈕目標（需持有鎖）
+func gc(now time.Time) {
+	if now.Sub(lastGC) < time.Hour {
+		return
+	}
+	lastGC = now
+	var expired []string
+	for token, target := range targets {
+		if now.After(target.ExpiresAt) {
+			delete(targets, token)
+			expired = append(expired, token)
+		}
+	}
+	if db == nil || len(expired) == 0 {
+		return
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(targetBucket)
+		for _, token := range expired {
+			if err := bucket.Delete([]byte(token)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		logger.Error("Failed to delete expired action targets", "actions", logger.Err(err))
+	}
+}
+
+// runbookURL 從共同 annotation 或第一個帶有 runbook 的警報取得 Runbook 連結
+func runbookURL(data *types.AlertManagerData) string {
+	annotation := config.Actions.RunbookAnnotation
+	if annotation == "" {
+		annotation = defaultRunbookAnnotation
+	}
+
+	if url, _ := data.CommonAnnotations[annotation].(string); url != "" {
+		return url
+	}
+	for _, alert := range data.Alerts {
+		annotations, _ := alert["annotations"].(map[string]interface{})
+		if url, _ := annotations[annotation].(string); url != "" {
+			return url
+		}
+	}
+	return ""
+}
+
+// targetTTL 返回按鈕目標的保留時間
+func targetTTL() time.Duration {
+	if config.Actions.TargetTTL > 0 {
+		return config.Actions.TargetTTL
+	}
+	return defaultTargetTTL
+}
+
+// newToken 產生按鈕目標 token（需符合 Telegram callback_data 64 位元組限制）
+func newToken() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%016x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,52 @@
+package actions
+
+import (
+	"testing"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/notification/types"
+)
+
+func TestTargetsSurviveReopen(t *testing.T) {
+	conf := config.ActionsConf{DataDir: t.TempDir()}
+	if err := Open(conf); err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+
+	target := Register(&types.AlertManagerData{
+		Status:       "firing",
+		GroupKey:     `{}:{alertname="HighCPU"}`,
+		CommonLabels: map[string]interface{}{"alertname": "HighCPU", "env": "prod"},
+		Alerts:       []map[string]interface{}{{"status": "firing"}},
+	})
+	if target == nil {
+		t.Fatal("Register returned nil for a firing group")
+	}
+	if _, ok := update(target.Token, func(t *Target) { t.AckedBy = "alice" }); !ok {
+		t.Fatal("update returned false for a registered target")
+	}
+
+	// 模擬重啟：關閉資料庫並清空記憶體中的目標
+	if err := Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	mu.Lock()
+	targets = make(map[string]*Target)
+	mu.Unlock()
+	if _, ok := Lookup(target.Token); ok {
+		t.Fatal("Lookup found the target after clearing memory")
+	}
+
+	if err := Open(conf); err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer Close()
+
+	got, ok := Lookup(target.Token)
+	if !ok {
+		t.Fatal("Lookup did not find the target after reopening")
+	}
+	if got.AlertName != "HighCPU" || got.Labels["env"] != "prod" || got.AckedBy != "alice" {
+		t.Errorf("Lookup after reopen = %+v, want alertname HighCPU, env prod and acked by alice", got)
+	}
+}
@@ -0,0 +1,145 @@
+package actions
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/notification/types"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// DiscordCustomIDPrefix prefixes button custom_id values, formatted as act:<action>:<token>
+const DiscordCustomIDPrefix = "act:"
+
+const (
+	discordMessageLimit = 2000
+
+	defaultDiscordTimestampWindow = 5 * time.Minute
+)
+
+// DiscordEnabled reports whether Discord action buttons are enabled
+func DiscordEnabled() bool {
+	return config.Actions.Discord.Enable
+}
+
+// DiscordComponents registers a button target for a firing alert group and returns its button row;
+// returns nil when disabled or the group is not firing
+func DiscordComponents(data *types.AlertManagerData) []discordgo.MessageComponent {
+	if !DiscordEnabled() {
+		return nil
+	}
+	target := Register(data)
+	if target == nil {
+		return nil
+	}
+	return discordComponents(target)
+}
+
+// DiscordUpdate builds the edited message content and components after an action:
+// the actor line is appended to the original content and buttons that no longer apply are removed
+func DiscordUpdate(content string, result *Result) (string, []discordgo.MessageComponent) {
+	suffix := "\n\n" + result.Summary
+	if content == "" {
+		// Embed messages have no text content
+		suffix = result.Summary
+	}
+	if runes := []rune(content); len(runes)+len([]rune(suffix)) > discordMessageLimit {
+		keep := discordMessageLimit - len([]rune(suffix)) - 1
+		if keep < 0 {
+			keep = 0
+		}
+		content = string(runes[:keep]) + "…"
+	}
+
+	components := discordComponents(result.Target)
+	if components == nil {
+		// An empty (non-nil) slice clears the buttons when editing
+		components = []discordgo.MessageComponent{}
+	}
+	return content + suffix, components
+}
+
+// ParseDiscordCustomID splits a button custom_id into its action and target token
+func ParseDiscordCustomID(customID string) (Action, string, bool) {
+	if !strings.HasPrefix(customID, DiscordCustomIDPrefix) {
+		return "", "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(customID, DiscordCustomIDPrefix), ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", "", false
+	}
+	return Action(parts[0]), parts[1], true
+}
+
+// VerifyDiscordSignature verifies the X-Signature-Ed25519 header (signature over timestamp + body)
+// against the configured application public key; requests whose X-Signature-Timestamp is further
+// than actions.discord.timestamp_window from now are rejected to prevent replay
+func VerifyDiscordSignature(body []byte, signature, timestamp string, now time.Time) error {
+	key, err := hex.DecodeString(config.Actions.Discord.PublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("discord public key is missing or invalid")
+	}
+
+	sig, err := hex.DecodeString(signature)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid X-Signature-Ed25519")
+	}
+	if timestamp == "" {
+		return fmt.Errorf("missing X-Signature-Timestamp")
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid X-Signature-Timestamp")
+	}
+
+	window := config.Actions.Discord.TimestampWindow
+	if window <= 0 {
+		window = defaultDiscordTimestampWindow
+	}
+	if diff := now.Sub(time.Unix(ts, 0)); diff > window || diff < -window {
+		return fmt.Errorf("request timestamp outside of the %s window", window)
+	}
+
+	message := make([]byte, 0, len(timestamp)+len(body))
+	message = append(message, timestamp...)
+	message = append(message, body...)
+
+	if !ed25519.Verify(ed25519.PublicKey(key), message, sig) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// discordComponents builds the button row for the actions still available on the target; returns nil when empty
+func discordComponents(target *Target) []discordgo.MessageComponent {
+	var buttons []discordgo.MessageComponent
+	for _, action := range target.Actions() {
+		style := discordgo.SecondaryButton
+		if action == ActionAck {
+			style = discordgo.SuccessButton
+		}
+		buttons = append(buttons, discordgo.Button{
+			Label:    action.Label(),
+			Style:    style,
+			CustomID: DiscordCustomIDPrefix + string(action) + ":" + target.Token,
+		})
+	}
+	if target.RunbookURL != "" {
+		buttons = append(buttons, discordgo.Button{
+			Label: "📖 Runbook",
+			Style: discordgo.LinkButton,
+			URL:   target.RunbookURL,
+		})
+	}
+
+	if len(buttons) == 0 {
+		return nil
+	}
+	return []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}}
+}
@@ -0,0 +1,74 @@
+package actions
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"alert-webhooks/config"
+)
+
+func TestVerifyDiscordSignature(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey returned error: %v", err)
+	}
+	_, otherKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey returned error: %v", err)
+	}
+	sign := func(key ed25519.PrivateKey, timestamp string, body []byte) string {
+		return hex.EncodeToString(ed25519.Sign(key, append([]byte(timestamp), body...)))
+	}
+
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"type":3,"data":{"custom_id":"act:ack:abc"}}`)
+	fresh := strconv.FormatInt(now.Unix(), 10)
+	stale := strconv.FormatInt(now.Add(-6*time.Minute).Unix(), 10)
+	future := strconv.FormatInt(now.Add(6*time.Minute).Unix(), 10)
+	key := hex.EncodeToString(publicKey)
+
+	tests := []struct {
+		name      string
+		publicKey string
+		window    time.Duration
+		body      []byte
+		timestamp string
+		signature string
+		err       string
+	}{
+		{"valid", key, 0, body, fresh, sign(privateKey, fresh, body), ""},
+		{"tampered body", key, 0, []byte(`{"type":1}`), fresh, sign(privateKey, fresh, body), "signature mismatch"},
+		{"signed by another key", key, 0, body, fresh, sign(otherKey, fresh, body), "signature mismatch"},
+		{"signature for another timestamp", key, 0, body, fresh, sign(privateKey, stale, body), "signature mismatch"},
+		{"malformed signature", key, 0, body, fresh, "not-hex", "invalid X-Signature-Ed25519"},
+		{"stale timestamp", key, 0, body, stale, sign(privateKey, stale, body), "outside of the 5m0s window"},
+		{"future timestamp", key, 0, body, future, sign(privateKey, future, body), "outside of the 5m0s window"},
+		{"stale timestamp within custom window", key, 10 * time.Minute, body, stale, sign(privateKey, stale, body), ""},
+		{"missing timestamp", key, 0, body, "", sign(privateKey, "", body), "missing X-Signature-Timestamp"},
+		{"invalid timestamp", key, 0, body, "yesterday", sign(privateKey, "yesterday", body), "invalid X-Signature-Timestamp"},
+		{"missing public key", "", 0, body, fresh, sign(privateKey, fresh, body), "public key is missing or invalid"},
+		{"truncated public key", key[:32], 0, body, fresh, sign(privateKey, fresh, body), "public key is missing or invalid"},
+	}
+
+	saved := config.Actions.Discord
+	defer func() { config.Actions.Discord = saved }()
+
+	for _, tt := range tests {
+		config.Actions.Discord.PublicKey = tt.publicKey
+		config.Actions.Discord.TimestampWindow = tt.window
+
+		err := VerifyDiscordSignature(tt.body, tt.signature, tt.timestamp, now)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: VerifyDiscordSignature returned error: %v", tt.name, err)
+		case tt.err != "" && err == nil:
+			t.Errorf("%s: VerifyDiscordSignature returned no error, want %q", tt.name, tt.err)
+		case tt.err != "" && !strings.Contains(err.Error(), tt.err):
+			t.Errorf("%s: VerifyDiscordSignature error = %q, want it to contain %q", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package actions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/matcher"
+	"alert-webhooks/pkg/silence"
+)
+
+const defaultAlertmanagerTimeout = 10 * time.Second
+
+// ErrTargetNotFound 按鈕目標不存在或已過期
+var ErrTargetNotFound = errors.New("alert is no longer tracked, the buttons have expired")
+
+// Result 按鈕動作的執行結果
+type Result struct {
+	Target  *Target
+	Summary string // 附加到原始訊息的說明，例如 "✅ Acked by alice"
+	Notice  string // 回覆給按下按鈕使用者的提示
+}
+
+// Perform 執行按鈕動作並記錄執行者
+func Perform(ctx context.Context, token string, action Action, user string) (*Result, error) {
+	target, ok := Lookup(token)
+	if !ok {
+		return nil, ErrTargetNotFound
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+
+	switch action {
+	case ActionAck:
+		if target.AckedBy != "" {
+			return &Result{Target: target, Notice: "Already acked by " + target.AckedBy}, nil
+		}
+		target, ok = update(token, func(t *Target) { t.AckedBy = user })
+		if !ok {
+			return nil, ErrTargetNotFound
+		}
+
+		logger.Info("Alert acknowledged", "actions",
+			logger.String("group_key", target.GroupKey),
+			logger.String("user", user))
+
+		return &Result{
+			Target:  target,
+			Summary: fmt.Sprintf("✅ Acked by %s at %s", user, now),
+			Notice:  "Acked",
+		}, nil
+
+	case ActionSilence1h, ActionSilence1d:
+		if target.SilencedBy != "" {
+			return &Result{Target: target, Notice: "Already silenced by " + target.SilencedBy}, nil
+		}
+
+		duration := action.Duration()
+		comment := fmt.Sprintf("Silenced from chat by %s", user)
+		if target.AlertName != "" {
+			comment = fmt.Sprintf("Silenced %s from chat by %s", target.AlertName, user)
+		}
+
+		id, err := CreateSilence(ctx, target.Labels, duration, user, comment)
+		if err != nil {
+			return nil, err
+		}
+
+		target, ok = update(token, func(t *Target) {
+			t.SilencedBy = user
+			t.SilenceID = id
+		})
+		if !ok {
+			return nil, ErrTargetNotFound
+		}
+
+		return &Result{
+			Target:  target,
+			Summary: fmt.Sprintf("🔕 Silenced for %s by %s at %s (silence %s)", formatDuration(duration), user, now, id),
+			Notice:  "Silenced for " + formatDuration(duration),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown action %q", action)
+}
+
+// CreateSilence 以等於匹配器建立靜音：配置 Alertmanager URL 時使用 v2 API，否則使用本地靜音
+func CreateSilence(ctx context.Context, labels map[string]string, duration time.Duration, createdBy, comment string) (string, error) {
+	if len(labels) == 0 {
+		return "", fmt.Errorf("alert group has no labels to silence")
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var id string
+	var err error
+	backend := "alertmanager"
+
+	if config.Actions.Alertmanager.URL != "" {
+		id, err = createAlertmanagerSilence(ctx, names, labels, duration, createdBy, comment)
+	} else if store := silence.GetStore(); store != nil {
+		backend = "local"
+		id, err = createLocalSilence(store, names, labels, duration, createdBy, comment)
+	} else {
+		return "", fmt.Errorf("no silence backend configured: set actions.alertmanager.url or enable silence")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	logger.Info("Silence created from notification action", "actions",
+		logger.String("backend", backend),
+		logger.String("silence_id", id),
+		logger.String("matchers", matcher.LabelsString(labels)),
+		logger.String("duration", duration.String()),
+		logger.String("created_by", createdBy))
+
+	return id, nil
+}
+
+// createLocalSilence 在本地靜音存放區建立靜音
+func createLocalSilence(store *silence.Store, names []string, labels map[string]string, duration time.Duration, createdBy, comment string) (string, error) {
+	matchers := make([]string, 0, len(names))
+	for _, name := range names {
+		m, err := matcher.NewMatcher(name, matcher.MatchEqual, labels[name])
+		if err != nil {
+			return "", err
+		}
+		matchers = append(matchers, m.String())
+	}
+
+	now := time.Now()
+	created, err := store.Create(&silence.Silence{
+		Matchers:  matchers,
+		StartsAt:  now,
+		EndsAt:    now.Add(duration),
+		CreatedBy: createdBy,
+		Comment:   comment,
+	})
+	if err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// alertmanagerMatcher Alertmanager v2 API 的匹配器
+type alertmanagerMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+// alertmanagerSilence Alertmanager v2 API 的靜音請求
+type alertmanagerSilence struct {
+	Matchers  []alertmanagerMatcher `json:"matchers"`
+	StartsAt  time.Time             `json:"startsAt"`
+	EndsAt    time.Time             `json:"endsAt"`
+	CreatedBy string                `json:"createdBy"`
+	Comment   string                `json:"comment"`
+}
+
+// createAlertmanagerSilence 透過 POST /api/v2/silences 建立靜音
+func createAlertmanagerSilence(ctx context.Context, names []string, labels map[string]string, duration time.Duration, createdBy, comment string) (string, error) {
+	conf := config.Actions.Alertmanager
+	timeout := conf.Timeout
+	if timeout <= 0 {
+		timeout = defaultAlertmanagerTimeout
+	}
+
+	now := time.Now().UTC()
+	body := alertmanagerSilence{
+		StartsAt:  now,
+		EndsAt:    now.Add(duration),
+		CreatedBy: createdBy,
+		Comment:   comment,
+	}
+	for _, name := range names {
+		body.Matchers = append(body.Matchers, alertmanagerMatcher{Name: name, Value: labels[name], IsEqual: true})
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode silence: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	url := strings.TrimSuffix(conf.URL, "/") + "/api/v2/silences"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to create Alertmanager request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if conf.Username != "" {
+		req.SetBasicAuth(conf.Username, conf.Password)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to call Alertmanager: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("alertmanager returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var result struct {
+		SilenceID string `json:"silenceID"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("failed to decode Alertmanager response: %v", err)
+	}
+	return result.SilenceID, nil
+}
+
+// formatDuration 以 1h、24h 形式顯示持續時間
+func formatDuration(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	}
+	return d.String()
+}
@@ -0,0 +1,168 @@
+package actions
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/blockkit"
+	"alert-webhooks/pkg/notification/types"
+)
+
+const (
+	// SlackActionsBlockID 按鈕所在 actions block 的 block_id，更新訊息時以此找出並替換
+	SlackActionsBlockID = "alert_actions"
+	// SlackRunbookActionID Runbook 連結按鈕的 action_id（Slack 仍會送出互動事件，需忽略）
+	SlackRunbookActionID = "runbook"
+
+	defaultSlackTimestampWindow = 5 * time.Minute
+)
+
+// SlackEnabled 檢查是否啟用 Slack 互動按鈕
+func SlackEnabled() bool {
+	return config.Actions.Slack.Enable
+}
+
+// SlackBlocks 返回警報訊息的 blocks：觸發中的群組在啟用互動按鈕時登記按鈕目標，並在 blocks
+// （Block Kit 模式下由模板渲染，否則以訊息文字組成 section）之後附加按鈕。
+// blocks 為空且沒有按鈕時返回 nil，只發送純文字
+func SlackBlocks(message string, blocks []blockkit.Block, data *types.AlertManagerData) []interface{} {
+	var target *Target
+	if SlackEnabled() {
+		target = Register(data)
+	}
+	if target == nil {
+		return blockkit.ToInterfaces(blocks)
+	}
+
+	if len(blocks) == 0 {
+		blocks = slackMessageBlocks(message)
+	}
+	if block := slackActionsBlock(target); block != nil {
+		// 保留按鈕所需的 block，超過上限時仍保留頁尾
+		blocks = append(blockkit.Limit(blocks, blockkit.MaxBlocks-1), block)
+	}
+	return blockkit.ToInterfaces(blocks)
+}
+
+// SlackUpdatedBlocks 以互動事件中的原始 blocks 產生更新後的 blocks：
+// 移除舊的按鈕、附加執行者說明，並依目標目前可用的動作重建按鈕
+func SlackUpdatedBlocks(original []json.RawMessage, result *Result) []interface{} {
+	blocks := make([]interface{}, 0, len(original)+2)
+	for _, raw := range original {
+		var block struct {
+			BlockID string `json:"block_id"`
+		}
+		if err := json.Unmarshal(raw, &block); err == nil && block.BlockID == SlackActionsBlockID {
+			continue
+		}
+		blocks = append(blocks, raw)
+	}
+
+	if result.Summary != "" {
+		blocks = append(blocks, blockkit.NewContext(blockkit.Mrkdwn(result.Summary)))
+	}
+	if block := slackActionsBlock(result.Target); block != nil {
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// VerifySlackSignature 以 Signing Secret 驗證 X-Slack-Signature（v0），並拒絕超出時間窗口的請求
+func VerifySlackSignature(body []byte, timestamp, signature string, now time.Time) error {
+	secret := config.Actions.Slack.SigningSecret
+	if secret == "" {
+		return fmt.Errorf("slack signing secret is not configured")
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid X-Slack-Request-Timestamp")
+	}
+
+	window := config.Actions.Slack.TimestampWindow
+	if window <= 0 {
+		window = defaultSlackTimestampWindow
+	}
+	if diff := now.Sub(time.Unix(ts, 0)); diff > window || diff < -window {
+		return fmt.Errorf("request timestamp outside of the %s window", window)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:", timestamp)
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// slackMessageBlocks 將訊息切成多個 section block（每個 section 文字上限 3000 字元）
+func slackMessageBlocks(message string) []blockkit.Block {
+	var blocks []blockkit.Block
+	for _, chunk := range splitSlackText(message, blockkit.MaxSectionText) {
+		blocks = append(blocks, blockkit.NewSection(blockkit.Mrkdwn(chunk)))
+	}
+	return blocks
+}
+
+// slackActionsBlock 依目標目前可用的動作建立按鈕，沒有任何按鈕時返回 nil
+func slackActionsBlock(target *Target) blockkit.Block {
+	var buttons []interface{}
+	for _, action := range target.Actions() {
+		button := blockkit.NewButton(string(action), action.Label())
+		button.Value = target.Token
+		if action == ActionAck {
+			button.Style = "primary"
+		}
+		buttons = append(buttons, button)
+	}
+	if target.RunbookURL != "" {
+		button := blockkit.NewButton(SlackRunbookActionID, "📖 Open runbook")
+		button.URL = target.RunbookURL
+		buttons = append(buttons, button)
+	}
+
+	if len(buttons) == 0 {
+		return nil
+	}
+	return blockkit.NewActions(SlackActionsBlockID, buttons...)
+}
+
+// splitSlackText 以行為單位切分文字，單行超過上限時硬切
+func splitSlackText(text string, limit int) []string {
+	if text == "" {
+		return nil
+	}
+
+	var chunks []string
+	var current strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		for len([]rune(line)) > limit {
+			if current.Len() > 0 {
+				chunks = append(chunks, current.String())
+				current.Reset()
+			}
+			runes := []rune(line)
+			chunks = append(chunks, string(runes[:limit]))
+			line = string(runes[limit:])
+		}
+		if len([]rune(current.String()))+len([]rune(line)) > limit {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+		current.WriteString(line)
+	}
+	if current.Len() > 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
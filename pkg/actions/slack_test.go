@@ -0,0 +1,68 @@
+package actions
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"alert-webhooks/config"
+)
+
+// slackSignature 以 Slack 的 v0 規則計算簽章
+func slackSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySlackSignature(t *testing.T) {
+	const secret = "8f742231b10e8888abcd99yyyzzz85a5"
+	now := time.Unix(1700000000, 0)
+	body := []byte("payload=%7B%22type%22%3A%22block_actions%22%7D")
+	fresh := strconv.FormatInt(now.Unix(), 10)
+	stale := strconv.FormatInt(now.Add(-6*time.Minute).Unix(), 10)
+	future := strconv.FormatInt(now.Add(6*time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name      string
+		secret    string
+		window    time.Duration
+		body      []byte
+		timestamp string
+		signature string
+		err       string
+	}{
+		{"valid", secret, 0, body, fresh, slackSignature(secret, fresh, body), ""},
+		{"tampered body", secret, 0, []byte("payload=%7B%7D"), fresh, slackSignature(secret, fresh, body), "signature mismatch"},
+		{"tampered signature", secret, 0, body, fresh, slackSignature("other-secret", fresh, body), "signature mismatch"},
+		{"signature for another timestamp", secret, 0, body, fresh, slackSignature(secret, stale, body), "signature mismatch"},
+		{"stale timestamp", secret, 0, body, stale, slackSignature(secret, stale, body), "outside of the 5m0s window"},
+		{"future timestamp", secret, 0, body, future, slackSignature(secret, future, body), "outside of the 5m0s window"},
+		{"stale timestamp within custom window", secret, 10 * time.Minute, body, stale, slackSignature(secret, stale, body), ""},
+		{"invalid timestamp", secret, 0, body, "yesterday", slackSignature(secret, "yesterday", body), "invalid X-Slack-Request-Timestamp"},
+		{"missing secret", "", 0, body, fresh, slackSignature(secret, fresh, body), "signing secret is not configured"},
+	}
+
+	saved := config.Actions.Slack
+	defer func() { config.Actions.Slack = saved }()
+
+	for _, tt := range tests {
+		config.Actions.Slack.SigningSecret = tt.secret
+		config.Actions.Slack.TimestampWindow = tt.window
+
+		err := VerifySlackSignature(tt.body, tt.timestamp, tt.signature, now)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: VerifySlackSignature returned error: %v", tt.name, err)
+		case tt.err != "" && err == nil:
+			t.Errorf("%s: VerifySlackSignature returned no error, want %q", tt.name, tt.err)
+		case tt.err != "" && !strings.Contains(err.Error(), tt.err):
+			t.Errorf("%s: VerifySlackSignature error = %q, want it to contain %q", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package actions
+
+import (
+	"context"
+	"strings"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/notification/types"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// TelegramCallbackPrefix 按鈕 callback_data 前綴，格式為 act:<action>:<token>
+const TelegramCallbackPrefix = "act:"
+
+// telegramKeyboard inline keyboard（自行定義以省略 models.InlineKeyboardButton 中不可省略的欄位）
+type telegramKeyboard struct {
+	InlineKeyboard [][]telegramButton `json:"inline_keyboard"`
+}
+
+// telegramButton inline keyboard 按鈕
+type telegramButton struct {
+	Text         string `json:"text"`
+	URL          string `json:"url,omitempty"`
+	CallbackData string `json:"callback_data,omitempty"`
+}
+
+// TelegramEnabled 檢查是否啟用 Telegram 互動按鈕
+func TelegramEnabled() bool {
+	return config.Actions.Telegram.Enable
+}
+
+// TelegramKeyboard 為觸發中的警報群組登記按鈕目標並返回 inline keyboard；未啟用或群組未觸發時返回 nil
+func TelegramKeyboard(data *types.AlertManagerData) models.ReplyMarkup {
+	if !TelegramEnabled() {
+		return nil
+	}
+	target := Register(data)
+	if target == nil {
+		return nil
+	}
+	return telegramMarkup(target)
+}
+
+// telegramMarkup 依目標目前可用的動作建立 inline keyboard，沒有任何按鈕時返回 nil（移除鍵盤）
+func telegramMarkup(target *Target) models.ReplyMarkup {
+	keyboard := telegramKeyboard{}
+
+	var row []telegramButton
+	for _, action := range target.Actions() {
+		row = append(row, telegramButton{
+			Text:         action.Label(),
+			CallbackData: TelegramCallbackPrefix + string(action) + ":" + target.Token,
+		})
+	}
+	if len(row) > 0 {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, row)
+	}
+	if target.RunbookURL != "" {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []telegramButton{
+			{Text: "📖 Runbook", URL: target.RunbookURL},
+		})
+	}
+
+	if len(keyboard.InlineKeyboard) == 0 {
+		return nil
+	}
+	return keyboard
+}
+
+// HandleTelegramCallback 處理按鈕的 callback query：執行動作、回覆按下的使用者並編輯原始訊息
+func HandleTelegramCallback(ctx context.Context, b *bot.Bot, update *models.Update) {
+	query := update.CallbackQuery
+	if query == nil || !strings.HasPrefix(query.Data, TelegramCallbackPrefix) {
+		return
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(query.Data, TelegramCallbackPrefix), ":", 2)
+	if len(parts) != 2 {
+		answerTelegramCallback(ctx, b, query.ID, "Invalid action")
+		return
+	}
+	action, token := Action(parts[0]), parts[1]
+	user := telegramUserName(query.From)
+
+	logger.Info("Telegram action received", "actions",
+		logger.String("action", string(action)),
+		logger.String("user", user))
+
+	result, err := Perform(ctx, token, action, user)
+	if err != nil {
+		logger.Error("Failed to perform Telegram action", "actions",
+			logger.String("action", string(action)),
+			logger.String("user", user),
+			logger.Err(err))
+		answerTelegramCallback(ctx, b, query.ID, "Failed: "+err.Error())
+		return
+	}
+
+	answerTelegramCallback(ctx, b, query.ID, result.Notice)
+	if result.Summary == "" {
+		return
+	}
+
+	msg := query.Message.Message
+	if msg == nil {
+		return
+	}
+
+	// 保留原始文字與格式（entities），在末尾附加執行者資訊
+	_, err = b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      msg.Chat.ID,
+		MessageID:   msg.ID,
+		Text:        msg.Text + "\n\n" + result.Summary,
+		Entities:    msg.Entities,
+		ReplyMarkup: telegramMarkup(result.Target),
+	})
+	if err != nil {
+		logger.Error("Failed to edit Telegram message after action", "actions",
+			logger.Int64("chat_id", msg.Chat.ID),
+			logger.Int("message_id", msg.ID),
+			logger.Err(err))
+	}
+}
+
+// answerTelegramCallback 回覆 callback query，讓用戶端停止載入動畫並顯示提示
+func answerTelegramCallback(ctx context.Context, b *bot.Bot, id, text string) {
+	if _, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: id,
+		Text:            text,
+	}); err != nil {
+		logger.Warn("Failed to answer Telegram callback query", "actions", logger.Err(err))
+	}
+}
+
+// telegramUserName 返回按下按鈕的使用者名稱，優先使用 @username
+func telegramUserName(user models.User) string {
+	if user.Username != "" {
+		return "@" + user.Username
+	}
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if name == "" {
+		return "unknown"
+	}
+	return name
+}
@@ -0,0 +1,92 @@
+// Package alertfilter 在渲染前過濾 AlertManager 群組中的警報，移除被本地靜音命中或被抑制規則抑制的警報
+package alertfilter
+
+import (
+	"alert-webhooks/pkg/inhibit"
+	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/matcher"
+	"alert-webhooks/pkg/notification/types"
+	"alert-webhooks/pkg/silence"
+)
+
+// Result 過濾結果
+type Result struct {
+	Data      *types.AlertManagerData // 過濾後的數據，未移除任何警報時為原始數據
+	Keep      []int                   // 保留的警報在原始 Alerts 中的索引
+	Silenced  int                     // 被靜音移除的警報數
+	Inhibited int                     // 被抑制規則移除的警報數
+}
+
+// Dropped 返回被移除的警報數
+func (r *Result) Dropped() int {
+	return r.Silenced + r.Inhibited
+}
+
+// Empty 檢查是否所有警報都被移除
+func (r *Result) Empty() bool {
+	return len(r.Keep) == 0 && r.Dropped() > 0
+}
+
+// Apply 依有效的靜音與抑制規則過濾警報；有警報被移除時返回副本並依剩餘警報重新計算群組狀態
+func Apply(data *types.AlertManagerData) *Result {
+	result := &Result{Data: data}
+	if data == nil {
+		return result
+	}
+
+	// 先以完整的 payload 更新觸發索引，讓同一群組中的來源警報也能抑制目標警報
+	inhibit.Observe(data)
+
+	result.Keep = make([]int, 0, len(data.Alerts))
+	store := silence.GetStore()
+
+	for i, alert := range data.Alerts {
+		if store != nil {
+			labels := matcher.AlertLabels(alert)
+			if ids := store.Silenced(labels); len(ids) > 0 {
+				result.Silenced++
+				logger.Info("Alert silenced", "alertfilter",
+					logger.String("group_key", data.GroupKey),
+					logger.String("labels", matcher.LabelsString(labels)),
+					logger.Any("silence_ids", ids))
+				continue
+			}
+		}
+		if source, inhibited := inhibit.Inhibited(alert); inhibited {
+			result.Inhibited++
+			logger.Info("Alert inhibited", "alertfilter",
+				logger.String("group_key", data.GroupKey),
+				logger.String("labels", matcher.LabelsString(matcher.AlertLabels(alert))),
+				logger.String("source_labels", matcher.LabelsString(source)))
+			continue
+		}
+		result.Keep = append(result.Keep, i)
+	}
+
+	if result.Dropped() == 0 {
+		return result
+	}
+
+	filtered := *data
+	filtered.Alerts = make([]map[string]interface{}, 0, len(result.Keep))
+	for _, i := range result.Keep {
+		filtered.Alerts = append(filtered.Alerts, data.Alerts[i])
+	}
+	filtered.Status = groupStatus(filtered.Alerts, data.Status)
+	result.Data = &filtered
+
+	return result
+}
+
+// groupStatus 與 Alertmanager 相同：任一警報仍在觸發時群組為 firing，否則為 resolved
+func groupStatus(alerts []map[string]interface{}, fallback string) string {
+	if len(alerts) == 0 {
+		return fallback
+	}
+	for _, alert := range alerts {
+		if status, _ := alert["status"].(string); status == "firing" {
+			return "firing"
+		}
+	}
+	return "resolved"
+}
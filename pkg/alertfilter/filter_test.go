@@ -0,0 +1,138 @@
+package alertfilter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/inhibit"
+	"alert-webhooks/pkg/notification/types"
+	"alert-webhooks/pkg/silence"
+)
+
+func alert(status string, labels map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"status": status, "labels": labels}
+}
+
+func TestApply(t *testing.T) {
+	if err := inhibit.Load(config.InhibitConf{Rules: []config.InhibitRuleConf{
+		{SourceMatchers: []string{`severity="critical"`}, TargetMatchers: []string{`severity="warning"`}, Equal: []string{"instance"}},
+	}}); err != nil {
+		t.Fatalf("inhibit.Load returned error: %v", err)
+	}
+	defer inhibit.Load(config.InhibitConf{})
+
+	store, err := silence.Open(config.SilenceConf{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("silence.Open returned error: %v", err)
+	}
+	defer store.Close()
+	silence.SetStore(store)
+	defer silence.SetStore(nil)
+
+	if _, err := store.Create(&silence.Silence{Matchers: []string{`alertname="Maintenance"`}, EndsAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+
+	// 每個案例使用不同的 instance，避免觸發索引互相影響；最後一個案例沿用 instance b 仍在觸發的來源警報
+	tests := []struct {
+		name      string
+		status    string
+		alerts    []map[string]interface{}
+		keep      []int
+		silenced  int
+		inhibited int
+		wantState string
+	}{
+		{
+			"nothing dropped keeps the original data",
+			"firing",
+			[]map[string]interface{}{
+				alert("firing", map[string]interface{}{"alertname": "HighCPU", "severity": "warning", "instance": "a"}),
+				alert("resolved", map[string]interface{}{"alertname": "DiskFull", "severity": "warning", "instance": "a"}),
+			},
+			[]int{0, 1}, 0, 0, "firing",
+		},
+		{
+			"source in the same group inhibits the target",
+			"firing",
+			[]map[string]interface{}{
+				alert("firing", map[string]interface{}{"alertname": "HighLoad", "severity": "warning", "instance": "b"}),
+				alert("firing", map[string]interface{}{"alertname": "NodeDown", "severity": "critical", "instance": "b"}),
+			},
+			[]int{1}, 0, 1, "firing",
+		},
+		{
+			"status becomes resolved when only resolved alerts remain",
+			"firing",
+			[]map[string]interface{}{
+				alert("firing", map[string]interface{}{"alertname": "Maintenance", "instance": "c"}),
+				alert("resolved", map[string]interface{}{"alertname": "HighCPU", "instance": "c"}),
+			},
+			[]int{1}, 1, 0, "resolved",
+		},
+		{
+			"status stays firing while a firing alert remains",
+			"firing",
+			[]map[string]interface{}{
+				alert("firing", map[string]interface{}{"alertname": "Maintenance", "instance": "d"}),
+				alert("resolved", map[string]interface{}{"alertname": "HighCPU", "instance": "d"}),
+				alert("firing", map[string]interface{}{"alertname": "DiskFull", "instance": "d"}),
+			},
+			[]int{1, 2}, 1, 0, "firing",
+		},
+		{
+			"all alerts dropped keeps the group status",
+			"firing",
+			[]map[string]interface{}{
+				alert("firing", map[string]interface{}{"alertname": "Maintenance", "instance": "e"}),
+				alert("firing", map[string]interface{}{"alertname": "HighLoad", "severity": "warning", "instance": "b"}),
+			},
+			[]int{}, 1, 1, "firing",
+		},
+	}
+
+	for _, tt := range tests {
+		data := &types.AlertManagerData{Status: tt.status, GroupKey: tt.name, Alerts: tt.alerts}
+		result := Apply(data)
+
+		if !reflect.DeepEqual(result.Keep, tt.keep) {
+			t.Errorf("%s: Keep = %v, want %v", tt.name, result.Keep, tt.keep)
+		}
+		if result.Silenced != tt.silenced || result.Inhibited != tt.inhibited {
+			t.Errorf("%s: silenced/inhibited = %d/%d, want %d/%d", tt.name, result.Silenced, result.Inhibited, tt.silenced, tt.inhibited)
+		}
+		if result.Data.Status != tt.wantState {
+			t.Errorf("%s: Status = %q, want %q", tt.name, result.Data.Status, tt.wantState)
+		}
+		if len(result.Data.Alerts) != len(tt.keep) {
+			t.Errorf("%s: %d alerts after filtering, want %d", tt.name, len(result.Data.Alerts), len(tt.keep))
+		}
+		if want := len(tt.keep) == 0; result.Empty() != want {
+			t.Errorf("%s: Empty = %t, want %t", tt.name, result.Empty(), want)
+		}
+
+		// 有警報被移除時返回副本，不修改原始數據
+		if result.Dropped() == 0 && result.Data != data {
+			t.Errorf("%s: Apply copied data although nothing was dropped", tt.name)
+		}
+		if result.Dropped() > 0 && (result.Data == data || len(data.Alerts) != len(tt.alerts) || data.Status != tt.status) {
+			t.Errorf("%s: Apply modified the original data", tt.name)
+		}
+	}
+}
+
+func TestApplyWithoutFilters(t *testing.T) {
+	if result := Apply(nil); result.Data != nil || result.Empty() {
+		t.Errorf("Apply(nil) = %+v, want empty result", result)
+	}
+
+	data := &types.AlertManagerData{Status: "firing", Alerts: []map[string]interface{}{
+		alert("firing", map[string]interface{}{"alertname": "HighCPU", "severity": "warning"}),
+	}}
+	result := Apply(data)
+	if result.Data != data || result.Dropped() != 0 || !reflect.DeepEqual(result.Keep, []int{0}) {
+		t.Errorf("Apply without silences or inhibit rules = %+v, want the original data", result)
+	}
+}
@@ -106,6 +106,16 @@ func BuildTemplateData(
         alertData = append(alertData, item)
     }
 
+    // 群組共同標籤；groupLabels 的值在群組內必然相同，CommonLabels 缺少時以其補上
+    common := map[string]string{}
+    for _, labels := range []map[string]interface{}{commonLabels, groupLabels} {
+        for k, val := range labels {
+            if s, ok := val.(string); ok && common[k] == "" {
+                common[k] = s
+            }
+        }
+    }
+
     // 組裝 TemplateData，並帶入外部連結與格式選項
     data := template.TemplateData{
         Status:        status,
@@ -117,6 +127,7 @@ func BuildTemplateData(
         FiringCount:   firingCount,
         ResolvedCount: resolvedCount,
         Alerts:        alertData,
+        CommonLabels:  common,
         ExternalURL:   externalURL,
         FormatOptions: formatOptions,
     }
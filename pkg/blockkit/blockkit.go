@@ -0,0 +1,298 @@
+// Package blockkit 提供 Slack Block Kit 的資料模型（section、header、context、divider、actions 與按鈕），
+// 以及解析模板輸出的 blocks 並套用 Slack 的長度與數量限制
+package blockkit
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Block Kit 的長度與數量限制
+const (
+	MaxBlocks          = 50   // 單則訊息的 block 數量上限
+	MaxSectionText     = 3000 // section 文字上限
+	MaxHeaderText      = 150  // header 文字上限
+	MaxFields          = 10   // section fields 數量上限
+	MaxFieldText       = 2000 // 單一 field 文字上限
+	MaxContextElements = 10   // context 元素數量上限
+	MaxButtonText      = 75   // 按鈕文字上限
+)
+
+// 文字物件類型
+const (
+	TypePlainText = "plain_text"
+	TypeMrkdwn    = "mrkdwn"
+)
+
+// Block 所有 Block Kit block 的共同介面
+type Block interface {
+	BlockType() string
+}
+
+// Text Block Kit 文字物件
+type Text struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	Emoji    bool   `json:"emoji,omitempty"`
+	Verbatim bool   `json:"verbatim,omitempty"`
+}
+
+// PlainText 建立 plain_text 文字物件（允許 emoji 短碼）
+func PlainText(text string) *Text {
+	return &Text{Type: TypePlainText, Text: text, Emoji: true}
+}
+
+// Mrkdwn 建立 mrkdwn 文字物件
+func Mrkdwn(text string) *Text {
+	return &Text{Type: TypeMrkdwn, Text: text}
+}
+
+// Section section block，可包含文字、最多 10 個 fields 與一個 accessory 元素
+type Section struct {
+	Type      string      `json:"type"`
+	BlockID   string      `json:"block_id,omitempty"`
+	Text      *Text       `json:"text,omitempty"`
+	Fields    []*Text     `json:"fields,omitempty"`
+	Accessory interface{} `json:"accessory,omitempty"`
+}
+
+// BlockType 實作 Block 介面
+func (*Section) BlockType() string { return "section" }
+
+// Header header block，只接受 plain_text
+type Header struct {
+	Type    string `json:"type"`
+	BlockID string `json:"block_id,omitempty"`
+	Text    *Text  `json:"text"`
+}
+
+// BlockType 實作 Block 介面
+func (*Header) BlockType() string { return "header" }
+
+// Context context block，以小字顯示文字或圖片元素
+type Context struct {
+	Type     string        `json:"type"`
+	BlockID  string        `json:"block_id,omitempty"`
+	Elements []interface{} `json:"elements"`
+}
+
+// BlockType 實作 Block 介面
+func (*Context) BlockType() string { return "context" }
+
+// Divider divider block
+type Divider struct {
+	Type    string `json:"type"`
+	BlockID string `json:"block_id,omitempty"`
+}
+
+// BlockType 實作 Block 介面
+func (*Divider) BlockType() string { return "divider" }
+
+// Actions actions block，包含按鈕等互動元素
+type Actions struct {
+	Type     string        `json:"type"`
+	BlockID  string        `json:"block_id,omitempty"`
+	Elements []interface{} `json:"elements"`
+}
+
+// BlockType 實作 Block 介面
+func (*Actions) BlockType() string { return "actions" }
+
+// Button 按鈕元素；設定 URL 時為連結按鈕
+type Button struct {
+	Type     string `json:"type"`
+	Text     *Text  `json:"text"`
+	ActionID string `json:"action_id"`
+	Value    string `json:"value,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Style    string `json:"style,omitempty"` // primary 或 danger
+}
+
+// NewSection 建立 section block，超過上限的文字與 fields 會被截斷
+func NewSection(text *Text, fields ...*Text) *Section {
+	section := &Section{Type: "section"}
+	if text != nil {
+		text.Text = Truncate(text.Text, MaxSectionText)
+		section.Text = text
+	}
+	if len(fields) > MaxFields {
+		fields = fields[:MaxFields]
+	}
+	for _, field := range fields {
+		field.Text = Truncate(field.Text, MaxFieldText)
+	}
+	section.Fields = fields
+	return section
+}
+
+// NewHeader 建立 header block，文字超過 150 字元時截斷
+func NewHeader(text string) *Header {
+	return &Header{Type: "header", Text: PlainText(Truncate(text, MaxHeaderText))}
+}
+
+// NewContext 建立 context block，最多保留 10 個元素
+func NewContext(elements ...*Text) *Context {
+	if len(elements) > MaxContextElements {
+		elements = elements[:MaxContextElements]
+	}
+	context := &Context{Type: "context", Elements: make([]interface{}, 0, len(elements))}
+	for _, element := range elements {
+		context.Elements = append(context.Elements, element)
+	}
+	return context
+}
+
+// NewDivider 建立 divider block
+func NewDivider() *Divider {
+	return &Divider{Type: "divider"}
+}
+
+// NewActions 建立 actions block
+func NewActions(blockID string, elements ...interface{}) *Actions {
+	return &Actions{Type: "actions", BlockID: blockID, Elements: elements}
+}
+
+// NewButton 建立按鈕元素
+func NewButton(actionID, label string) *Button {
+	return &Button{
+		Type:     "button",
+		Text:     PlainText(Truncate(label, MaxButtonText)),
+		ActionID: actionID,
+	}
+}
+
+// ToInterfaces 將 blocks 轉為可直接放入訊息 payload 的 []interface{}
+func ToInterfaces(blocks []Block) []interface{} {
+	if len(blocks) == 0 {
+		return nil
+	}
+	result := make([]interface{}, 0, len(blocks))
+	for _, block := range blocks {
+		result = append(result, block)
+	}
+	return result
+}
+
+// Truncate 將文字截斷到 limit 個字元（以 rune 計算），截斷時以 … 結尾
+func Truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	if limit <= 1 {
+		return string(runes[:limit])
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+// Parse 解析模板輸出的 blocks JSON 陣列，並套用 Slack 的限制：section、header 與 context 以 NewSection、
+// NewHeader 與 NewContext 截斷，其他類型的 block 原樣保留；超過 MaxBlocks 時以 Limit 保留最後的 block
+func Parse(data []byte) ([]Block, error) {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return nil, fmt.Errorf("blocks must be a JSON array: %v", err)
+	}
+
+	blocks := make([]Block, 0, len(raws))
+	for i, raw := range raws {
+		block, err := parseBlock(raw)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %v", i, err)
+		}
+		blocks = append(blocks, block)
+	}
+	return Limit(blocks, MaxBlocks), nil
+}
+
+// Limit 將 blocks 限制在 max 個以內：保留前 max-1 個與最後一個 block（通常是頁尾）
+func Limit(blocks []Block, max int) []Block {
+	if max <= 0 || len(blocks) <= max {
+		return blocks
+	}
+	if max == 1 {
+		return blocks[len(blocks)-1:]
+	}
+	limited := make([]Block, 0, max)
+	limited = append(limited, blocks[:max-1]...)
+	return append(limited, blocks[len(blocks)-1])
+}
+
+// Raw 未建模的 block，以原始 JSON 送出
+type Raw struct {
+	Type string
+	JSON json.RawMessage
+}
+
+// BlockType 實作 Block 介面
+func (r *Raw) BlockType() string { return r.Type }
+
+// MarshalJSON 輸出原始 JSON
+func (r *Raw) MarshalJSON() ([]byte, error) { return r.JSON, nil }
+
+// parseBlock 依 type 解析單一 block
+func parseBlock(raw json.RawMessage) (Block, error) {
+	var head struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(raw, &head); err != nil {
+		return nil, err
+	}
+
+	switch head.Type {
+	case "":
+		return nil, fmt.Errorf("missing type")
+	case "section":
+		var section Section
+		if err := json.Unmarshal(raw, &section); err != nil {
+			return nil, err
+		}
+		limited := NewSection(section.Text, section.Fields...)
+		limited.BlockID, limited.Accessory = section.BlockID, section.Accessory
+		return limited, nil
+	case "header":
+		var header Header
+		if err := json.Unmarshal(raw, &header); err != nil {
+			return nil, err
+		}
+		if header.Text == nil {
+			return nil, fmt.Errorf("header without text")
+		}
+		limited := NewHeader(header.Text.Text)
+		limited.BlockID = header.BlockID
+		limited.Text.Emoji = header.Text.Emoji
+		return limited, nil
+	case "context":
+		var context struct {
+			BlockID  string            `json:"block_id"`
+			Elements []json.RawMessage `json:"elements"`
+		}
+		if err := json.Unmarshal(raw, &context); err != nil {
+			return nil, err
+		}
+		if len(context.Elements) > MaxContextElements {
+			context.Elements = context.Elements[:MaxContextElements]
+		}
+		limited := &Context{Type: "context", BlockID: context.BlockID, Elements: make([]interface{}, 0, len(context.Elements))}
+		for _, element := range context.Elements {
+			limited.Elements = append(limited.Elements, contextElement(element))
+		}
+		return limited, nil
+	case "divider":
+		var divider Divider
+		if err := json.Unmarshal(raw, &divider); err != nil {
+			return nil, err
+		}
+		return &divider, nil
+	}
+	return &Raw{Type: head.Type, JSON: raw}, nil
+}
+
+// contextElement 文字元素解析為 *Text 並截斷到 section 文字上限，圖片等其他元素原樣保留
+func contextElement(raw json.RawMessage) interface{} {
+	var text Text
+	if err := json.Unmarshal(raw, &text); err == nil && (text.Type == TypeMrkdwn || text.Type == TypePlainText) {
+		text.Text = Truncate(text.Text, MaxSectionText)
+		return &text
+	}
+	return raw
+}
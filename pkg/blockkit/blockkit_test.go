@@ -0,0 +1,128 @@
+package blockkit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParse(t *testing.T) {
+	long := strings.Repeat("x", MaxSectionText+10)
+	fields := make([]string, MaxFields+2)
+	for i := range fields {
+		fields[i] = fmt.Sprintf(`{"type":"mrkdwn","text":"field%d"}`, i)
+	}
+	elements := make([]string, MaxContextElements+1)
+	for i := range elements {
+		elements[i] = `{"type":"mrkdwn","text":"element"}`
+	}
+	elements[0] = `{"type":"image","image_url":"https://example.com/a.png","alt_text":"icon"}`
+
+	input := fmt.Sprintf(`[
+		{"type":"header","block_id":"title","text":{"type":"plain_text","text":%q,"emoji":true}},
+		{"type":"section","text":{"type":"mrkdwn","text":%q},"fields":[%s]},
+		{"type":"context","elements":[%s]},
+		{"type":"divider"},
+		{"type":"image","image_url":"https://example.com/graph.png","alt_text":"graph"}
+	]`, strings.Repeat("h", MaxHeaderText+1), long, strings.Join(fields, ","), strings.Join(elements, ","))
+
+	blocks, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if len(blocks) != 5 {
+		t.Fatalf("Parse returned %d blocks, want 5", len(blocks))
+	}
+
+	header := blocks[0].(*Header)
+	if n := utf8.RuneCountInString(header.Text.Text); n != MaxHeaderText || header.BlockID != "title" || !header.Text.Emoji {
+		t.Errorf("header = %d characters, block_id %q, emoji %t", n, header.BlockID, header.Text.Emoji)
+	}
+	section := blocks[1].(*Section)
+	if n := utf8.RuneCountInString(section.Text.Text); n != MaxSectionText || len(section.Fields) != MaxFields {
+		t.Errorf("section = %d characters and %d fields, want %d and %d", n, len(section.Fields), MaxSectionText, MaxFields)
+	}
+	context := blocks[2].(*Context)
+	if len(context.Elements) != MaxContextElements {
+		t.Errorf("context has %d elements, want %d", len(context.Elements), MaxContextElements)
+	}
+	if _, ok := context.Elements[0].(json.RawMessage); !ok {
+		t.Errorf("image element = %T, want it kept as raw JSON", context.Elements[0])
+	}
+	if text, ok := context.Elements[1].(*Text); !ok || text.Text != "element" {
+		t.Errorf("text element = %#v, want *Text", context.Elements[1])
+	}
+	if blocks[3].BlockType() != "divider" {
+		t.Errorf("fourth block = %s, want divider", blocks[3].BlockType())
+	}
+
+	// 未建模的 block 原樣輸出
+	out, err := json.Marshal(blocks[4])
+	if err != nil || blocks[4].BlockType() != "image" || !strings.Contains(string(out), "graph.png") {
+		t.Errorf("image block = %s (%s), %v", out, blocks[4].BlockType(), err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"not an array", `{"type":"divider"}`, "JSON array"},
+		{"invalid JSON", `[{"type":"divider"},]`, "JSON array"},
+		{"block without type", `[{"type":"divider"},{"text":"x"}]`, "block 1: missing type"},
+		{"header without text", `[{"type":"header"}]`, "header without text"},
+	}
+	for _, tt := range tests {
+		if _, err := Parse([]byte(tt.input)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Parse error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestLimit(t *testing.T) {
+	blocks := func(n int) []Block {
+		result := make([]Block, n)
+		for i := range result {
+			result[i] = &Divider{Type: "divider", BlockID: fmt.Sprint(i)}
+		}
+		return result
+	}
+
+	tests := []struct {
+		name  string
+		count int
+		max   int
+		ids   string
+	}{
+		{"within the limit", 3, 5, "0,1,2"},
+		{"exactly the limit", 5, 5, "0,1,2,3,4"},
+		{"over the limit keeps the last block", 8, 4, "0,1,2,7"},
+		{"limit of one keeps the last block", 3, 1, "2"},
+	}
+	for _, tt := range tests {
+		var ids []string
+		for _, block := range Limit(blocks(tt.count), tt.max) {
+			ids = append(ids, block.(*Divider).BlockID)
+		}
+		if got := strings.Join(ids, ","); got != tt.ids {
+			t.Errorf("%s: Limit = %s, want %s", tt.name, got, tt.ids)
+		}
+	}
+
+	// Parse 套用 MaxBlocks
+	raws := make([]string, MaxBlocks+5)
+	for i := range raws {
+		raws[i] = fmt.Sprintf(`{"type":"divider","block_id":"%d"}`, i)
+	}
+	parsed, err := Parse([]byte("[" + strings.Join(raws, ",") + "]"))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if len(parsed) != MaxBlocks || parsed[len(parsed)-1].(*Divider).BlockID != fmt.Sprint(MaxBlocks+4) {
+		t.Errorf("Parse returned %d blocks, want %d ending with the last block", len(parsed), MaxBlocks)
+	}
+}
@@ -0,0 +1,187 @@
+// Package dedup 提供通知去重窗口，以 GroupKey、狀態與排序後的警報 fingerprint 組成鍵值，
+// 抑制 Alertmanager repeat_interval 重送與 HA 成對投遞造成的重複通知
+package dedup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/matcher"
+	"alert-webhooks/pkg/notification/types"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const defaultWindow = 5 * time.Minute
+
+// hitsTotal 去重命中次數，依提供者區分
+var hitsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "alert_webhooks_dedup_hits_total",
+	Help: "Number of notifications suppressed by the deduplication window.",
+}, []string{"provider"})
+
+// Deduplicator 記錄窗口內已發送過的通知鍵值
+type Deduplicator struct {
+	window time.Duration
+
+	mu     sync.Mutex
+	seen   map[string]time.Time
+	lastGC time.Time
+}
+
+// New 創建去重器，window 小於等於 0 時使用預設值
+func New(window time.Duration) *Deduplicator {
+	if window <= 0 {
+		window = defaultWindow
+	}
+	return &Deduplicator{
+		window: window,
+		seen:   make(map[string]time.Time),
+	}
+}
+
+// Window 返回去重時間窗口
+func (d *Deduplicator) Window() time.Duration {
+	return d.window
+}
+
+// Seen 檢查鍵值是否在窗口內出現過；未出現時記錄並返回 false，檢查與記錄為原子操作，
+// 確保 HA 成對投遞同時到達時只有一筆會被發送
+func (d *Deduplicator) Seen(key string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	d.gc(now)
+
+	if expires, ok := d.seen[key]; ok && now.Before(expires) {
+		return true
+	}
+	d.seen[key] = now.Add(d.window)
+	return false
+}
+
+// Forget 移除鍵值，發送失敗時呼叫，讓 Alertmanager 的重試不會被抑制
+func (d *Deduplicator) Forget(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.seen, key)
+}
+
+// gc 清除過期的鍵值（需持有鎖）
+func (d *Deduplicator) gc(now time.Time) {
+	if now.Sub(d.lastGC) < d.window {
+		return
+	}
+	d.lastGC = now
+	for key, expires := range d.seen {
+		if !now.Before(expires) {
+			delete(d.seen, key)
+		}
+	}
+}
+
+// Key 以目的地、GroupKey、狀態與排序後的 fingerprint 計算去重鍵值；
+// 警報缺少 fingerprint 時以排序後的標籤代替
+func Key(dest types.Destination, data *types.AlertManagerData) string {
+	fingerprints := make([]string, 0, len(data.Alerts))
+	for _, alert := range data.Alerts {
+		fingerprint, _ := alert["fingerprint"].(string)
+		if fingerprint == "" {
+			fingerprint = matcher.LabelsString(matcher.AlertLabels(alert))
+		}
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	sort.Strings(fingerprints)
+
+	scope := dest.Provider + "/" + dest.Level
+	if dest.Channel != "" {
+		scope = dest.Provider + "/" + dest.Channel
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		scope,
+		data.GroupKey,
+		data.Status,
+		strings.Join(fingerprints, ","),
+	}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+var (
+	mu      sync.RWMutex
+	current *Deduplicator
+)
+
+// Load 依配置重建全局去重器；窗口未變更時保留既有紀錄
+func Load(conf config.DedupConf) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !conf.Enable {
+		current = nil
+		logger.Info("Notification deduplication disabled", "dedup")
+		return
+	}
+
+	window := conf.Window
+	if window <= 0 {
+		window = defaultWindow
+	}
+	if current != nil && current.window == window {
+		return
+	}
+
+	current = New(window)
+	logger.Info("Notification deduplication enabled", "dedup",
+		logger.String("window", window.String()))
+}
+
+// Check 檢查發送到 dest 的通知是否重複；重複時記錄指標並返回 true 與鍵值。
+// 未啟用去重或沒有 AlertManager 數據時一律返回 false 與空鍵值
+func Check(dest types.Destination, data *types.AlertManagerData) (string, bool) {
+	mu.RLock()
+	d := current
+	mu.RUnlock()
+
+	if d == nil || data == nil {
+		return "", false
+	}
+
+	key := Key(dest, data)
+	if !d.Seen(key) {
+		return key, false
+	}
+
+	hitsTotal.WithLabelValues(dest.Provider).Inc()
+	logger.Info("Duplicate notification suppressed", "dedup",
+		logger.String("provider", dest.Provider),
+		logger.String("level", dest.Level),
+		logger.String("channel", dest.Channel),
+		logger.String("group_key", data.GroupKey),
+		logger.String("status", data.Status),
+		logger.String("window", d.window.String()))
+	return key, true
+}
+
+// Release 發送失敗時釋放鍵值，key 為空時不做任何事
+func Release(key string) {
+	if key == "" {
+		return
+	}
+
+	mu.RLock()
+	d := current
+	mu.RUnlock()
+
+	if d != nil {
+		d.Forget(key)
+	}
+}
@@ -0,0 +1,122 @@
+package dedup
+
+import (
+	"testing"
+	"time"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/notification/types"
+)
+
+func alertData(status string, fingerprints ...string) *types.AlertManagerData {
+	data := &types.AlertManagerData{Status: status, GroupKey: `{}:{alertname="HighCPU"}`}
+	for _, fingerprint := range fingerprints {
+		data.Alerts = append(data.Alerts, map[string]interface{}{"fingerprint": fingerprint})
+	}
+	return data
+}
+
+func TestKey(t *testing.T) {
+	telegram := types.Destination{Provider: "telegram", Level: "L0"}
+	base := Key(telegram, alertData("firing", "a1", "b2", "c3"))
+
+	tests := []struct {
+		name string
+		dest types.Destination
+		data *types.AlertManagerData
+		same bool
+	}{
+		{"fingerprint order does not matter", telegram, alertData("firing", "c3", "a1", "b2"), true},
+		{"status changes the key", telegram, alertData("resolved", "a1", "b2", "c3"), false},
+		{"level changes the key", types.Destination{Provider: "telegram", Level: "L1"}, alertData("firing", "a1", "b2", "c3"), false},
+		{"provider changes the key", types.Destination{Provider: "slack", Level: "L0"}, alertData("firing", "a1", "b2", "c3"), false},
+		{"channel changes the key", types.Destination{Provider: "telegram", Level: "L0", Channel: "#ops"}, alertData("firing", "a1", "b2", "c3"), false},
+		{"template does not change the key", types.Destination{Provider: "telegram", Level: "L0", Template: "short"}, alertData("firing", "a1", "b2", "c3"), true},
+		{"alert set changes the key", telegram, alertData("firing", "a1", "b2"), false},
+	}
+
+	for _, tt := range tests {
+		if got := Key(tt.dest, tt.data) == base; got != tt.same {
+			t.Errorf("%s: key equal = %t, want %t", tt.name, got, tt.same)
+		}
+	}
+
+	// 缺少 fingerprint 時以標籤計算，標籤順序不影響鍵值
+	withLabels := func(labels map[string]interface{}) *types.AlertManagerData {
+		return &types.AlertManagerData{Status: "firing", Alerts: []map[string]interface{}{{"labels": labels}}}
+	}
+	first := Key(telegram, withLabels(map[string]interface{}{"alertname": "HighCPU", "instance": "web-1"}))
+	second := Key(telegram, withLabels(map[string]interface{}{"instance": "web-1", "alertname": "HighCPU"}))
+	other := Key(telegram, withLabels(map[string]interface{}{"alertname": "HighCPU", "instance": "web-2"}))
+	if first != second || first == other {
+		t.Errorf("label keys: same labels equal = %t, different labels equal = %t", first == second, first == other)
+	}
+}
+
+func TestCheckAndRelease(t *testing.T) {
+	Load(config.DedupConf{Enable: true, Window: time.Minute})
+	defer Load(config.DedupConf{})
+
+	dest := types.Destination{Provider: "slack", Channel: "#ops"}
+	data := alertData("firing", "a1", "b2")
+
+	key, dup := Check(dest, data)
+	if dup || key == "" {
+		t.Fatalf("first Check = %q, %t, want a key and false", key, dup)
+	}
+	if _, dup := Check(dest, alertData("firing", "b2", "a1")); !dup {
+		t.Error("second Check with reordered fingerprints was not suppressed")
+	}
+	if _, dup := Check(types.Destination{Provider: "slack", Channel: "#db"}, data); dup {
+		t.Error("Check for another channel was suppressed")
+	}
+	if _, dup := Check(dest, alertData("resolved", "a1", "b2")); dup {
+		t.Error("Check for the resolved notification was suppressed")
+	}
+
+	// 發送失敗釋放鍵值後，重試可以再次發送
+	Release(key)
+	if _, dup := Check(dest, data); dup {
+		t.Error("Check after Release was suppressed")
+	}
+	if _, dup := Check(dest, data); !dup {
+		t.Error("Check after the retry was not suppressed")
+	}
+	Release("")
+}
+
+func TestCheckExpiresAfterWindow(t *testing.T) {
+	Load(config.DedupConf{Enable: true, Window: 20 * time.Millisecond})
+	defer Load(config.DedupConf{})
+
+	dest := types.Destination{Provider: "discord", Level: "L2"}
+	data := alertData("firing", "a1")
+
+	if _, dup := Check(dest, data); dup {
+		t.Fatal("first Check was suppressed")
+	}
+	if _, dup := Check(dest, data); !dup {
+		t.Fatal("Check inside the window was not suppressed")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, dup := Check(dest, data); dup {
+		t.Error("Check after the window was suppressed")
+	}
+}
+
+func TestCheckDisabled(t *testing.T) {
+	Load(config.DedupConf{})
+
+	dest := types.Destination{Provider: "telegram", Level: "L0"}
+	for i := 0; i < 2; i++ {
+		if key, dup := Check(dest, alertData("firing", "a1")); dup || key != "" {
+			t.Errorf("Check %d with dedup disabled = %q, %t, want empty key and false", i, key, dup)
+		}
+	}
+
+	Load(config.DedupConf{Enable: true, Window: time.Minute})
+	defer Load(config.DedupConf{})
+	if key, dup := Check(dest, nil); dup || key != "" {
+		t.Errorf("Check without AlertManager data = %q, %t, want empty key and false", key, dup)
+	}
+}
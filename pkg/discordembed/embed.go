@@ -0,0 +1,323 @@
+// Package discordembed renders AlertManager alert groups as Discord embeds and splits them
+// into pages that respect Discord's per-message embed limits
+package discordembed
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/matcher"
+	"alert-webhooks/pkg/notification/types"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord embed limits
+const (
+	MaxEmbedsPerMessage = 10
+	MaxTotalChars       = 6000 // combined title, description, fields, footer and author text per message
+	MaxTitle            = 256
+	MaxDescription      = 4096
+	MaxFields           = 25
+	MaxFieldName        = 256
+	MaxFieldValue       = 1024
+	MaxFooter           = 2048
+)
+
+// Embed colors by status and severity
+const (
+	ColorResolved = 0x2ECC71
+	ColorCritical = 0xE74C3C
+	ColorError    = 0xE67E22
+	ColorWarning  = 0xF1C40F
+	ColorInfo     = 0x3498DB
+	ColorDefault  = 0x95A5A6
+)
+
+const zeroTime = "0001-01-01T00:00:00Z"
+
+// Enabled reports whether Discord alerts should be sent as embeds (discord.message_format: embed)
+func Enabled() bool {
+	return strings.EqualFold(config.Conf.Discord.MessageFormat, "embed")
+}
+
+// AlertEmbeds renders the alert group as embeds: one per alert by default, or a single
+// group embed when discord.embed_mode is "group". Returns nil when data is nil
+func AlertEmbeds(data *types.AlertManagerData) []*discordgo.MessageEmbed {
+	if data == nil {
+		return nil
+	}
+	fields := config.Conf.Discord.EmbedFields
+	if strings.EqualFold(config.Conf.Discord.EmbedMode, "group") || len(data.Alerts) == 0 {
+		return []*discordgo.MessageEmbed{GroupEmbed(data, fields)}
+	}
+
+	embeds := make([]*discordgo.MessageEmbed, 0, len(data.Alerts))
+	for _, alert := range data.Alerts {
+		embeds = append(embeds, AlertEmbed(alert, data, fields))
+	}
+	return embeds
+}
+
+// AlertEmbed renders a single alert: the summary as title, the description, inline label fields,
+// the start (or end, once resolved) time as timestamp and a link to the generator URL
+func AlertEmbed(alert map[string]interface{}, data *types.AlertManagerData, fieldNames []string) *discordgo.MessageEmbed {
+	status, _ := alert["status"].(string)
+	labels := matcher.AlertLabels(alert)
+	annotations := matcher.LabelsFromMap(toMap(alert["annotations"]))
+
+	title := annotations["summary"]
+	if title == "" {
+		title = labels["alertname"]
+	}
+	prefix := "🔥"
+	if strings.EqualFold(status, "resolved") {
+		prefix = "✅"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       truncate(prefix+" "+title, MaxTitle),
+		Description: truncate(annotations["description"], MaxDescription),
+		Color:       Color(status, labels["severity"]),
+		Fields:      labelFields(labels, fieldNames),
+		Footer:      &discordgo.MessageEmbedFooter{Text: truncate(footerText(labels["alertname"], status, data), MaxFooter)},
+	}
+	if url, _ := alert["generatorURL"].(string); url != "" {
+		embed.URL = url
+	}
+
+	timestamp, _ := alert["startsAt"].(string)
+	if endsAt, _ := alert["endsAt"].(string); strings.EqualFold(status, "resolved") && endsAt != "" && endsAt != zeroTime {
+		timestamp = endsAt
+	}
+	if _, err := time.Parse(time.RFC3339, timestamp); err == nil && timestamp != zeroTime {
+		embed.Timestamp = timestamp
+	}
+	return fit(embed)
+}
+
+// GroupEmbed renders the whole alert group as one embed: a status title, one description line
+// per alert, inline fields for the common labels and a link to Alertmanager
+func GroupEmbed(data *types.AlertManagerData, fieldNames []string) *discordgo.MessageEmbed {
+	commonLabels := matcher.LabelsFromMap(data.CommonLabels)
+	firing := 0
+	var lines []string
+	for _, alert := range data.Alerts {
+		status, _ := alert["status"].(string)
+		labels := matcher.AlertLabels(alert)
+		annotations := matcher.LabelsFromMap(toMap(alert["annotations"]))
+
+		summary := annotations["summary"]
+		if summary == "" {
+			summary = labels["alertname"]
+		}
+		line := "🔥 " + summary
+		if strings.EqualFold(status, "resolved") {
+			line = "✅ " + summary
+		} else {
+			firing++
+		}
+		if url, _ := alert["generatorURL"].(string); url != "" {
+			line += fmt.Sprintf(" ([source](%s))", url)
+		}
+		lines = append(lines, line)
+	}
+
+	status := strings.ToUpper(data.Status)
+	if strings.EqualFold(data.Status, "firing") {
+		status = fmt.Sprintf("%s:%d", status, firing)
+	}
+	name := commonLabels["alertname"]
+	if name == "" {
+		name = "Alert"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       truncate(fmt.Sprintf("[%s] %s", status, name), MaxTitle),
+		Description: truncateLines(lines, MaxDescription),
+		Color:       Color(data.Status, commonLabels["severity"]),
+		Fields:      labelFields(commonLabels, fieldNames),
+		Footer:      &discordgo.MessageEmbedFooter{Text: truncate(footerText(name, data.Status, data), MaxFooter)},
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+	}
+	if data.ExternalURL != "" {
+		embed.URL = data.ExternalURL
+	}
+	return fit(embed)
+}
+
+// Color returns the embed color for an alert status and severity
+func Color(status, severity string) int {
+	if strings.EqualFold(status, "resolved") {
+		return ColorResolved
+	}
+	switch strings.ToLower(severity) {
+	case "critical", "fatal", "emergency", "page", "high":
+		return ColorCritical
+	case "error", "major":
+		return ColorError
+	case "warning", "warn", "medium":
+		return ColorWarning
+	case "info", "informational", "low", "none":
+		return ColorInfo
+	default:
+		return ColorDefault
+	}
+}
+
+// Paginate splits embeds into pages of at most 10 embeds and 6000 characters each,
+// so every page can be sent as a single message
+func Paginate(embeds []*discordgo.MessageEmbed) [][]*discordgo.MessageEmbed {
+	var pages [][]*discordgo.MessageEmbed
+	var current []*discordgo.MessageEmbed
+	size := 0
+	for _, embed := range embeds {
+		embedSize := Size(embed)
+		if len(current) > 0 && (len(current) == MaxEmbedsPerMessage || size+embedSize > MaxTotalChars) {
+			pages = append(pages, current)
+			current, size = nil, 0
+		}
+		current = append(current, embed)
+		size += embedSize
+	}
+	if len(current) > 0 {
+		pages = append(pages, current)
+	}
+	return pages
+}
+
+// Size returns the number of characters Discord counts toward the 6000 character message limit
+func Size(embed *discordgo.MessageEmbed) int {
+	n := runeLen(embed.Title) + runeLen(embed.Description)
+	for _, field := range embed.Fields {
+		n += runeLen(field.Name) + runeLen(field.Value)
+	}
+	if embed.Footer != nil {
+		n += runeLen(embed.Footer.Text)
+	}
+	if embed.Author != nil {
+		n += runeLen(embed.Author.Name)
+	}
+	return n
+}
+
+// fit keeps a single embed within the 6000 character message limit by dropping trailing fields
+// and then shortening the description
+func fit(embed *discordgo.MessageEmbed) *discordgo.MessageEmbed {
+	for Size(embed) > MaxTotalChars && len(embed.Fields) > 0 {
+		embed.Fields = embed.Fields[:len(embed.Fields)-1]
+	}
+	if over := Size(embed) - MaxTotalChars; over > 0 {
+		keep := runeLen(embed.Description) - over
+		if keep < 1 {
+			keep = 1
+		}
+		embed.Description = truncate(embed.Description, keep)
+	}
+	return embed
+}
+
+// labelFields builds inline fields for the configured labels, or for all labels except alertname
+// (sorted) when none are configured
+func labelFields(labels map[string]string, names []string) []*discordgo.MessageEmbedField {
+	if len(names) == 0 {
+		for name := range labels {
+			if name != "alertname" {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+	}
+
+	var fields []*discordgo.MessageEmbedField
+	for _, name := range names {
+		value := labels[name]
+		if value == "" {
+			continue
+		}
+		if len(fields) == MaxFields {
+			break
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   truncate(name, MaxFieldName),
+			Value:  truncate(value, MaxFieldValue),
+			Inline: true,
+		})
+	}
+	return fields
+}
+
+// footerText builds the embed footer, e.g. "HighCPU · firing · alertmanager receiver"
+func footerText(alertName, status string, data *types.AlertManagerData) string {
+	parts := make([]string, 0, 3)
+	for _, part := range []string{alertName, status, data.Receiver} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+// truncateLines joins lines up to limit characters, summarising the lines that do not fit
+func truncateLines(lines []string, limit int) string {
+	// rest[i] is the length of lines[i:] joined with newlines
+	rest := make([]int, len(lines)+1)
+	for i := len(lines) - 1; i >= 0; i-- {
+		rest[i] = runeLen(lines[i]) + rest[i+1]
+		if i < len(lines)-1 {
+			rest[i]++
+		}
+	}
+
+	var b strings.Builder
+	size := 0
+	for i, line := range lines {
+		// room for the summary is only needed when the remaining lines do not all fit
+		reserve := 0
+		if i < len(lines)-1 && size+1+rest[i] > limit {
+			reserve = runeLen(fmt.Sprintf("\n…and %d more", len(lines)-i-1))
+		}
+		if i > 0 && size+1+runeLen(line)+reserve > limit {
+			b.WriteString(fmt.Sprintf("\n…and %d more", len(lines)-i))
+			break
+		}
+		if i > 0 {
+			b.WriteString("\n")
+			size++
+		}
+		b.WriteString(line)
+		size += runeLen(line)
+	}
+	return truncate(b.String(), limit)
+}
+
+// truncate shortens text to limit runes, ending with an ellipsis when cut
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+func runeLen(s string) int {
+	return len([]rune(s))
+}
+
+// toMap converts an annotations value to map[string]interface{}
+func toMap(value interface{}) map[string]interface{} {
+	switch m := value.(type) {
+	case map[string]interface{}:
+		return m
+	case map[string]string:
+		result := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			result[k] = v
+		}
+		return result
+	}
+	return nil
+}
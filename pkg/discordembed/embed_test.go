@@ -0,0 +1,159 @@
+package discordembed
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// embedOfSize builds an embed whose Size is exactly n
+func embedOfSize(n int) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{Description: strings.Repeat("x", n)}
+}
+
+func TestPaginate(t *testing.T) {
+	repeat := func(count, size int) []int {
+		sizes := make([]int, count)
+		for i := range sizes {
+			sizes[i] = size
+		}
+		return sizes
+	}
+
+	tests := []struct {
+		name  string
+		sizes []int
+		pages []int // embeds per page
+	}{
+		{"no embeds", nil, nil},
+		{"exactly 10 embeds fit one page", repeat(10, 100), []int{10}},
+		{"11th embed starts a new page", repeat(11, 100), []int{10, 1}},
+		{"21 embeds", repeat(21, 10), []int{10, 10, 1}},
+		{"total of exactly 6000 characters fits one page", []int{3000, 3000}, []int{2}},
+		{"one character over 6000 starts a new page", []int{3000, 3001}, []int{1, 1}},
+		{"single 6000 character embed", []int{6000, 1}, []int{1, 1}},
+		{"size limit before count limit", append(repeat(6, 1000), repeat(5, 100)...), []int{6, 5}},
+	}
+
+	for _, tt := range tests {
+		embeds := make([]*discordgo.MessageEmbed, 0, len(tt.sizes))
+		for _, size := range tt.sizes {
+			embeds = append(embeds, embedOfSize(size))
+		}
+
+		pages := Paginate(embeds)
+		var got []int
+		for _, page := range pages {
+			got = append(got, len(page))
+			total := 0
+			for _, embed := range page {
+				total += Size(embed)
+			}
+			if len(page) > MaxEmbedsPerMessage || total > MaxTotalChars {
+				t.Errorf("%s: page with %d embeds and %d characters exceeds the limits", tt.name, len(page), total)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.pages) {
+			t.Errorf("%s: pages = %v, want %v", tt.name, got, tt.pages)
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	fields := func(count int) []*discordgo.MessageEmbedField {
+		result := make([]*discordgo.MessageEmbedField, count)
+		for i := range result {
+			result[i] = &discordgo.MessageEmbedField{Name: fmt.Sprintf("field%02d", i), Value: strings.Repeat("v", MaxFieldValue)}
+		}
+		return result
+	}
+
+	tests := []struct {
+		name        string
+		embed       *discordgo.MessageEmbed
+		fields      int
+		description int
+	}{
+		{
+			"embed within the limit is unchanged",
+			&discordgo.MessageEmbed{Title: "HighCPU", Description: strings.Repeat("d", 100), Fields: fields(3)},
+			3, 100,
+		},
+		{
+			"embed of exactly 6000 characters is unchanged",
+			&discordgo.MessageEmbed{Description: strings.Repeat("d", MaxDescription), Footer: &discordgo.MessageEmbedFooter{Text: strings.Repeat("f", MaxTotalChars-MaxDescription)}},
+			0, MaxDescription,
+		},
+		{
+			// title 7 + description 4096 + 1031 per field: only one field fits
+			"trailing fields are dropped first",
+			&discordgo.MessageEmbed{Title: "HighCPU", Description: strings.Repeat("d", MaxDescription), Fields: fields(MaxFields)},
+			1, MaxDescription,
+		},
+		{
+			"description is shortened when no fields are left",
+			&discordgo.MessageEmbed{Title: "HighCPU", Description: strings.Repeat("d", MaxDescription), Footer: &discordgo.MessageEmbedFooter{Text: strings.Repeat("f", MaxFooter)}},
+			0, MaxTotalChars - 7 - MaxFooter,
+		},
+	}
+
+	for _, tt := range tests {
+		embed := fit(tt.embed)
+		if size := Size(embed); size > MaxTotalChars {
+			t.Errorf("%s: Size after fit = %d, want at most %d", tt.name, size, MaxTotalChars)
+		}
+		if len(embed.Fields) != tt.fields {
+			t.Errorf("%s: %d fields after fit, want %d", tt.name, len(embed.Fields), tt.fields)
+		}
+		if got := runeLen(embed.Description); got != tt.description {
+			t.Errorf("%s: description has %d characters after fit, want %d", tt.name, got, tt.description)
+		}
+	}
+
+	// a shortened description ends with an ellipsis and the embed uses the whole budget
+	embed := fit(tests[3].embed)
+	if !strings.HasSuffix(embed.Description, "…") || Size(embed) != MaxTotalChars {
+		t.Errorf("shortened embed: suffix %q, size %d, want an ellipsis and %d", embed.Description[len(embed.Description)-3:], Size(embed), MaxTotalChars)
+	}
+}
+
+func TestTruncateLines(t *testing.T) {
+	lines := func(count int) []string {
+		result := make([]string, count)
+		for i := range result {
+			result[i] = fmt.Sprintf("alert-%03d", i) // 9 characters
+		}
+		return result
+	}
+
+	tests := []struct {
+		name  string
+		lines []string
+		limit int
+		want  string
+	}{
+		{"lines within the limit", lines(2), 100, "alert-000\nalert-001"},
+		{"lines of exactly the limit", lines(3), 29, "alert-000\nalert-001\nalert-002"},
+		{"remaining lines are summarised", lines(10), 40, "alert-000\nalert-001\n…and 8 more"},
+		{"summary counts every dropped line", lines(3), 28, "alert-000\n…and 2 more"},
+		{"single line longer than the limit is cut", []string{strings.Repeat("x", 20)}, 10, strings.Repeat("x", 9) + "…"},
+	}
+
+	for _, tt := range tests {
+		got := truncateLines(tt.lines, tt.limit)
+		if got != tt.want {
+			t.Errorf("%s: truncateLines = %q, want %q", tt.name, got, tt.want)
+		}
+		if runeLen(got) > tt.limit {
+			t.Errorf("%s: %d characters, want at most %d", tt.name, runeLen(got), tt.limit)
+		}
+	}
+
+	// the group description never exceeds Discord's limit, however many alerts there are
+	if got := truncateLines(lines(1000), MaxDescription); runeLen(got) > MaxDescription || !strings.HasSuffix(got, "more") {
+		t.Errorf("1000 lines: %d characters ending %q, want at most %d with a summary", runeLen(got), got[len(got)-12:], MaxDescription)
+	}
+}
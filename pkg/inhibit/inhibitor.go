@@ -0,0 +1,166 @@
+package inhibit
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/matcher"
+	"alert-webhooks/pkg/notification/types"
+)
+
+const defaultFiringTTL = 4 * time.Hour
+
+// firingAlert 觸發索引中的單一警報
+type firingAlert struct {
+	labels    map[string]string
+	expiresAt time.Time
+}
+
+var (
+	mu        sync.RWMutex
+	rules     []*Rule
+	firingTTL = defaultFiringTTL
+
+	indexMu sync.Mutex
+	firing  = make(map[string]*firingAlert)
+)
+
+// Load 依配置重建全局抑制規則，配置無效時保留原本的規則；觸發索引不受影響
+func Load(conf config.InhibitConf) error {
+	loaded := make([]*Rule, 0, len(conf.Rules))
+	for i, ruleConf := range conf.Rules {
+		rule, err := NewRule(ruleConf)
+		if err != nil {
+			return fmt.Errorf("invalid inhibit rule %d: %v", i, err)
+		}
+		loaded = append(loaded, rule)
+	}
+
+	ttl := conf.FiringTTL
+	if ttl <= 0 {
+		ttl = defaultFiringTTL
+	}
+
+	mu.Lock()
+	rules = loaded
+	firingTTL = ttl
+	mu.Unlock()
+
+	if len(loaded) > 0 {
+		logger.Info("Inhibit rules loaded", "inhibit",
+			logger.Int("rules", len(loaded)),
+			logger.String("firing_ttl", ttl.String()))
+	}
+	return nil
+}
+
+// Enabled 檢查是否配置了抑制規則
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return len(rules) > 0
+}
+
+// Observe 以 payload 中的警報更新觸發索引：firing 警報加入或延長，resolved 警報移除
+func Observe(data *types.AlertManagerData) {
+	if data == nil || !Enabled() {
+		return
+	}
+
+	mu.RLock()
+	ttl := firingTTL
+	mu.RUnlock()
+
+	now := time.Now()
+
+	indexMu.Lock()
+	defer indexMu.Unlock()
+
+	gc(now)
+	for _, alert := range data.Alerts {
+		labels := matcher.AlertLabels(alert)
+		key := fingerprint(alert, labels)
+
+		status, _ := alert["status"].(string)
+		if status == "" {
+			status = data.Status
+		}
+		if status != "firing" {
+			delete(firing, key)
+			continue
+		}
+
+		firing[key] = &firingAlert{
+			labels:    labels,
+			expiresAt: expiry(alert, now, ttl),
+		}
+	}
+}
+
+// Inhibited 檢查警報是否被任何觸發中的來源警報抑制，返回抑制它的來源警報標籤
+func Inhibited(alert map[string]interface{}) (map[string]string, bool) {
+	mu.RLock()
+	active := rules
+	mu.RUnlock()
+
+	if len(active) == 0 {
+		return nil, false
+	}
+
+	labels := matcher.AlertLabels(alert)
+	key := fingerprint(alert, labels)
+	now := time.Now()
+
+	indexMu.Lock()
+	defer indexMu.Unlock()
+
+	for _, rule := range active {
+		if !rule.TargetMatchers.Matches(labels) {
+			continue
+		}
+		// 同時符合來源與目標的警報不能抑制自己，也不能抑制其他同樣同時符合的警報
+		targetIsSource := rule.SourceMatchers.Matches(labels)
+		for sourceKey, source := range firing {
+			if sourceKey == key || now.After(source.expiresAt) {
+				continue
+			}
+			if targetIsSource && rule.TargetMatchers.Matches(source.labels) {
+				continue
+			}
+			if rule.inhibits(source.labels, labels) {
+				return source.labels, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// gc 清除過期的觸發警報（需持有 indexMu）
+func gc(now time.Time) {
+	for key, alert := range firing {
+		if now.After(alert.expiresAt) {
+			delete(firing, key)
+		}
+	}
+}
+
+// fingerprint 返回警報的 fingerprint，缺少時以排序後的標籤代替
+func fingerprint(alert map[string]interface{}, labels map[string]string) string {
+	if fp, _ := alert["fingerprint"].(string); fp != "" {
+		return fp
+	}
+	return matcher.LabelsString(labels)
+}
+
+// expiry 計算觸發警報在索引中的到期時間：endsAt 在未來時使用 endsAt，否則使用 TTL
+func expiry(alert map[string]interface{}, now time.Time, ttl time.Duration) time.Time {
+	if endsAt, _ := alert["endsAt"].(string); endsAt != "" {
+		if t, err := time.Parse(time.RFC3339, endsAt); err == nil && t.After(now) {
+			return t
+		}
+	}
+	return now.Add(ttl)
+}
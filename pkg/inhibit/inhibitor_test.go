@@ -0,0 +1,153 @@
+package inhibit
+
+import (
+	"testing"
+	"time"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/notification/types"
+)
+
+// loadRules 載入抑制規則並清空觸發索引，測試結束後還原為未啟用
+func loadRules(t *testing.T, conf config.InhibitConf) {
+	t.Helper()
+	if err := Load(conf); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	resetIndex := func() {
+		indexMu.Lock()
+		firing = make(map[string]*firingAlert)
+		indexMu.Unlock()
+	}
+	resetIndex()
+	t.Cleanup(func() {
+		Load(config.InhibitConf{})
+		resetIndex()
+	})
+}
+
+func alert(status string, labels map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"status": status, "labels": labels}
+}
+
+func TestInhibited(t *testing.T) {
+	loadRules(t, config.InhibitConf{Rules: []config.InhibitRuleConf{
+		{SourceMatchers: []string{`severity="critical"`}, TargetMatchers: []string{`severity="warning"`}, Equal: []string{"instance"}},
+		// 來源與目標可能是同一個警報的規則
+		{SourceMatchers: []string{`alertname="NodeDown"`}, TargetMatchers: []string{`team="infra"`}, Equal: []string{"node"}},
+	}})
+
+	Observe(&types.AlertManagerData{Status: "firing", Alerts: []map[string]interface{}{
+		alert("firing", map[string]interface{}{"alertname": "HighCPU", "severity": "critical", "instance": "web-1"}),
+		alert("firing", map[string]interface{}{"alertname": "NodeDown", "team": "infra", "node": "n1"}),
+		alert("resolved", map[string]interface{}{"alertname": "HighCPU", "severity": "critical", "instance": "web-3"}),
+	}})
+
+	tests := []struct {
+		name   string
+		labels map[string]interface{}
+		want   bool
+	}{
+		{"target with equal labels", map[string]interface{}{"alertname": "HighLoad", "severity": "warning", "instance": "web-1"}, true},
+		{"target with different equal label", map[string]interface{}{"alertname": "HighLoad", "severity": "warning", "instance": "web-2"}, false},
+		{"resolved source does not inhibit", map[string]interface{}{"alertname": "HighLoad", "severity": "warning", "instance": "web-3"}, false},
+		{"source is not a target", map[string]interface{}{"alertname": "HighCPU", "severity": "critical", "instance": "web-1"}, false},
+		{"source does not inhibit itself", map[string]interface{}{"alertname": "NodeDown", "team": "infra", "node": "n1"}, false},
+		{"target matching the source side too is not inhibited", map[string]interface{}{"alertname": "NodeDown", "team": "infra", "node": "n1", "pod": "p1"}, false},
+		{"plain target inhibited by two-sided source", map[string]interface{}{"alertname": "PodCrash", "team": "infra", "node": "n1"}, true},
+	}
+
+	for _, tt := range tests {
+		if _, got := Inhibited(alert("firing", tt.labels)); got != tt.want {
+			t.Errorf("%s: Inhibited = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+
+	// 來源警報 resolved 後解除抑制
+	Observe(&types.AlertManagerData{Status: "resolved", Alerts: []map[string]interface{}{
+		alert("resolved", map[string]interface{}{"alertname": "HighCPU", "severity": "critical", "instance": "web-1"}),
+	}})
+	if _, inhibited := Inhibited(alert("firing", map[string]interface{}{"severity": "warning", "instance": "web-1"})); inhibited {
+		t.Error("target still inhibited after the source resolved")
+	}
+}
+
+func TestFiringIndexExpiry(t *testing.T) {
+	loadRules(t, config.InhibitConf{
+		FiringTTL: 30 * time.Millisecond,
+		Rules: []config.InhibitRuleConf{
+			{SourceMatchers: []string{`severity="critical"`}, TargetMatchers: []string{`severity="warning"`}, Equal: []string{"instance"}},
+		},
+	})
+
+	now := time.Now()
+	source := func(instance string, endsAt time.Time) map[string]interface{} {
+		a := alert("firing", map[string]interface{}{"severity": "critical", "instance": instance})
+		if !endsAt.IsZero() {
+			a["endsAt"] = endsAt.Format(time.RFC3339Nano)
+		}
+		return a
+	}
+	Observe(&types.AlertManagerData{Status: "firing", Alerts: []map[string]interface{}{
+		// 沒有 endsAt 時使用 firing_ttl
+		source("ttl", time.Time{}),
+		// endsAt 在未來時以 endsAt 為準
+		source("ends-late", now.Add(time.Hour)),
+		source("ends-soon", now.Add(10*time.Millisecond)),
+		// endsAt 已過去時回退為 firing_ttl
+		source("ends-past", now.Add(-time.Hour)),
+	}})
+
+	target := func(instance string) map[string]interface{} {
+		return alert("firing", map[string]interface{}{"severity": "warning", "instance": instance})
+	}
+
+	tests := []struct {
+		instance string
+		at       time.Duration
+		want     bool
+	}{
+		{"ttl", 0, true},
+		{"ends-late", 0, true},
+		{"ends-soon", 0, true},
+		{"ends-past", 0, true},
+		{"ends-soon", 20 * time.Millisecond, false},
+		{"ttl", 20 * time.Millisecond, true},
+		{"ttl", 50 * time.Millisecond, false},
+		{"ends-past", 50 * time.Millisecond, false},
+		{"ends-late", 50 * time.Millisecond, true},
+	}
+
+	for _, tt := range tests {
+		if wait := tt.at - time.Since(now); wait > 0 {
+			time.Sleep(wait)
+		}
+		if _, got := Inhibited(target(tt.instance)); got != tt.want {
+			t.Errorf("%s after %s: Inhibited = %t, want %t", tt.instance, tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestLoadKeepsRulesOnInvalidConfig(t *testing.T) {
+	loadRules(t, config.InhibitConf{Rules: []config.InhibitRuleConf{
+		{SourceMatchers: []string{`severity="critical"`}, TargetMatchers: []string{`severity="warning"`}},
+	}})
+
+	if err := Load(config.InhibitConf{Rules: []config.InhibitRuleConf{{SourceMatchers: []string{`severity="critical"`}}}}); err == nil {
+		t.Fatal("Load with a rule without target_matchers returned no error")
+	}
+	if !Enabled() {
+		t.Error("rules were dropped after a rejected Load")
+	}
+
+	// 沒有規則時不更新觸發索引
+	Load(config.InhibitConf{})
+	Observe(&types.AlertManagerData{Status: "firing", Alerts: []map[string]interface{}{
+		alert("firing", map[string]interface{}{"severity": "critical"}),
+	}})
+	indexMu.Lock()
+	defer indexMu.Unlock()
+	if len(firing) != 0 {
+		t.Errorf("firing index has %d alerts while inhibition is disabled", len(firing))
+	}
+}
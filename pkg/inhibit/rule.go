@@ -0,0 +1,55 @@
+// Package inhibit 提供 Alertmanager 風格的抑制規則：來源警報觸發時，抑制 equal 標籤相同的目標警報。
+// 來源警報取自已接收 payload 建立的記憶體觸發索引
+package inhibit
+
+import (
+	"fmt"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/matcher"
+)
+
+// Rule 單一抑制規則
+type Rule struct {
+	SourceMatchers matcher.Matchers
+	TargetMatchers matcher.Matchers
+	Equal          []string
+}
+
+// NewRule 從配置建立抑制規則
+func NewRule(conf config.InhibitRuleConf) (*Rule, error) {
+	if len(conf.SourceMatchers) == 0 {
+		return nil, fmt.Errorf("source_matchers is required")
+	}
+	if len(conf.TargetMatchers) == 0 {
+		return nil, fmt.Errorf("target_matchers is required")
+	}
+
+	source, err := matcher.ParseAll(conf.SourceMatchers)
+	if err != nil {
+		return nil, fmt.Errorf("source_matchers: %v", err)
+	}
+	target, err := matcher.ParseAll(conf.TargetMatchers)
+	if err != nil {
+		return nil, fmt.Errorf("target_matchers: %v", err)
+	}
+
+	return &Rule{
+		SourceMatchers: source,
+		TargetMatchers: target,
+		Equal:          conf.Equal,
+	}, nil
+}
+
+// inhibits 檢查來源警報是否依此規則抑制目標警報；不存在的 equal 標籤視為空字串
+func (r *Rule) inhibits(source, target map[string]string) bool {
+	if !r.SourceMatchers.Matches(source) {
+		return false
+	}
+	for _, name := range r.Equal {
+		if source[name] != target[name] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package inhibit
+
+import (
+	"strings"
+	"testing"
+
+	"alert-webhooks/config"
+)
+
+func TestNewRule(t *testing.T) {
+	tests := []struct {
+		conf config.InhibitRuleConf
+		err  string
+	}{
+		{config.InhibitRuleConf{TargetMatchers: []string{`severity="warning"`}}, "source_matchers is required"},
+		{config.InhibitRuleConf{SourceMatchers: []string{`severity="critical"`}}, "target_matchers is required"},
+		{config.InhibitRuleConf{SourceMatchers: []string{`severity`}, TargetMatchers: []string{`severity="warning"`}}, "source_matchers"},
+		{config.InhibitRuleConf{SourceMatchers: []string{`severity="critical"`}, TargetMatchers: []string{`severity=~"("`}}, "target_matchers"},
+		{config.InhibitRuleConf{SourceMatchers: []string{`severity="critical"`}, TargetMatchers: []string{`severity="warning"`}, Equal: []string{"instance"}}, ""},
+	}
+
+	for _, tt := range tests {
+		_, err := NewRule(tt.conf)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("NewRule(%+v) returned error: %v", tt.conf, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("NewRule(%+v) error = %v, want it to contain %q", tt.conf, err, tt.err)
+		}
+	}
+}
+
+func TestRuleInhibits(t *testing.T) {
+	rule, err := NewRule(config.InhibitRuleConf{
+		SourceMatchers: []string{`severity="critical"`},
+		TargetMatchers: []string{`severity="warning"`},
+		Equal:          []string{"instance", "cluster"},
+	})
+	if err != nil {
+		t.Fatalf("NewRule returned error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		source map[string]string
+		target map[string]string
+		want   bool
+	}{
+		{
+			"equal labels match",
+			map[string]string{"severity": "critical", "instance": "web-1", "cluster": "eu"},
+			map[string]string{"severity": "warning", "instance": "web-1", "cluster": "eu"},
+			true,
+		},
+		{
+			"equal label differs",
+			map[string]string{"severity": "critical", "instance": "web-1", "cluster": "eu"},
+			map[string]string{"severity": "warning", "instance": "web-2", "cluster": "eu"},
+			false,
+		},
+		{
+			"label missing on both sides counts as equal",
+			map[string]string{"severity": "critical", "instance": "web-1"},
+			map[string]string{"severity": "warning", "instance": "web-1"},
+			true,
+		},
+		{
+			"label missing on one side",
+			map[string]string{"severity": "critical", "instance": "web-1"},
+			map[string]string{"severity": "warning", "instance": "web-1", "cluster": "eu"},
+			false,
+		},
+		{
+			"source matchers must match",
+			map[string]string{"severity": "info", "instance": "web-1", "cluster": "eu"},
+			map[string]string{"severity": "warning", "instance": "web-1", "cluster": "eu"},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := rule.inhibits(tt.source, tt.target); got != tt.want {
+			t.Errorf("%s: inhibits = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
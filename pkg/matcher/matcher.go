@@ -0,0 +1,188 @@
+// Package matcher 提供 Alertmanager 風格的標籤匹配器（=、!=、=~、!~）
+package matcher
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MatchType 匹配類型
+type MatchType string
+
+const (
+	MatchEqual     MatchType = "="
+	MatchNotEqual  MatchType = "!="
+	MatchRegexp    MatchType = "=~"
+	MatchNotRegexp MatchType = "!~"
+)
+
+// Matcher 單一標籤匹配器
+type Matcher struct {
+	Name  string    `json:"name"`
+	Type  MatchType `json:"type"`
+	Value string    `json:"value"`
+
+	re *regexp.Regexp
+}
+
+// NewMatcher 創建匹配器，正則表達式會如同 Alertmanager 一樣完整錨定
+func NewMatcher(name string, matchType MatchType, value string) (*Matcher, error) {
+	if name == "" {
+		return nil, fmt.Errorf("matcher label name is required")
+	}
+
+	m := &Matcher{Name: name, Type: matchType, Value: value}
+
+	switch matchType {
+	case MatchEqual, MatchNotEqual:
+	case MatchRegexp, MatchNotRegexp:
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression in matcher %s%s%q: %v", name, matchType, value, err)
+		}
+		m.re = re
+	default:
+		return nil, fmt.Errorf("unsupported match type %q", matchType)
+	}
+
+	return m, nil
+}
+
+// Parse 解析字串形式的匹配器，例如 severity="critical"、env!=dev、service=~"api|web"
+func Parse(s string) (*Matcher, error) {
+	s = strings.TrimSpace(s)
+
+	// 依序尋找運算子，!= / =~ / !~ 需要比 = 優先判斷
+	idx := strings.IndexAny(s, "=!")
+	if idx <= 0 {
+		return nil, fmt.Errorf("invalid matcher %q: missing label name or operator", s)
+	}
+
+	name := strings.TrimSpace(s[:idx])
+	rest := s[idx:]
+
+	var matchType MatchType
+	switch {
+	case strings.HasPrefix(rest, "=~"):
+		matchType = MatchRegexp
+	case strings.HasPrefix(rest, "!~"):
+		matchType = MatchNotRegexp
+	case strings.HasPrefix(rest, "!="):
+		matchType = MatchNotEqual
+	case strings.HasPrefix(rest, "="):
+		matchType = MatchEqual
+	default:
+		return nil, fmt.Errorf("invalid matcher %q: unknown operator", s)
+	}
+
+	value := strings.TrimSpace(rest[len(matchType):])
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		value = strings.ReplaceAll(value[1:len(value)-1], `\"`, `"`)
+	}
+
+	return NewMatcher(name, matchType, value)
+}
+
+// Matches 檢查標籤是否符合匹配器，不存在的標籤視為空字串
+func (m *Matcher) Matches(labels map[string]string) bool {
+	value := labels[m.Name]
+
+	switch m.Type {
+	case MatchEqual:
+		return value == m.Value
+	case MatchNotEqual:
+		return value != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(value)
+	case MatchNotRegexp:
+		return !m.re.MatchString(value)
+	}
+	return false
+}
+
+// String 返回匹配器的字串表示
+func (m *Matcher) String() string {
+	return fmt.Sprintf("%s%s%q", m.Name, m.Type, m.Value)
+}
+
+// Matchers 匹配器集合，所有匹配器都符合才算匹配
+type Matchers []*Matcher
+
+// ParseAll 解析多個字串形式的匹配器
+func ParseAll(values []string) (Matchers, error) {
+	matchers := make(Matchers, 0, len(values))
+	for _, v := range values {
+		m, err := Parse(v)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers, nil
+}
+
+// Matches 檢查標籤是否符合所有匹配器，空集合匹配任何標籤
+func (ms Matchers) Matches(labels map[string]string) bool {
+	for _, m := range ms {
+		if !m.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// Strings 返回所有匹配器的字串表示
+func (ms Matchers) Strings() []string {
+	result := make([]string, 0, len(ms))
+	for _, m := range ms {
+		result = append(result, m.String())
+	}
+	return result
+}
+
+// LabelsFromMap 將 AlertManager payload 中的 map[string]interface{} 標籤轉為 map[string]string
+func LabelsFromMap(m map[string]interface{}) map[string]string {
+	labels := make(map[string]string, len(m))
+	for k, v := range m {
+		switch value := v.(type) {
+		case string:
+			labels[k] = value
+		case nil:
+		default:
+			labels[k] = fmt.Sprintf("%v", value)
+		}
+	}
+	return labels
+}
+
+// AlertLabels 從單筆 alert（map 結構）取出 labels
+func AlertLabels(alert map[string]interface{}) map[string]string {
+	switch labels := alert["labels"].(type) {
+	case map[string]interface{}:
+		return LabelsFromMap(labels)
+	case map[string]string:
+		result := make(map[string]string, len(labels))
+		for k, v := range labels {
+			result[k] = v
+		}
+		return result
+	}
+	return map[string]string{}
+}
+
+// LabelsString 以排序後的 key=value 形式輸出標籤，方便記錄日誌或作為鍵值
+func LabelsString(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", k, labels[k]))
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
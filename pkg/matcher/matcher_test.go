@@ -0,0 +1,160 @@
+package matcher
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input     string
+		name      string
+		matchType MatchType
+		value     string
+	}{
+		{`severity="critical"`, "severity", MatchEqual, "critical"},
+		{`severity=critical`, "severity", MatchEqual, "critical"},
+		{` env != dev `, "env", MatchNotEqual, "dev"},
+		{`service=~"api|web"`, "service", MatchRegexp, "api|web"},
+		{`service!~"test.*"`, "service", MatchNotRegexp, "test.*"},
+		{`summary="say \"hi\""`, "summary", MatchEqual, `say "hi"`},
+		{`team=""`, "team", MatchEqual, ""},
+		{`team=`, "team", MatchEqual, ""},
+		{`url="a=b"`, "url", MatchEqual, "a=b"},
+		{`path="x"y"`, "path", MatchEqual, `x"y`},
+	}
+
+	for _, tt := range tests {
+		m, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if m.Name != tt.name || m.Type != tt.matchType || m.Value != tt.value {
+			t.Errorf("Parse(%q) = %s %s %q, want %s %s %q", tt.input, m.Name, m.Type, m.Value, tt.name, tt.matchType, tt.value)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{``, "missing label name or operator"},
+		{`severity`, "missing label name or operator"},
+		{`="critical"`, "missing label name or operator"},
+		{`!="critical"`, "missing label name or operator"},
+		{`severity!critical`, "unknown operator"},
+		{`service=~"api("`, "invalid regular expression"},
+		{`service!~"[a-"`, "invalid regular expression"},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.input)
+		if err == nil {
+			t.Errorf("Parse(%q) returned no error, want %q", tt.input, tt.err)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Parse(%q) error = %q, want it to contain %q", tt.input, err, tt.err)
+		}
+	}
+}
+
+func TestNewMatcherErrors(t *testing.T) {
+	if _, err := NewMatcher("", MatchEqual, "x"); err == nil {
+		t.Error("NewMatcher with empty name returned no error")
+	}
+	if _, err := NewMatcher("a", MatchType("=="), "x"); err == nil {
+		t.Error("NewMatcher with unsupported type returned no error")
+	}
+}
+
+func TestMatches(t *testing.T) {
+	labels := map[string]string{
+		"severity": "critical",
+		"service":  "api-gateway",
+		"env":      "prod",
+	}
+
+	tests := []struct {
+		matcher string
+		want    bool
+	}{
+		{`severity="critical"`, true},
+		{`severity="warning"`, false},
+		{`severity!="warning"`, true},
+		{`severity!="critical"`, false},
+		// 正則表達式完整錨定，不做部分匹配
+		{`service=~"api"`, false},
+		{`service=~"api.*"`, true},
+		{`service=~"gateway"`, false},
+		{`service=~"web|api-gateway"`, true},
+		{`service!~"api"`, true},
+		{`service!~"api-.*"`, false},
+		// 錨定套用於整個交替式，而不只是第一個分支
+		{`env=~"dev|prod"`, true},
+		{`env=~"pro|x"`, false},
+		// 不存在的標籤視為空字串
+		{`team=""`, true},
+		{`team!=""`, false},
+		{`team=~".*"`, true},
+		{`team=~".+"`, false},
+		{`team!~".+"`, true},
+	}
+
+	for _, tt := range tests {
+		m, err := Parse(tt.matcher)
+		if err != nil {
+			t.Fatalf("Parse(%q) returned error: %v", tt.matcher, err)
+		}
+		if got := m.Matches(labels); got != tt.want {
+			t.Errorf("%s.Matches(%v) = %t, want %t", tt.matcher, labels, got, tt.want)
+		}
+	}
+}
+
+func TestMatchersMatches(t *testing.T) {
+	labels := map[string]string{"severity": "critical", "env": "prod"}
+
+	tests := []struct {
+		matchers []string
+		want     bool
+	}{
+		{nil, true},
+		{[]string{`severity="critical"`, `env="prod"`}, true},
+		{[]string{`severity="critical"`, `env="dev"`}, false},
+		{[]string{`severity=~"critical|warning"`, `env!="dev"`}, true},
+	}
+
+	for _, tt := range tests {
+		ms, err := ParseAll(tt.matchers)
+		if err != nil {
+			t.Fatalf("ParseAll(%q) returned error: %v", tt.matchers, err)
+		}
+		if got := ms.Matches(labels); got != tt.want {
+			t.Errorf("ParseAll(%q).Matches = %t, want %t", tt.matchers, got, tt.want)
+		}
+	}
+
+	if _, err := ParseAll([]string{`severity="critical"`, `bad`}); err == nil {
+		t.Error("ParseAll with an invalid matcher returned no error")
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	for _, input := range []string{`severity="critical"`, `env!="dev"`, `service=~"api|web"`, `summary="say \"hi\""`} {
+		m, err := Parse(input)
+		if err != nil {
+			t.Fatalf("Parse(%q) returned error: %v", input, err)
+		}
+		again, err := Parse(m.String())
+		if err != nil {
+			t.Fatalf("Parse(%q) returned error: %v", m.String(), err)
+		}
+		if again.Name != m.Name || again.Type != m.Type || again.Value != m.Value {
+			t.Errorf("Parse(%q.String()) = %s, want %s", input, again, m)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+// Package msgref 記錄警報群組發送到各提供者後的訊息參照（聊天 / 頻道與訊息 ID），
+// 讓 resolved 通知可以編輯原本的 firing 訊息，而不是另外發送一則新訊息。
+// 參照與互動按鈕目標一起持久化在 actions.db，重啟後 resolved 編輯、Slack 討論串與 Telegram 回覆仍可延續
+package msgref
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/matcher"
+	"alert-webhooks/pkg/notification/types"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const defaultTTL = 24 * time.Hour
+
+var refBucket = []byte("message_refs")
+
+// Ref 單一提供者訊息的參照
+type Ref struct {
+	Provider  string    `json:"provider"`
+	ChatID    string    `json:"chat_id"`    // Telegram chat ID、Slack channel ID 或 Discord channel ID
+	MessageID string    `json:"message_id"` // Telegram message ID、Slack ts 或 Discord message ID
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+var (
+	mu            sync.Mutex
+	refs          = make(map[string]*Ref)
+	db            *bolt.DB // 未開啟時參照只保存在記憶體中
+	ttl           = defaultTTL
+	editOnResolve bool
+	lastGC        time.Time
+)
+
+// Load 依配置更新 TTL 與 resolved 編輯開關；既有參照保留
+func Load(conf config.MessageRefConf) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	ttl = conf.TTL
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	editOnResolve = conf.EditOnResolve
+
+	logger.Info("Message reference store configured", "msgref",
+		logger.Bool("edit_on_resolve", editOnResolve),
+		logger.String("ttl", ttl.String()))
+}
+
+// Open 在已開啟的資料庫（actions.db）中建立訊息參照 bucket，載入未過期的參照並清除已過期的參照
+func Open(store *bolt.DB) error {
+	now := time.Now()
+	loaded := make(map[string]*Ref)
+	if err := store.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(refBucket)
+		if err != nil {
+			return err
+		}
+		var expired [][]byte
+		err = bucket.ForEach(func(k, v []byte) error {
+			ref := &Ref{}
+			if err := json.Unmarshal(v, ref); err != nil || !now.Before(ref.ExpiresAt) {
+				expired = append(expired, append([]byte(nil), k...))
+				return nil
+			}
+			loaded[string(k)] = ref
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to load message references: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for key, ref := range loaded {
+		if _, exists := refs[key]; !exists {
+			refs[key] = ref
+		}
+	}
+	db = store
+	lastGC = now
+
+	logger.Info("Message reference store opened", "msgref",
+		logger.String("path", store.Path()),
+		logger.Int("refs", len(loaded)))
+	return nil
+}
+
+// Close 停止持久化，之後的參照只保存在記憶體中；資料庫由 actions 關閉
+func Close() {
+	mu.Lock()
+	defer mu.Unlock()
+	db = nil
+}
+
+// EditOnResolve 檢查 resolved 通知是否應編輯原本的訊息
+func EditOnResolve() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return editOnResolve
+}
+
+// Key 以提供者、目的地（chat ID / 頻道）與警報群組計算鍵值，不含狀態，
+// firing 與 resolved 會對應到同一個鍵值；群組優先使用 GroupKey，缺少時使用排序後的 fingerprint。
+// 沒有 AlertManager 數據或無法識別群組時返回空字串
+func Key(provider, destination string, data *types.AlertManagerData) string {
+	if data == nil {
+		return ""
+	}
+
+	group := data.GroupKey
+	if group == "" {
+		fingerprints := make([]string, 0, len(data.Alerts))
+		for _, alert := range data.Alerts {
+			fingerprint, _ := alert["fingerprint"].(string)
+			if fingerprint == "" {
+				fingerprint = matcher.LabelsString(matcher.AlertLabels(alert))
+			}
+			if fingerprint != "" {
+				fingerprints = append(fingerprints, fingerprint)
+			}
+		}
+		if len(fingerprints) == 0 {
+			return ""
+		}
+		sort.Strings(fingerprints)
+		group = strings.Join(fingerprints, ",")
+	}
+
+	return provider + "\x00" + destination + "\x00" + group
+}
+
+// Put 記錄訊息參照，key 為空時不做任何事
+func Put(key string, ref Ref) {
+	if key == "" {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	now := time.Now()
+	gc(now)
+	ref.ExpiresAt = now.Add(ttl)
+	refs[key] = &ref
+	save(key, &ref)
+}
+
+// Get 取得未過期的訊息參照
+func Get(key string) (Ref, bool) {
+	if key == "" {
+		return Ref{}, false
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	ref, ok := refs[key]
+	if !ok || !time.Now().Before(ref.ExpiresAt) {
+		return Ref{}, false
+	}
+	return *ref, true
+}
+
+// Delete 移除訊息參照
+func Delete(key string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := refs[key]; !ok {
+		return
+	}
+	delete(refs, key)
+	remove([]string{key})
+}
+
+// IsResolved 檢查 AlertManager 數據是否為 resolved 通知
+func IsResolved(data *types.AlertManagerData) bool {
+	return data != nil && strings.EqualFold(data.Status, "resolved")
+}
+
+// gc 清除過期的參照（需持有鎖），最多每分鐘執行一次
+func gc(now time.Time) {
+	if now.Sub(lastGC) < time.Minute {
+		return
+	}
+	lastGC = now
+	var expired []string
+	for key, ref := range refs {
+		if !now.Before(ref.ExpiresAt) {
+			delete(refs, key)
+			expired = append(expired, key)
+		}
+	}
+	remove(expired)
+}
+
+// save 將參照寫入資料庫（需持有鎖），資料庫未開啟時不做任何事
+func save(key string, ref *Ref) {
+	if db == nil {
+		return
+	}
+	data, err := json.Marshal(ref)
+	if err == nil {
+		err = db.Update(func(tx *bolt.Tx) error {
+			return tx.Bucket(refBucket).Put([]byte(key), data)
+		})
+	}
+	if err != nil {
+		logger.Error("Failed to persist message reference", "msgref",
+			logger.String("provider", ref.Provider),
+			logger.Err(err))
+	}
+}
+
+// remove 從資料庫刪除參照（需持有鎖），資料庫未開啟或沒有鍵值時不做任何事
+func remove(keys []string) {
+	if db == nil || len(keys) == 0 {
+		return
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(refBucket)
+		for _, key := range keys {
+			if err := bucket.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		logger.Error("Failed to delete message references", "msgref", logger.Err(err))
+	}
+}
@@ -0,0 +1,145 @@
+package msgref
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/notification/types"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// openStore 開啟 dir 下的資料庫並載入訊息參照，返回關閉函數
+func openStore(t *testing.T, dir string) func() {
+	t.Helper()
+	store, err := bolt.Open(filepath.Join(dir, "actions.db"), 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("bolt.Open returned error: %v", err)
+	}
+	if err := Open(store); err != nil {
+		store.Close()
+		t.Fatalf("Open returned error: %v", err)
+	}
+	return func() {
+		Close()
+		store.Close()
+		// 模擬重啟：清空記憶體中的參照
+		mu.Lock()
+		refs = make(map[string]*Ref)
+		mu.Unlock()
+	}
+}
+
+func TestRefsSurviveRestart(t *testing.T) {
+	Load(config.MessageRefConf{TTL: time.Hour})
+	defer Load(config.MessageRefConf{})
+	dir := t.TempDir()
+
+	data := &types.AlertManagerData{GroupKey: `{}:{alertname="HighCPU"}`}
+	kept := Key("slack", "#ops", data)
+	deleted := Key("telegram", "-100123", data)
+
+	closeStore := openStore(t, dir)
+	Put(kept, Ref{Provider: "slack", ChatID: "C123", MessageID: "1700000000.000100"})
+	Put(deleted, Ref{Provider: "telegram", ChatID: "-100123", MessageID: "42"})
+	Delete(deleted)
+	closeStore()
+
+	if _, ok := Get(kept); ok {
+		t.Fatal("reference still in memory after the simulated restart")
+	}
+
+	closeStore = openStore(t, dir)
+	defer closeStore()
+
+	ref, ok := Get(kept)
+	if !ok {
+		t.Fatal("reference was not restored from the database")
+	}
+	if ref.Provider != "slack" || ref.ChatID != "C123" || ref.MessageID != "1700000000.000100" {
+		t.Errorf("restored reference = %+v", ref)
+	}
+	if time.Until(ref.ExpiresAt) < 59*time.Minute {
+		t.Errorf("restored reference expires at %s, want about an hour from now", ref.ExpiresAt)
+	}
+	if _, ok := Get(deleted); ok {
+		t.Error("deleted reference was restored")
+	}
+}
+
+func TestExpiredRefsAreDroppedOnOpen(t *testing.T) {
+	Load(config.MessageRefConf{TTL: 20 * time.Millisecond})
+	defer Load(config.MessageRefConf{})
+	dir := t.TempDir()
+
+	key := Key("discord", "123456", &types.AlertManagerData{GroupKey: "group"})
+
+	closeStore := openStore(t, dir)
+	Put(key, Ref{Provider: "discord", ChatID: "123456", MessageID: "789"})
+	if _, ok := Get(key); !ok {
+		t.Fatal("Get did not find the reference inside the TTL")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := Get(key); ok {
+		t.Error("Get found the reference after the TTL")
+	}
+	closeStore()
+
+	closeStore = openStore(t, dir)
+	defer closeStore()
+	if _, ok := Get(key); ok {
+		t.Error("expired reference was restored")
+	}
+
+	// 過期的參照在載入時從資料庫清除
+	mu.Lock()
+	store := db
+	mu.Unlock()
+	if err := store.View(func(tx *bolt.Tx) error {
+		if n := tx.Bucket(refBucket).Stats().KeyN; n != 0 {
+			t.Errorf("bucket has %d references after loading, want 0", n)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("View returned error: %v", err)
+	}
+}
+
+func TestKey(t *testing.T) {
+	alerts := func(fingerprints ...string) []map[string]interface{} {
+		var result []map[string]interface{}
+		for _, fingerprint := range fingerprints {
+			result = append(result, map[string]interface{}{"fingerprint": fingerprint})
+		}
+		return result
+	}
+
+	firing := Key("slack", "#ops", &types.AlertManagerData{Status: "firing", GroupKey: "group"})
+	tests := []struct {
+		name string
+		key  string
+		same bool
+	}{
+		{"resolved maps to the firing key", Key("slack", "#ops", &types.AlertManagerData{Status: "resolved", GroupKey: "group"}), true},
+		{"destination changes the key", Key("slack", "#db", &types.AlertManagerData{GroupKey: "group"}), false},
+		{"provider changes the key", Key("discord", "#ops", &types.AlertManagerData{GroupKey: "group"}), false},
+	}
+	for _, tt := range tests {
+		if got := tt.key == firing; got != tt.same {
+			t.Errorf("%s: key equal = %t, want %t", tt.name, got, tt.same)
+		}
+	}
+
+	// 缺少 GroupKey 時以排序後的 fingerprint 識別群組
+	if Key("telegram", "1", &types.AlertManagerData{Alerts: alerts("b", "a")}) != Key("telegram", "1", &types.AlertManagerData{Alerts: alerts("a", "b")}) {
+		t.Error("fingerprint order changed the key")
+	}
+	if key := Key("telegram", "1", &types.AlertManagerData{}); key != "" {
+		t.Errorf("Key without group = %q, want empty", key)
+	}
+	if key := Key("telegram", "1", nil); key != "" {
+		t.Errorf("Key(nil) = %q, want empty", key)
+	}
+}
@@ -3,6 +3,7 @@ package notification
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 
 	"alert-webhooks/config"
@@ -135,6 +136,55 @@ func (nm *NotificationManager) SendNotification(ctx context.Context, providerNam
 	}, nil
 }
 
+// SendToAll 將同一個通知扇出到所有已註冊的提供者，並回傳每個提供者的結果
+func (nm *NotificationManager) SendToAll(ctx context.Context, req *types.NotificationRequest) []*types.NotificationResponse {
+	providerNames := nm.GetProviderNames()
+	destinations := make([]types.Destination, 0, len(providerNames))
+	for _, providerName := range providerNames {
+		destinations = append(destinations, types.Destination{
+			Provider: providerName,
+			Level:    req.Level,
+			Channel:  req.Channel,
+		})
+	}
+
+	return nm.SendToDestinations(ctx, req, destinations)
+}
+
+// SendToDestinations 將同一個通知依序發送到指定的目的地，並回傳每個目的地的結果
+// 每個目的地使用獨立的請求副本，避免某個平台渲染後的訊息被其他平台沿用
+func (nm *NotificationManager) SendToDestinations(ctx context.Context, req *types.NotificationRequest, destinations []types.Destination) []*types.NotificationResponse {
+	results := make([]*types.NotificationResponse, 0, len(destinations))
+
+	for _, dest := range destinations {
+		destReq := *req
+		destReq.ProviderName = dest.Provider
+		destReq.Level = dest.Level
+		destReq.Channel = dest.Channel
+		if destReq.TemplateName == "" {
+			destReq.TemplateName = dest.Template
+		}
+		if req.Options != nil {
+			destReq.Options = make(map[string]interface{}, len(req.Options))
+			for k, v := range req.Options {
+				destReq.Options[k] = v
+			}
+		}
+
+		resp, err := nm.SendNotification(ctx, dest.Provider, &destReq)
+		if err != nil {
+			logger.Warn("Failed to deliver notification to provider", "notification_manager",
+				logger.String("provider", dest.Provider),
+				logger.String("level", dest.Level),
+				logger.String("channel", dest.Channel),
+				logger.Err(err))
+		}
+		results = append(results, resp)
+	}
+
+	return results
+}
+
 // preprocessRequest 預處理請求
 func (nm *NotificationManager) preprocessRequest(req *types.NotificationRequest, providerName string) error {
 	// If AlertManager data is provided but no message content, render template
@@ -148,14 +198,18 @@ func (nm *NotificationManager) preprocessRequest(req *types.NotificationRequest,
 			if err != nil {
 				return fmt.Errorf("failed to convert AlertManager data: %v", err)
 			}
+			templateData.FormatOptions = nm.getProviderFormatOptions(providerName)
+			templateData.Level = req.Level
 			
-			// 渲染模板
-			actualLanguage := nm.templateEngine.GetDefaultLanguage(templateLanguage)
-			message, err := nm.templateEngine.RenderTemplate(actualLanguage, *templateData)
+			// 渲染模板（依提供者平台輸出對應的格式）；具名模板集依請求 / 路由、level 與提供者配置選擇
+			templateName := template.SelectTemplateSet(req.TemplateName, providerName, req.Level)
+			actualLanguage := nm.templateEngine.GetTemplateSetLanguage(templateName, templateLanguage)
+			message, err := nm.templateEngine.RenderNamedTemplateForPlatform(templateName, actualLanguage, providerName, *templateData)
 			if err != nil {
 				logger.Warn("Failed to render template, will use raw data", "notification_manager",
 					logger.String("provider", providerName),
 					logger.String("language", actualLanguage),
+					logger.String("template_set", templateName),
 					logger.Err(err))
 				return err
 			}
@@ -163,7 +217,8 @@ func (nm *NotificationManager) preprocessRequest(req *types.NotificationRequest,
 			req.Message = message
 			logger.Info("Template rendered successfully", "notification_manager",
 				logger.String("provider", providerName),
-				logger.String("language", actualLanguage))
+				logger.String("language", actualLanguage),
+				logger.String("template_set", templateName))
 		}
 	}
 	
@@ -183,11 +238,31 @@ func (nm *NotificationManager) getProviderTemplateLanguage(providerName, request
 		return config.Telegram.TemplateLanguage
 	case "slack":
 		return config.Slack.TemplateLanguage
+	case "discord":
+		return config.Conf.Discord.TemplateLanguage
 	default:
 		return "eng" // 預設英文
 	}
 }
 
+// getProviderFormatOptions 根據提供者的 template_mode 取得格式化選項
+func (nm *NotificationManager) getProviderFormatOptions(providerName string) template.FormatOptions {
+	var templateMode string
+	switch providerName {
+	case "telegram":
+		templateMode = config.Telegram.TemplateMode
+	case "slack":
+		templateMode = config.Slack.TemplateMode
+	case "discord":
+		templateMode = config.Conf.Discord.TemplateMode
+	}
+
+	if templateMode == "minimal" {
+		return nm.templateEngine.GetMinimalDefaultConfig().FormatOptions
+	}
+	return nm.templateEngine.GetCurrentFormatOptions()
+}
+
 // convertAlertManagerData 轉換 AlertManager 數據為模板格式
 func (nm *NotificationManager) convertAlertManagerData(data *types.AlertManagerData) (*template.TemplateData, error) {
 	if data == nil {
@@ -294,6 +369,19 @@ func (nm *NotificationManager) GetProvider(name string) (types.NotificationProvi
 	return provider, exists
 }
 
+// GetProviderNames 獲取所有已註冊提供者的名稱（依名稱排序）
+func (nm *NotificationManager) GetProviderNames() []string {
+	nm.mu.RLock()
+	defer nm.mu.RUnlock()
+
+	names := make([]string, 0, len(nm.providers))
+	for name := range nm.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // GetAllProviders 獲取所有提供者
 func (nm *NotificationManager) GetAllProviders() map[string]types.NotificationProvider {
 	nm.mu.RLock()
@@ -0,0 +1,23 @@
+package providers
+
+import "alert-webhooks/pkg/notification/types"
+
+// MessageLengthLimits 返回各提供者 ProviderCapabilities.MaxMessageLength，
+// 不需要已初始化的服務，供模板驗證與預覽在發送前檢查訊息長度
+func MessageLengthLimits() map[string]int {
+	return map[string]int{
+		"telegram": (&TelegramProvider{}).GetCapabilities().MaxMessageLength,
+		"slack":    (&SlackProvider{}).GetCapabilities().MaxMessageLength,
+		"discord":  (&DiscordProvider{}).GetCapabilities().MaxMessageLength,
+	}
+}
+
+// Capabilities 返回各提供者的 ProviderCapabilities，不需要已初始化的服務，
+// 供路由樹在載入配置時驗證提供者名稱與接收者是否支援 level / channel
+func Capabilities() map[string]*types.ProviderCapabilities {
+	return map[string]*types.ProviderCapabilities{
+		"telegram": (&TelegramProvider{}).GetCapabilities(),
+		"slack":    (&SlackProvider{}).GetCapabilities(),
+		"discord":  (&DiscordProvider{}).GetCapabilities(),
+	}
+}
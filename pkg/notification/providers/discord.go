@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"strings"
 
+	"alert-webhooks/pkg/actions"
+	"alert-webhooks/pkg/discordembed"
 	"alert-webhooks/pkg/notification/types"
 
 	"go.opentelemetry.io/otel"
@@ -15,8 +17,10 @@ import (
 // DiscordService interface to avoid circular dependencies
 type DiscordService interface {
 	SendMessage(ctx context.Context, level string, message string) error
-	SendMessageToChannel(channelID, message string) error
+	SendMessageToChannel(ctx context.Context, channelID, message string) error
 	SendMessageToLevel(ctx context.Context, level string, message string) error
+	SendChannelMessage(ctx context.Context, channelID, message string, opts *types.DiscordMessageOptions) error
+	SendLevelMessage(ctx context.Context, level, message string, opts *types.DiscordMessageOptions) error
 	TestConnection() error
 	ValidateChannel(channelID string) error
 	GetBotInfo() (interface{}, error)
@@ -43,13 +47,18 @@ func (dp *DiscordProvider) GetName() string {
 
 // GetCapabilities returns the provider capabilities
 func (dp *DiscordProvider) GetCapabilities() *types.ProviderCapabilities {
+	supportedLanguages := []string{"eng", "tw", "zh", "ja", "ko"}
+	if dp.templateEngine != nil {
+		supportedLanguages = dp.templateEngine.GetSupportedLanguages()
+	}
+
 	return &types.ProviderCapabilities{
 		SupportsLevels:       true,
 		SupportsChannels:     true,
 		SupportsRichText:     true,
 		SupportsAttachments:  false,
 		MaxMessageLength:     2000,
-		SupportedLanguages:   dp.templateEngine.GetSupportedLanguages(),
+		SupportedLanguages:   supportedLanguages,
 	}
 }
 
@@ -90,7 +99,7 @@ func (dp *DiscordProvider) SendMessage(ctx context.Context, req *types.Notificat
 	if req.Message != "" {
 		var err error
 		if req.Channel != "" {
-			err = dp.service.SendMessageToChannel(req.Channel, req.Message)
+			err = dp.service.SendMessageToChannel(ctx, req.Channel, req.Message)
 		} else if req.Level != "" {
 			err = dp.service.SendMessage(ctx, req.Level, req.Message)
 		} else {
@@ -108,10 +117,18 @@ func (dp *DiscordProvider) SendMessage(ctx context.Context, req *types.Notificat
 			span.SetStatus(codes.Error, err.Error())
 			return err
 		}
+		// Attach action buttons to firing alerts; embed format replaces the rendered text
+		opts := &types.DiscordMessageOptions{
+			Components: actions.DiscordComponents(req.AlertData),
+			AlertData:  req.AlertData,
+		}
+		if discordembed.Enabled() {
+			opts.Embeds = discordembed.AlertEmbeds(req.AlertData)
+		}
 		if req.Channel != "" {
-			err = dp.service.SendMessageToChannel(req.Channel, message)
+			err = dp.service.SendChannelMessage(ctx, req.Channel, message, opts)
 		} else if req.Level != "" {
-			err = dp.service.SendMessage(ctx, req.Level, message)
+			err = dp.service.SendLevelMessage(ctx, req.Level, message, opts)
 		} else {
 			err = fmt.Errorf("either channel or level must be specified")
 		}
@@ -18,6 +18,8 @@ import (
 type SlackService interface {
 	SendMessage(ctx context.Context, channel, message string) error
 	SendMessageToLevel(ctx context.Context, level, message string) error
+	SendChannelMessage(ctx context.Context, channel, message string, opts *types.SlackMessageOptions) error
+	SendLevelMessage(ctx context.Context, level, message string, opts *types.SlackMessageOptions) error
 	TestConnection() error
 }
 
@@ -78,6 +80,13 @@ func (sp *SlackProvider) SendMessage(ctx context.Context, req *types.Notificatio
 	var channel string
 	var err error
 
+	// blocks 模式的版面與互動按鈕由 SlackService 依 AlertData 產生
+	opts := &types.SlackMessageOptions{
+		AlertData:    req.AlertData,
+		TemplateName: req.TemplateName,
+		Language:     req.TemplateLanguage,
+	}
+
 	// 決定發送到哪個頻道
 	if req.Channel != "" {
 		// 直接指定頻道
@@ -85,10 +94,10 @@ func (sp *SlackProvider) SendMessage(ctx context.Context, req *types.Notificatio
 		if !strings.HasPrefix(channel, "#") && !strings.HasPrefix(channel, "@") {
 			channel = "#" + channel
 		}
-		err = sp.slackService.SendMessage(ctx, channel, req.Message)
+		err = sp.slackService.SendChannelMessage(ctx, channel, req.Message, opts)
 	} else if req.Level != "" {
 		// 根據等級發送
-		err = sp.slackService.SendMessageToLevel(ctx, req.Level, req.Message)
+		err = sp.slackService.SendLevelMessage(ctx, req.Level, req.Message, opts)
 		channel = sp.getLevelChannel(req.Level)
 	} else {
 		// 使用預設頻道
@@ -96,7 +105,7 @@ func (sp *SlackProvider) SendMessage(ctx context.Context, req *types.Notificatio
 		if channel == "" {
 			channel = "#alerts" // 預設頻道
 		}
-		err = sp.slackService.SendMessage(ctx, channel, req.Message)
+		err = sp.slackService.SendChannelMessage(ctx, channel, req.Message, opts)
 	}
 
 	if err != nil {
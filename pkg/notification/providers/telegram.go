@@ -8,9 +8,11 @@ import (
 	"time"
 
 	"alert-webhooks/config"
+	"alert-webhooks/pkg/actions"
 	"alert-webhooks/pkg/logger"
 	"alert-webhooks/pkg/notification/types"
 
+	"github.com/go-telegram/bot/models"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -19,6 +21,7 @@ import (
 // TelegramService 接口定義（避免循環依賴）
 type TelegramService interface {
 	SendMessage(ctx context.Context, level int, message string) error
+	SendMessageWithOptions(ctx context.Context, level int, message string, opts *types.TelegramMessageOptions) (*models.Message, error)
 	GetBotInfo() (interface{}, error)
 }
 
@@ -103,8 +106,12 @@ func (tp *TelegramProvider) SendMessage(ctx context.Context, req *types.Notifica
 			logger.String("message_preview", messagePreview))
 	}
 
-	// Send message
-	err = tp.telegramService.SendMessage(ctx, level, req.Message)
+	// Send message，觸發中的警報附加互動按鈕
+	opts := &types.TelegramMessageOptions{AlertData: req.AlertData}
+	if keyboard := actions.TelegramKeyboard(req.AlertData); keyboard != nil {
+		opts.ReplyMarkup = keyboard
+	}
+	_, err = tp.telegramService.SendMessageWithOptions(ctx, level, req.Message, opts)
 	if err != nil {
 		tp.stats.MessagesError++
 		span.RecordError(err)
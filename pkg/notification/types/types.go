@@ -2,6 +2,8 @@ package types
 
 import (
 	"context"
+
+	"github.com/bwmarrin/discordgo"
 )
 
 // TemplateEngine 接口定義（避免循環依賴）
@@ -32,11 +34,37 @@ type NotificationRequest struct {
 	Message      string // Simple text message
 	AlertData    *AlertManagerData // AlertManager 數據
 	TemplateLanguage string // 模板語言
+	TemplateName     string // 具名模板集，空值依路由、level 與提供者配置選擇
 	
 	// 針對特定提供者的額外選項
 	Options map[string]interface{}
 }
 
+// TelegramMessageOptions Telegram 訊息的額外選項
+type TelegramMessageOptions struct {
+	ReplyMarkup interface{}       // inline keyboard，nil 表示不附加
+	AlertData   *AlertManagerData // 用於記錄訊息參照與 resolved 時編輯原訊息，nil 表示不處理
+}
+
+// SlackMessageOptions Slack 訊息的額外選項
+type SlackMessageOptions struct {
+	Blocks       []interface{}     // Block Kit blocks；nil 時由 SlackService 依 template_mode 與互動按鈕為 AlertData 產生
+	ThreadTS     string            // 回覆到指定 thread
+	Username     string            // 覆寫顯示名稱
+	IconURL      string            // 覆寫頭像 URL
+	IconEmoji    string            // 覆寫頭像 emoji
+	AlertData    *AlertManagerData // 用於記錄訊息參照、resolved 時編輯原訊息與產生 blocks，nil 表示不處理
+	TemplateName string            // blocks 模式使用的具名模板集，空值依 level 與提供者配置選擇
+	Language     string            // blocks 模式使用的模板語言，空值使用 slack.template_language
+}
+
+// DiscordMessageOptions Discord 訊息的額外選項
+type DiscordMessageOptions struct {
+	Components []discordgo.MessageComponent // 按鈕等元件，附加在最後一則訊息
+	Embeds     []*discordgo.MessageEmbed    // embed 訊息格式，設定時取代文字內容並依 Discord 限制分頁
+	AlertData  *AlertManagerData            // 用於記錄訊息參照與 resolved 時編輯原訊息，nil 表示不處理
+}
+
 // NotificationResponse 統一的通知響應結構
 type NotificationResponse struct {
 	Success  bool   `json:"success"`
@@ -48,6 +76,14 @@ type NotificationResponse struct {
 	Channel  string `json:"channel,omitempty"`
 }
 
+// Destination 通知目的地（提供者 + 等級或頻道），由路由樹決定
+type Destination struct {
+	Provider string `json:"provider"`
+	Level    string `json:"level,omitempty"`
+	Channel  string `json:"channel,omitempty"`
+	Template string `json:"template,omitempty"` // 路由指定的具名模板集
+}
+
 // ProviderStatus 提供者狀態
 type ProviderStatus struct {
 	Name       string            `json:"name"`
@@ -0,0 +1,177 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/notification/types"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var deadLetterBucket = []byte("dead_letters")
+
+// DeadLetter 重試次數用盡後保留的投遞紀錄
+type DeadLetter struct {
+	ID               string                  `json:"id"`
+	Provider         string                  `json:"provider"`
+	Level            string                  `json:"level,omitempty"`
+	Channel          string                  `json:"channel,omitempty"`
+	ChatID           string                  `json:"chat_id,omitempty"`
+	Message          string                  `json:"message,omitempty"`          // 原始請求中的文字訊息
+	RenderedMessage  string                  `json:"rendered_message,omitempty"` // 最後一次嘗試時實際送出的訊息
+	TemplateLanguage string                  `json:"template_language,omitempty"`
+	TemplateName     string                  `json:"template_name,omitempty"`
+	Payload          *types.AlertManagerData `json:"payload,omitempty"` // 原始 AlertManager payload
+	Options          map[string]interface{}  `json:"options,omitempty"`
+	Attempts         int                     `json:"attempts"`
+	Deferrals        int                     `json:"deferrals,omitempty"` // 因提供者速率限制延後的次數
+	LastError        string                  `json:"last_error"`
+	CreatedAt        time.Time               `json:"created_at"`
+	FailedAt         time.Time               `json:"failed_at"`
+}
+
+// newDeadLetter 從重試用盡的任務建立死信紀錄
+func newDeadLetter(job *Job) *DeadLetter {
+	return &DeadLetter{
+		ID:               job.ID,
+		Provider:         job.Provider,
+		Level:            job.Level,
+		Channel:          job.Channel,
+		ChatID:           job.ChatID,
+		Message:          job.Message,
+		RenderedMessage:  job.RenderedMessage,
+		TemplateLanguage: job.TemplateLanguage,
+		TemplateName:     job.TemplateName,
+		Payload:          job.AlertData,
+		Options:          job.Options,
+		Attempts:         job.Attempts,
+		Deferrals:        job.Deferrals,
+		LastError:        job.LastError,
+		CreatedAt:        job.CreatedAt,
+		FailedAt:         time.Now(),
+	}
+}
+
+// Request 將死信還原為通知請求，dest 不為 nil 時改送到新的目的地
+func (d *DeadLetter) Request(dest *types.Destination) *types.NotificationRequest {
+	req := &types.NotificationRequest{
+		ProviderName:     d.Provider,
+		Level:            d.Level,
+		Channel:          d.Channel,
+		ChatID:           d.ChatID,
+		Message:          d.Message,
+		AlertData:        d.Payload,
+		TemplateLanguage: d.TemplateLanguage,
+		TemplateName:     d.TemplateName,
+		Options:          d.Options,
+	}
+
+	if dest != nil {
+		req.ProviderName = dest.Provider
+		req.Level = dest.Level
+		req.Channel = dest.Channel
+		req.ChatID = ""
+	}
+
+	return req
+}
+
+// moveToDeadLetter 在同一個交易中移除待處理任務並寫入死信
+func (q *Queue) moveToDeadLetter(job *Job) error {
+	data, err := json.Marshal(newDeadLetter(job))
+	if err != nil {
+		return fmt.Errorf("failed to encode dead letter: %v", err)
+	}
+
+	return q.db.Update(func(tx *bolt.Tx) error {
+		if err := deletePending(tx, job.ID); err != nil {
+			return err
+		}
+		return tx.Bucket(deadLetterBucket).Put([]byte(job.ID), data)
+	})
+}
+
+// ListDeadLetters 列出死信，provider 為空時返回全部
+func (q *Queue) ListDeadLetters(provider string) ([]*DeadLetter, error) {
+	letters := []*DeadLetter{}
+	err := q.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(deadLetterBucket).ForEach(func(k, v []byte) error {
+			letter := &DeadLetter{}
+			if err := json.Unmarshal(v, letter); err != nil {
+				logger.Warn("Skipping undecodable dead letter", "queue",
+					logger.String("id", string(k)),
+					logger.Err(err))
+				return nil
+			}
+			if provider != "" && !strings.EqualFold(letter.Provider, provider) {
+				return nil
+			}
+			letters = append(letters, letter)
+			return nil
+		})
+	})
+	return letters, err
+}
+
+// GetDeadLetter 查詢單筆死信
+func (q *Queue) GetDeadLetter(id string) (*DeadLetter, error) {
+	var letter *DeadLetter
+	err := q.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(deadLetterBucket).Get([]byte(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		letter = &DeadLetter{}
+		return json.Unmarshal(data, letter)
+	})
+	return letter, err
+}
+
+// DeleteDeadLetter 刪除單筆死信
+func (q *Queue) DeleteDeadLetter(id string) error {
+	return q.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(deadLetterBucket)
+		if bucket.Get([]byte(id)) == nil {
+			return ErrNotFound
+		}
+		return bucket.Delete([]byte(id))
+	})
+}
+
+// ReplayDeadLetter 將死信重新入列，dest 為 nil 時送回原目的地；成功入列後刪除死信
+func (q *Queue) ReplayDeadLetter(id string, dest *types.Destination) (Receipt, error) {
+	letter, err := q.GetDeadLetter(id)
+	if err != nil {
+		return Receipt{}, err
+	}
+
+	job := NewJob(letter.Request(dest))
+	data, err := json.Marshal(job)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("failed to encode delivery: %v", err)
+	}
+
+	if err := q.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(deadLetterBucket).Delete([]byte(id)); err != nil {
+			return err
+		}
+		return putPending(tx, job, data)
+	}); err != nil {
+		return Receipt{}, err
+	}
+
+	q.wake()
+
+	logger.Info("Dead letter replayed", "queue",
+		logger.String("dead_letter_id", id),
+		logger.String("delivery_id", job.ID),
+		logger.String("provider", job.Provider),
+		logger.String("level", job.Level),
+		logger.String("channel", job.Channel))
+
+	return job.Receipt(), nil
+}
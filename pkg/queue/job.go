@@ -0,0 +1,92 @@
+// Package queue 提供以 bbolt 為儲存的持久化通知投遞佇列，支援 worker pool、指數退避重試與重啟後恢復
+package queue
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"alert-webhooks/pkg/notification/types"
+)
+
+// Job 佇列中的單一投遞任務（一個目的地一筆）
+type Job struct {
+	ID               string                  `json:"id"`
+	Provider         string                  `json:"provider"`
+	Level            string                  `json:"level,omitempty"`
+	Channel          string                  `json:"channel,omitempty"`
+	ChatID           string                  `json:"chat_id,omitempty"`
+	Message          string                  `json:"message,omitempty"`
+	TemplateLanguage string                  `json:"template_language,omitempty"`
+	TemplateName     string                  `json:"template_name,omitempty"`
+	AlertData        *types.AlertManagerData `json:"alert_data,omitempty"`
+	Options          map[string]interface{}  `json:"options,omitempty"`
+	RenderedMessage  string                  `json:"rendered_message,omitempty"` // 最後一次嘗試時實際送出的訊息，由 DeliverFunc 回填
+	Attempts         int                     `json:"attempts"`
+	Deferrals        int                     `json:"deferrals,omitempty"` // 因提供者速率限制延後的次數，不計入 Attempts
+	LastError        string                  `json:"last_error,omitempty"`
+	CreatedAt        time.Time               `json:"created_at"`
+	NextAttemptAt    time.Time               `json:"next_attempt_at"`
+}
+
+// Receipt 入列後回傳給呼叫端的投遞憑證
+type Receipt struct {
+	ID       string `json:"id"`
+	Provider string `json:"provider"`
+	Level    string `json:"level,omitempty"`
+	Channel  string `json:"channel,omitempty"`
+}
+
+// NewJob 從通知請求建立投遞任務
+func NewJob(req *types.NotificationRequest) *Job {
+	now := time.Now()
+	return &Job{
+		ID:               newID(now),
+		Provider:         req.ProviderName,
+		Level:            req.Level,
+		Channel:          req.Channel,
+		ChatID:           req.ChatID,
+		Message:          req.Message,
+		TemplateLanguage: req.TemplateLanguage,
+		TemplateName:     req.TemplateName,
+		AlertData:        req.AlertData,
+		Options:          req.Options,
+		CreatedAt:        now,
+		NextAttemptAt:    now,
+	}
+}
+
+// Request 將投遞任務還原為通知請求
+func (j *Job) Request() *types.NotificationRequest {
+	return &types.NotificationRequest{
+		ProviderName:     j.Provider,
+		Level:            j.Level,
+		Channel:          j.Channel,
+		ChatID:           j.ChatID,
+		Message:          j.Message,
+		AlertData:        j.AlertData,
+		TemplateLanguage: j.TemplateLanguage,
+		TemplateName:     j.TemplateName,
+		Options:          j.Options,
+	}
+}
+
+// Receipt 返回任務的投遞憑證
+func (j *Job) Receipt() Receipt {
+	return Receipt{
+		ID:       j.ID,
+		Provider: j.Provider,
+		Level:    j.Level,
+		Channel:  j.Channel,
+	}
+}
+
+// newID 產生依時間排序的任務 ID，確保 bbolt 中的鍵值順序即為入列順序
+func newID(now time.Time) string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%016x", now.UnixNano())
+	}
+	return fmt.Sprintf("%016x-%s", now.UnixNano(), hex.EncodeToString(b))
+}
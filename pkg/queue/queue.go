@@ -0,0 +1,574 @@
+package queue
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/notification/types"
+	"alert-webhooks/pkg/service"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	dbFileName   = "queue.db"
+	pollInterval = time.Second
+
+	defaultDataDir        = "./data"
+	defaultWorkers        = 4
+	defaultMaxAttempts    = 8
+	defaultMaxDeferrals   = 20
+	defaultInitialBackoff = 2 * time.Second
+	defaultMaxBackoff     = 5 * time.Minute
+	defaultSendTimeout    = 30 * time.Second
+)
+
+var (
+	pendingBucket = []byte("pending")
+	// dueBucket 待處理任務的到期索引：key 為 8 bytes big-endian 的 NextAttemptAt（UnixNano）加上任務 ID，
+	// 依 key 排序即依到期時間排序，派送迴圈掃描到第一個未到期的項目即停止
+	dueBucket = []byte("pending_due")
+)
+
+// ErrNotFound 任務不存在
+var ErrNotFound = errors.New("delivery not found")
+
+// DeliverFunc 實際執行投遞的函式，回傳錯誤時任務會被重試
+type DeliverFunc func(ctx context.Context, job *Job) error
+
+// Queue 持久化投遞佇列
+type Queue struct {
+	db      *bolt.DB
+	conf    config.QueueConf
+	deliver DeliverFunc
+
+	jobs     chan *Job
+	notify   chan struct{}
+	inflight map[string]struct{}
+	mu       sync.Mutex
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var (
+	instance   *Queue
+	instanceMu sync.RWMutex
+)
+
+// Open 開啟（或建立）資料目錄下的佇列資料庫
+func Open(conf config.QueueConf, deliver DeliverFunc) (*Queue, error) {
+	conf = withDefaults(conf)
+
+	if err := os.MkdirAll(conf.DataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create queue data dir: %v", err)
+	}
+
+	db, err := bolt.Open(filepath.Join(conf.DataDir, dbFileName), 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open queue database: %v", err)
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{pendingBucket, deadLetterBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		if tx.Bucket(dueBucket) == nil {
+			// 舊版資料庫沒有到期索引，從待處理任務重建
+			return rebuildDueIndex(tx)
+		}
+		return nil
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize queue database: %v", err)
+	}
+
+	return &Queue{
+		db:       db,
+		conf:     conf,
+		deliver:  deliver,
+		jobs:     make(chan *Job, conf.Workers),
+		notify:   make(chan struct{}, 1),
+		inflight: make(map[string]struct{}),
+	}, nil
+}
+
+// withDefaults 補上未設定的配置預設值
+func withDefaults(conf config.QueueConf) config.QueueConf {
+	if conf.DataDir == "" {
+		conf.DataDir = defaultDataDir
+	}
+	if conf.Workers <= 0 {
+		conf.Workers = defaultWorkers
+	}
+	if conf.MaxAttempts <= 0 {
+		conf.MaxAttempts = defaultMaxAttempts
+	}
+	if conf.MaxDeferrals <= 0 {
+		conf.MaxDeferrals = defaultMaxDeferrals
+	}
+	if conf.InitialBackoff <= 0 {
+		conf.InitialBackoff = defaultInitialBackoff
+	}
+	if conf.MaxBackoff <= 0 {
+		conf.MaxBackoff = defaultMaxBackoff
+	}
+	if conf.SendTimeout <= 0 {
+		conf.SendTimeout = defaultSendTimeout
+	}
+	return conf
+}
+
+// SetQueue 設定全局佇列實例
+func SetQueue(q *Queue) {
+	instanceMu.Lock()
+	defer instanceMu.Unlock()
+	instance = q
+}
+
+// GetQueue 獲取全局佇列實例，未啟用時返回 nil
+func GetQueue() *Queue {
+	instanceMu.RLock()
+	defer instanceMu.RUnlock()
+	return instance
+}
+
+// Start 啟動派送迴圈與 worker pool，重啟前未完成的任務會自動恢復投遞
+func (q *Queue) Start(ctx context.Context) {
+	ctx, q.cancel = context.WithCancel(ctx)
+
+	for i := 0; i < q.conf.Workers; i++ {
+		q.wg.Add(1)
+		go q.worker(ctx)
+	}
+
+	q.wg.Add(1)
+	go q.dispatchLoop(ctx)
+
+	pending, _ := q.Len()
+	logger.Info("Delivery queue started", "queue",
+		logger.String("data_dir", q.conf.DataDir),
+		logger.Int("workers", q.conf.Workers),
+		logger.Int("pending", pending))
+}
+
+// Stop 停止 worker 並關閉資料庫，進行中的投遞會等待完成，未投遞的任務保留在磁碟上
+func (q *Queue) Stop() {
+	if q.cancel != nil {
+		q.cancel()
+	}
+	q.wg.Wait()
+
+	if err := q.db.Close(); err != nil {
+		logger.Warn("Failed to close queue database", "queue", logger.Err(err))
+	}
+	logger.Info("Delivery queue stopped", "queue")
+}
+
+// Enqueue 將任務寫入磁碟並喚醒派送迴圈
+func (q *Queue) Enqueue(job *Job) error {
+	if err := q.put(job); err != nil {
+		return err
+	}
+
+	q.wake()
+
+	logger.Info("Delivery enqueued", "queue",
+		logger.String("id", job.ID),
+		logger.String("provider", job.Provider),
+		logger.String("level", job.Level),
+		logger.String("channel", job.Channel))
+	return nil
+}
+
+// wake 喚醒派送迴圈立即掃描
+func (q *Queue) wake() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Submit 為單一通知請求建立任務並入列
+func (q *Queue) Submit(req *types.NotificationRequest) (Receipt, error) {
+	job := NewJob(req)
+	if err := q.Enqueue(job); err != nil {
+		return Receipt{}, err
+	}
+	return job.Receipt(), nil
+}
+
+// SubmitAll 為每個目的地建立獨立任務並入列，各目的地獨立重試
+func (q *Queue) SubmitAll(req *types.NotificationRequest, destinations []types.Destination) ([]Receipt, error) {
+	receipts := make([]Receipt, 0, len(destinations))
+	for _, dest := range destinations {
+		destReq := *req
+		destReq.ProviderName = dest.Provider
+		destReq.Level = dest.Level
+		destReq.Channel = dest.Channel
+		if destReq.TemplateName == "" {
+			destReq.TemplateName = dest.Template
+		}
+
+		receipt, err := q.Submit(&destReq)
+		if err != nil {
+			return receipts, err
+		}
+		receipts = append(receipts, receipt)
+	}
+	return receipts, nil
+}
+
+// Get 查詢尚未完成的任務
+func (q *Queue) Get(id string) (*Job, error) {
+	var job *Job
+	err := q.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(pendingBucket).Get([]byte(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		job = &Job{}
+		return json.Unmarshal(data, job)
+	})
+	return job, err
+}
+
+// Len 返回尚未完成的任務數量
+func (q *Queue) Len() (int, error) {
+	count := 0
+	err := q.db.View(func(tx *bolt.Tx) error {
+		count = tx.Bucket(pendingBucket).Stats().KeyN
+		return nil
+	})
+	return count, err
+}
+
+// put 寫入或更新任務
+func (q *Queue) put(job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode delivery: %v", err)
+	}
+	return q.db.Update(func(tx *bolt.Tx) error {
+		return putPending(tx, job, data)
+	})
+}
+
+// remove 刪除任務
+func (q *Queue) remove(id string) error {
+	return q.db.Update(func(tx *bolt.Tx) error {
+		return deletePending(tx, id)
+	})
+}
+
+// dueKey 到期索引的 key：NextAttemptAt 在前以便依時間排序，任務 ID 在後讓同一時間的任務不衝突
+func dueKey(at time.Time, id string) []byte {
+	nanos := at.UnixNano()
+	if at.IsZero() || nanos < 0 {
+		nanos = 0
+	}
+	key := make([]byte, 8, 8+len(id))
+	binary.BigEndian.PutUint64(key, uint64(nanos))
+	return append(key, id...)
+}
+
+// putPending 在交易中寫入待處理任務，並以新的 NextAttemptAt 取代原本的到期索引
+func putPending(tx *bolt.Tx, job *Job, data []byte) error {
+	if err := unindexPending(tx, job.ID); err != nil {
+		return err
+	}
+	if err := tx.Bucket(pendingBucket).Put([]byte(job.ID), data); err != nil {
+		return err
+	}
+	return tx.Bucket(dueBucket).Put(dueKey(job.NextAttemptAt, job.ID), nil)
+}
+
+// deletePending 在交易中刪除待處理任務與其到期索引
+func deletePending(tx *bolt.Tx, id string) error {
+	if err := unindexPending(tx, id); err != nil {
+		return err
+	}
+	return tx.Bucket(pendingBucket).Delete([]byte(id))
+}
+
+// unindexPending 刪除任務目前的到期索引；無法解碼的任務留下的索引由 dueJobs 清除
+func unindexPending(tx *bolt.Tx, id string) error {
+	data := tx.Bucket(pendingBucket).Get([]byte(id))
+	if data == nil {
+		return nil
+	}
+	existing := &Job{}
+	if err := json.Unmarshal(data, existing); err != nil {
+		return nil
+	}
+	return tx.Bucket(dueBucket).Delete(dueKey(existing.NextAttemptAt, id))
+}
+
+// rebuildDueIndex 建立到期索引並為所有待處理任務加入索引
+func rebuildDueIndex(tx *bolt.Tx) error {
+	due, err := tx.CreateBucketIfNotExists(dueBucket)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(pendingBucket).ForEach(func(k, v []byte) error {
+		job := &Job{}
+		if err := json.Unmarshal(v, job); err != nil {
+			logger.Warn("Skipping undecodable delivery", "queue",
+				logger.String("id", string(k)),
+				logger.Err(err))
+			return nil
+		}
+		return due.Put(dueKey(job.NextAttemptAt, string(k)), nil)
+	})
+}
+
+// dispatchLoop 定期掃描到期的任務並交給 worker
+func (q *Queue) dispatchLoop(ctx context.Context) {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, job := range q.dueJobs() {
+			select {
+			case q.jobs <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-q.notify:
+		}
+	}
+}
+
+// dueJobs 取出已到重試時間且未在處理中的任務，並標記為處理中；
+// 依到期索引掃描，遇到第一個未到期的項目即停止，只解碼到期的任務
+func (q *Queue) dueJobs() []*Job {
+	now := time.Now()
+	var due []*Job
+	var stale [][]byte
+
+	q.mu.Lock()
+	inflight := make(map[string]struct{}, len(q.inflight))
+	for id := range q.inflight {
+		inflight[id] = struct{}{}
+	}
+	q.mu.Unlock()
+
+	err := q.db.View(func(tx *bolt.Tx) error {
+		pending := tx.Bucket(pendingBucket)
+		cursor := tx.Bucket(dueBucket).Cursor()
+		for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
+			if len(k) <= 8 {
+				stale = append(stale, append([]byte(nil), k...))
+				continue
+			}
+			if int64(binary.BigEndian.Uint64(k[:8])) > now.UnixNano() {
+				break
+			}
+			id := string(k[8:])
+			if _, busy := inflight[id]; busy {
+				continue
+			}
+			data := pending.Get([]byte(id))
+			if data == nil {
+				stale = append(stale, append([]byte(nil), k...))
+				continue
+			}
+			job := &Job{}
+			if err := json.Unmarshal(data, job); err != nil {
+				logger.Warn("Skipping undecodable delivery", "queue",
+					logger.String("id", id),
+					logger.Err(err))
+				continue
+			}
+			due = append(due, job)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to scan delivery queue", "queue", logger.Err(err))
+		return nil
+	}
+
+	if len(stale) > 0 {
+		if err := q.db.Update(func(tx *bolt.Tx) error {
+			for _, k := range stale {
+				if err := tx.Bucket(dueBucket).Delete(k); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			logger.Warn("Failed to remove stale due index entries", "queue", logger.Err(err))
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	result := due[:0]
+	for _, job := range due {
+		if _, busy := q.inflight[job.ID]; busy {
+			continue
+		}
+		q.inflight[job.ID] = struct{}{}
+		result = append(result, job)
+	}
+	return result
+}
+
+// worker 執行投遞並依結果刪除或重新排程任務
+func (q *Queue) worker(ctx context.Context) {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-q.jobs:
+			q.process(job)
+		}
+	}
+}
+
+// process 投遞單一任務
+func (q *Queue) process(job *Job) {
+	defer func() {
+		q.mu.Lock()
+		delete(q.inflight, job.ID)
+		q.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), q.conf.SendTimeout)
+	err := q.deliver(ctx, job)
+	cancel()
+
+	// 提供者速率限制：依 retry_after 延後，不計入嘗試次數，但延後次數超過 max_deferrals 時移到死信區
+	if rle, limited := service.AsRateLimitError(err); limited {
+		q.deferRateLimited(job, rle)
+		return
+	}
+
+	job.Attempts++
+
+	if err == nil {
+		if rerr := q.remove(job.ID); rerr != nil {
+			logger.Error("Failed to remove delivered job", "queue",
+				logger.String("id", job.ID),
+				logger.Err(rerr))
+		}
+		logger.Info("Delivery succeeded", "queue",
+			logger.String("id", job.ID),
+			logger.String("provider", job.Provider),
+			logger.Int("attempts", job.Attempts))
+		return
+	}
+
+	job.LastError = err.Error()
+
+	if job.Attempts >= q.conf.MaxAttempts {
+		if derr := q.moveToDeadLetter(job); derr != nil {
+			logger.Error("Failed to move exhausted job to dead letters", "queue",
+				logger.String("id", job.ID),
+				logger.Err(derr))
+		}
+		logger.Error("Delivery failed permanently, moved to dead letters", "queue",
+			logger.String("id", job.ID),
+			logger.String("provider", job.Provider),
+			logger.String("level", job.Level),
+			logger.String("channel", job.Channel),
+			logger.Int("attempts", job.Attempts),
+			logger.Err(err))
+		return
+	}
+
+	delay := q.backoff(job.Attempts)
+	job.NextAttemptAt = time.Now().Add(delay)
+	if perr := q.put(job); perr != nil {
+		logger.Error("Failed to reschedule delivery", "queue",
+			logger.String("id", job.ID),
+			logger.Err(perr))
+		return
+	}
+
+	logger.Warn("Delivery failed, retry scheduled", "queue",
+		logger.String("id", job.ID),
+		logger.String("provider", job.Provider),
+		logger.Int("attempts", job.Attempts),
+		logger.String("retry_in", delay.String()),
+		logger.Err(err))
+}
+
+// deferRateLimited 依提供者回報的等待時間重新排程任務；持續被限制的任務在延後 max_deferrals 次後移到死信區
+func (q *Queue) deferRateLimited(job *Job, rle *service.RateLimitError) {
+	job.Deferrals++
+	job.LastError = rle.Error()
+
+	if job.Deferrals > q.conf.MaxDeferrals {
+		if derr := q.moveToDeadLetter(job); derr != nil {
+			logger.Error("Failed to move rate limited job to dead letters", "queue",
+				logger.String("id", job.ID),
+				logger.Err(derr))
+		}
+		logger.Error("Delivery rate limited too many times, moved to dead letters", "queue",
+			logger.String("id", job.ID),
+			logger.String("provider", job.Provider),
+			logger.String("level", job.Level),
+			logger.String("channel", job.Channel),
+			logger.Int("deferrals", job.Deferrals))
+		return
+	}
+
+	delay := rle.RetryAfter
+	if delay <= 0 {
+		delay = q.conf.InitialBackoff
+	}
+	// 加入少量抖動，避免同一頻道的任務在同一時間點再次湧入
+	delay += time.Duration(rand.Int63n(int64(delay)/10 + 1))
+
+	job.NextAttemptAt = time.Now().Add(delay)
+	if err := q.put(job); err != nil {
+		logger.Error("Failed to reschedule rate limited delivery", "queue",
+			logger.String("id", job.ID),
+			logger.Err(err))
+		return
+	}
+
+	logger.Warn("Delivery rate limited, deferred", "queue",
+		logger.String("id", job.ID),
+		logger.String("provider", job.Provider),
+		logger.Int("deferrals", job.Deferrals),
+		logger.String("retry_in", delay.String()))
+}
+
+// backoff 計算指數退避時間並加入抖動（介於計算值的 50% 至 100%）
+func (q *Queue) backoff(attempts int) time.Duration {
+	delay := q.conf.InitialBackoff
+	for i := 1; i < attempts && delay < q.conf.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > q.conf.MaxBackoff {
+		delay = q.conf.MaxBackoff
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
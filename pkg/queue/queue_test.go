@@ -0,0 +1,186 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/notification/types"
+	"alert-webhooks/pkg/service"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// openTestQueue 在暫存目錄開啟佇列
+func openTestQueue(t *testing.T, conf config.QueueConf, deliver DeliverFunc) *Queue {
+	t.Helper()
+	conf.DataDir = t.TempDir()
+	q, err := Open(conf, deliver)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	t.Cleanup(func() { q.db.Close() })
+	return q
+}
+
+func TestRateLimitedJobMovesToDeadLetterAfterMaxDeferrals(t *testing.T) {
+	deliver := func(ctx context.Context, job *Job) error {
+		return &service.RateLimitError{Provider: "slack", RetryAfter: time.Second, Err: errors.New("429")}
+	}
+	q := openTestQueue(t, config.QueueConf{MaxAttempts: 2, MaxDeferrals: 3}, deliver)
+
+	job := NewJob(&types.NotificationRequest{ProviderName: "slack", Level: "L1", Message: "hi"})
+	if err := q.Enqueue(job); err != nil {
+		t.Fatalf("Enqueue returned error: %v", err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		q.process(job)
+		stored, err := q.Get(job.ID)
+		if err != nil {
+			t.Fatalf("deferral %d: job is no longer pending: %v", i, err)
+		}
+		if stored.Deferrals != i || stored.Attempts != 0 {
+			t.Fatalf("deferral %d: deferrals=%d attempts=%d, want %d and 0", i, stored.Deferrals, stored.Attempts, i)
+		}
+	}
+
+	q.process(job)
+	if _, err := q.Get(job.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("job still pending after exceeding max_deferrals: %v", err)
+	}
+	letter, err := q.GetDeadLetter(job.ID)
+	if err != nil {
+		t.Fatalf("GetDeadLetter returned error: %v", err)
+	}
+	if letter.Deferrals != 4 || letter.LastError == "" {
+		t.Errorf("dead letter deferrals=%d last_error=%q, want 4 and the rate limit error", letter.Deferrals, letter.LastError)
+	}
+}
+
+func TestFailedJobMovesToDeadLetterAfterMaxAttempts(t *testing.T) {
+	deliver := func(ctx context.Context, job *Job) error {
+		return errors.New("provider unavailable")
+	}
+	q := openTestQueue(t, config.QueueConf{MaxAttempts: 2}, deliver)
+
+	job := NewJob(&types.NotificationRequest{ProviderName: "telegram", Level: "L0", Message: "hi"})
+	if err := q.Enqueue(job); err != nil {
+		t.Fatalf("Enqueue returned error: %v", err)
+	}
+
+	q.process(job)
+	if stored, err := q.Get(job.ID); err != nil || stored.Attempts != 1 {
+		t.Fatalf("after first failure: job=%v err=%v, want 1 attempt", stored, err)
+	}
+	q.process(job)
+	if _, err := q.GetDeadLetter(job.ID); err != nil {
+		t.Fatalf("job not moved to dead letters after max_attempts: %v", err)
+	}
+}
+
+// dueIndexLen 返回到期索引的項目數
+func dueIndexLen(t *testing.T, q *Queue) int {
+	t.Helper()
+	count := 0
+	if err := q.db.View(func(tx *bolt.Tx) error {
+		count = tx.Bucket(dueBucket).Stats().KeyN
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestDueJobsStopsAtFirstFutureEntry(t *testing.T) {
+	q := openTestQueue(t, config.QueueConf{}, nil)
+
+	now := time.Now()
+	var ids []string
+	for _, offset := range []time.Duration{-time.Minute, time.Hour, -time.Second, 2 * time.Hour} {
+		job := NewJob(&types.NotificationRequest{ProviderName: "slack", Level: "L1", Message: "hi"})
+		job.NextAttemptAt = now.Add(offset)
+		if err := q.Enqueue(job); err != nil {
+			t.Fatalf("Enqueue returned error: %v", err)
+		}
+		ids = append(ids, job.ID)
+	}
+
+	due := q.dueJobs()
+	if len(due) != 2 || due[0].ID != ids[0] || due[1].ID != ids[2] {
+		t.Fatalf("dueJobs returned %d jobs, want the two past-due jobs in due order", len(due))
+	}
+	// 處理中的任務不會再次派送
+	if again := q.dueJobs(); len(again) != 0 {
+		t.Errorf("dueJobs returned %d in-flight jobs again", len(again))
+	}
+}
+
+func TestDueIndexFollowsReschedule(t *testing.T) {
+	deliver := func(ctx context.Context, job *Job) error {
+		return errors.New("provider unavailable")
+	}
+	q := openTestQueue(t, config.QueueConf{MaxAttempts: 3, InitialBackoff: time.Hour}, deliver)
+
+	job := NewJob(&types.NotificationRequest{ProviderName: "telegram", Level: "L0", Message: "hi"})
+	if err := q.Enqueue(job); err != nil {
+		t.Fatalf("Enqueue returned error: %v", err)
+	}
+	due := q.dueJobs()
+	if len(due) != 1 {
+		t.Fatalf("dueJobs returned %d jobs, want 1", len(due))
+	}
+
+	// 失敗後重新排程到一小時後，舊的索引項目被取代
+	q.process(due[0])
+	if n := dueIndexLen(t, q); n != 1 {
+		t.Errorf("due index has %d entries after reschedule, want 1", n)
+	}
+	if again := q.dueJobs(); len(again) != 0 {
+		t.Errorf("dueJobs returned %d jobs before the retry time", len(again))
+	}
+
+	if err := q.moveToDeadLetter(job); err != nil {
+		t.Fatalf("moveToDeadLetter returned error: %v", err)
+	}
+	if n := dueIndexLen(t, q); n != 0 {
+		t.Errorf("due index has %d entries after moving to dead letters, want 0", n)
+	}
+
+	if _, err := q.ReplayDeadLetter(job.ID, nil); err != nil {
+		t.Fatalf("ReplayDeadLetter returned error: %v", err)
+	}
+	if replayed := q.dueJobs(); len(replayed) != 1 {
+		t.Errorf("dueJobs returned %d jobs after replay, want 1", len(replayed))
+	}
+}
+
+func TestOpenRebuildsDueIndex(t *testing.T) {
+	conf := config.QueueConf{DataDir: t.TempDir()}
+	q, err := Open(conf, nil)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	job := NewJob(&types.NotificationRequest{ProviderName: "discord", Level: "L2", Message: "hi"})
+	if err := q.Enqueue(job); err != nil {
+		t.Fatalf("Enqueue returned error: %v", err)
+	}
+	// 模擬沒有到期索引的舊版資料庫
+	if err := q.db.Update(func(tx *bolt.Tx) error {
+		return tx.DeleteBucket(dueBucket)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	q.db.Close()
+
+	q, err = Open(conf, nil)
+	if err != nil {
+		t.Fatalf("reopen returned error: %v", err)
+	}
+	defer q.db.Close()
+	if due := q.dueJobs(); len(due) != 1 || due[0].ID != job.ID {
+		t.Errorf("dueJobs after reopen returned %d jobs, want the pending job", len(due))
+	}
+}
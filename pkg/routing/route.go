@@ -0,0 +1,146 @@
+// Package routing 提供類似 Alertmanager route 的路由樹，依警報標籤決定通知目的地
+package routing
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/matcher"
+	"alert-webhooks/pkg/notification/providers"
+	"alert-webhooks/pkg/notification/types"
+)
+
+// Route 路由樹節點
+type Route struct {
+	Matchers  matcher.Matchers
+	Receivers []types.Destination
+	Continue  bool
+	Routes    []*Route
+}
+
+// NewRoute 從配置建立路由樹，未設定接收者的節點沿用父節點的接收者
+func NewRoute(conf config.RouteConf, parent *Route) (*Route, error) {
+	matchers, err := matcher.ParseAll(conf.Matchers)
+	if err != nil {
+		return nil, err
+	}
+
+	route := &Route{
+		Matchers: matchers,
+		Continue: conf.Continue,
+	}
+
+	if len(conf.Receivers) > 0 {
+		for i, receiver := range conf.Receivers {
+			dest, err := newDestination(receiver)
+			if err != nil {
+				return nil, fmt.Errorf("receiver %d: %v", i, err)
+			}
+			route.Receivers = append(route.Receivers, dest)
+		}
+	} else if parent != nil {
+		route.Receivers = parent.Receivers
+	}
+
+	for i, childConf := range conf.Routes {
+		child, err := NewRoute(childConf, route)
+		if err != nil {
+			return nil, fmt.Errorf("route %d: %v", i, err)
+		}
+		route.Routes = append(route.Routes, child)
+	}
+
+	return route, nil
+}
+
+// levelPattern 接收者 level 的格式，與提供者配置的 L0-L6 相同
+var levelPattern = regexp.MustCompile(`^L[0-6]$`)
+
+// newDestination 驗證並正規化接收者配置：提供者必須存在，level 必須為 L0-L6，
+// channel 只能用於支援頻道的提供者（Telegram 只能以 level 指定 chat）
+func newDestination(receiver config.ReceiverConf) (types.Destination, error) {
+	provider := strings.ToLower(strings.TrimSpace(receiver.Provider))
+	if provider == "" {
+		return types.Destination{}, fmt.Errorf("provider is required")
+	}
+	capabilities, exists := providers.Capabilities()[provider]
+	if !exists {
+		return types.Destination{}, fmt.Errorf("unknown provider %q (supported: discord, slack, telegram)", provider)
+	}
+
+	level := strings.ToUpper(strings.TrimSpace(receiver.Level))
+	if len(level) > 0 && level[0] >= '0' && level[0] <= '9' {
+		level = "L" + level
+	}
+	channel := strings.TrimSpace(receiver.Channel)
+
+	if level == "" && channel == "" {
+		return types.Destination{}, fmt.Errorf("receiver for provider %s requires level or channel", provider)
+	}
+	if level != "" && (!capabilities.SupportsLevels || !levelPattern.MatchString(level)) {
+		return types.Destination{}, fmt.Errorf("invalid level %q for provider %s, expected L0-L6", receiver.Level, provider)
+	}
+	if channel != "" && !capabilities.SupportsChannels {
+		return types.Destination{}, fmt.Errorf("provider %s does not support channel receivers, use level instead", provider)
+	}
+
+	return types.Destination{
+		Provider: provider,
+		Level:    level,
+		Channel:  channel,
+		Template: strings.TrimSpace(receiver.Template),
+	}, nil
+}
+
+// Match 回傳匹配標籤的最深層節點接收者
+// 子節點依序比對，第一個匹配的子節點若未設定 continue 則停止比對後續兄弟節點；
+// 沒有任何子節點匹配時使用當前節點的接收者
+func (r *Route) Match(labels map[string]string) []types.Destination {
+	if !r.Matchers.Matches(labels) {
+		return nil
+	}
+
+	var destinations []types.Destination
+	matched := false
+	for _, child := range r.Routes {
+		if !child.Matchers.Matches(labels) {
+			continue
+		}
+		matched = true
+		destinations = append(destinations, child.Match(labels)...)
+		if !child.Continue {
+			break
+		}
+	}
+
+	if !matched {
+		destinations = append(destinations, r.Receivers...)
+	}
+
+	return dedupDestinations(destinations)
+}
+
+// dedupDestinations 移除重複的目的地並保留原本順序
+func dedupDestinations(destinations []types.Destination) []types.Destination {
+	seen := make(map[types.Destination]struct{}, len(destinations))
+	result := make([]types.Destination, 0, len(destinations))
+	for _, dest := range destinations {
+		if _, exists := seen[dest]; exists {
+			continue
+		}
+		seen[dest] = struct{}{}
+		result = append(result, dest)
+	}
+	return result
+}
+
+// GroupLabels 合併警報群組的 CommonLabels 與 GroupLabels，作為路由比對的標籤集合
+func GroupLabels(data *types.AlertManagerData) map[string]string {
+	labels := matcher.LabelsFromMap(data.CommonLabels)
+	for k, v := range matcher.LabelsFromMap(data.GroupLabels) {
+		labels[k] = v
+	}
+	return labels
+}
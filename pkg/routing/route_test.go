@@ -0,0 +1,170 @@
+package routing
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/notification/types"
+)
+
+func testTree(t *testing.T) *Route {
+	t.Helper()
+	conf := config.RouteConf{
+		Receivers: []config.ReceiverConf{{Provider: "slack", Channel: "#default"}},
+		Routes: []config.RouteConf{
+			{
+				Matchers:  []string{`team="db"`},
+				Receivers: []config.ReceiverConf{{Provider: "telegram", Level: "L1"}},
+				Routes: []config.RouteConf{
+					{Matchers: []string{`severity="critical"`}, Receivers: []config.ReceiverConf{{Provider: "telegram", Level: "0"}}},
+					// 沒有接收者的子節點沿用父節點的接收者
+					{Matchers: []string{`severity="info"`}},
+				},
+			},
+			{
+				Matchers:  []string{`env="prod"`},
+				Receivers: []config.ReceiverConf{{Provider: "discord", Level: "L2"}},
+				Continue:  true,
+			},
+			{
+				Matchers:  []string{`env=~"prod|staging"`},
+				Receivers: []config.ReceiverConf{{Provider: "slack", Channel: "#ops", Template: "short"}},
+			},
+			{
+				Matchers:  []string{`env="prod"`},
+				Receivers: []config.ReceiverConf{{Provider: "slack", Channel: "#never"}},
+			},
+		},
+	}
+	route, err := NewRoute(conf, nil)
+	if err != nil {
+		t.Fatalf("NewRoute returned error: %v", err)
+	}
+	return route
+}
+
+func TestRouteMatch(t *testing.T) {
+	route := testTree(t)
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   []types.Destination
+	}{
+		{
+			"no child matches uses root receivers",
+			map[string]string{"env": "dev"},
+			[]types.Destination{{Provider: "slack", Channel: "#default"}},
+		},
+		{
+			"deepest match wins over parent",
+			map[string]string{"team": "db", "severity": "critical"},
+			[]types.Destination{{Provider: "telegram", Level: "L0"}},
+		},
+		{
+			"matching parent without matching child",
+			map[string]string{"team": "db", "severity": "warning"},
+			[]types.Destination{{Provider: "telegram", Level: "L1"}},
+		},
+		{
+			"child without receivers inherits parent receivers",
+			map[string]string{"team": "db", "severity": "info"},
+			[]types.Destination{{Provider: "telegram", Level: "L1"}},
+		},
+		{
+			"continue evaluates later siblings until one stops",
+			map[string]string{"env": "prod"},
+			[]types.Destination{{Provider: "discord", Level: "L2"}, {Provider: "slack", Channel: "#ops", Template: "short"}},
+		},
+		{
+			"first match without continue stops siblings",
+			map[string]string{"team": "db", "env": "prod"},
+			[]types.Destination{{Provider: "telegram", Level: "L1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := route.Match(tt.labels); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Match(%v) = %v, want %v", tt.name, tt.labels, got, tt.want)
+		}
+	}
+}
+
+func TestRouteMatchDeduplicates(t *testing.T) {
+	route, err := NewRoute(config.RouteConf{
+		Routes: []config.RouteConf{
+			{Matchers: []string{`env="prod"`}, Receivers: []config.ReceiverConf{{Provider: "slack", Channel: "#ops"}}, Continue: true},
+			{Matchers: []string{`env="prod"`}, Receivers: []config.ReceiverConf{{Provider: "Slack", Channel: " #ops "}}},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewRoute returned error: %v", err)
+	}
+	want := []types.Destination{{Provider: "slack", Channel: "#ops"}}
+	if got := route.Match(map[string]string{"env": "prod"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Match = %v, want %v", got, want)
+	}
+}
+
+func TestNewRouteRejectsInvalidReceivers(t *testing.T) {
+	tests := []struct {
+		receiver config.ReceiverConf
+		err      string
+	}{
+		{config.ReceiverConf{Level: "L1"}, "provider is required"},
+		{config.ReceiverConf{Provider: "email", Level: "L1"}, `unknown provider "email"`},
+		{config.ReceiverConf{Provider: "telegram"}, "requires level or channel"},
+		{config.ReceiverConf{Provider: "telegram", Channel: "#alerts"}, "does not support channel receivers"},
+		{config.ReceiverConf{Provider: "telegram", Level: "L9"}, `invalid level "L9"`},
+		{config.ReceiverConf{Provider: "slack", Level: "7"}, `invalid level "7"`},
+		{config.ReceiverConf{Provider: "discord", Level: "high"}, `invalid level "high"`},
+	}
+
+	for _, tt := range tests {
+		conf := config.RouteConf{Routes: []config.RouteConf{{Receivers: []config.ReceiverConf{tt.receiver}}}}
+		_, err := NewRoute(conf, nil)
+		if err == nil {
+			t.Errorf("NewRoute(%+v) returned no error, want %q", tt.receiver, tt.err)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("NewRoute(%+v) error = %q, want it to contain %q", tt.receiver, err, tt.err)
+		}
+	}
+
+	for _, receiver := range []config.ReceiverConf{
+		{Provider: "telegram", Level: "l6"},
+		{Provider: "slack", Channel: "#alerts"},
+		{Provider: "discord", Level: "0"},
+	} {
+		if _, err := newDestination(receiver); err != nil {
+			t.Errorf("newDestination(%+v) returned error: %v", receiver, err)
+		}
+	}
+}
+
+func TestLoadKeepsTreeOnInvalidConfig(t *testing.T) {
+	defer Load(config.RoutingConf{})
+
+	valid := config.RoutingConf{Enable: true, Route: config.RouteConf{
+		Receivers: []config.ReceiverConf{{Provider: "telegram", Level: "L0"}},
+	}}
+	if err := Load(valid); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	invalid := config.RoutingConf{Enable: true, Route: config.RouteConf{
+		Receivers: []config.ReceiverConf{{Provider: "telegram", Channel: "#alerts"}},
+	}}
+	if err := Load(invalid); err == nil {
+		t.Fatal("Load with a telegram channel receiver returned no error")
+	}
+
+	data := &types.AlertManagerData{}
+	want := []types.Destination{{Provider: "telegram", Level: "L0"}}
+	if got := Evaluate(data); !reflect.DeepEqual(got, want) {
+		t.Errorf("Evaluate after rejected Load = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,63 @@
+package routing
+
+import (
+	"fmt"
+	"sync"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/notification/types"
+)
+
+var (
+	mu      sync.RWMutex
+	enabled bool
+	root    *Route
+)
+
+// Load 依配置重建全局路由樹，配置無效時保留原本的路由樹
+func Load(conf config.RoutingConf) error {
+	if !conf.Enable {
+		mu.Lock()
+		enabled = false
+		root = nil
+		mu.Unlock()
+		logger.Info("Routing tree disabled", "routing")
+		return nil
+	}
+
+	tree, err := NewRoute(conf.Route, nil)
+	if err != nil {
+		return fmt.Errorf("invalid routing tree: %v", err)
+	}
+
+	mu.Lock()
+	enabled = true
+	root = tree
+	mu.Unlock()
+
+	logger.Info("Routing tree loaded", "routing",
+		logger.Int("routes", len(tree.Routes)),
+		logger.Int("default_receivers", len(tree.Receivers)))
+	return nil
+}
+
+// Enabled 檢查路由樹是否啟用
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return enabled && root != nil
+}
+
+// Evaluate 根據警報群組標籤計算通知目的地
+func Evaluate(data *types.AlertManagerData) []types.Destination {
+	mu.RLock()
+	tree := root
+	mu.RUnlock()
+
+	if tree == nil || data == nil {
+		return nil
+	}
+
+	return tree.Match(GroupLabels(data))
+}
@@ -6,7 +6,10 @@ import (
 	"strings"
 
 	"alert-webhooks/config"
+	"alert-webhooks/pkg/discordembed"
 	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/msgref"
+	"alert-webhooks/pkg/notification/types"
 
 	"github.com/bwmarrin/discordgo"
 	"go.opentelemetry.io/otel"
@@ -20,6 +23,7 @@ type DiscordService struct {
 	config   config.DiscordConf
 	guildID  string
 	channels map[string]string // level -> channel ID mapping
+	limiter  *RateLimiter      // per-channel rate limiting
 }
 
 // NewDiscordService creates a new Discord service instance
@@ -38,6 +42,10 @@ func NewDiscordService(cfg config.DiscordConf) (*DiscordService, error) {
 		return nil, fmt.Errorf("failed to create Discord session: %w", err)
 	}
 
+	// Surface rate limits as errors instead of sleeping inside discordgo,
+	// so the shared rate limiter can decide whether to wait or requeue
+	session.ShouldRetryOnRateLimit = false
+
 	// Test connection
 	session.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildMessages
 
@@ -63,6 +71,7 @@ func NewDiscordService(cfg config.DiscordConf) (*DiscordService, error) {
 		config:   cfg,
 		guildID:  cfg.GuildID,
 		channels: cfg.Channels,
+		limiter:  newProviderRateLimiter("discord"),
 	}
 
 	return ds, nil
@@ -70,6 +79,11 @@ func NewDiscordService(cfg config.DiscordConf) (*DiscordService, error) {
 
 // SendMessage send message to specified level chat
 func (ds *DiscordService) SendMessage(ctx context.Context, level string, message string) error {
+	return ds.SendLevelMessage(ctx, level, message, nil)
+}
+
+// SendLevelMessage sends a message to the level channel; see SendChannelMessage for the options
+func (ds *DiscordService) SendLevelMessage(ctx context.Context, level, message string, opts *types.DiscordMessageOptions) error {
 	ctx, span := otel.Tracer("discord").Start(ctx, "DiscordService.SendMessage")
 	defer span.End()
 
@@ -101,7 +115,7 @@ func (ds *DiscordService) SendMessage(ctx context.Context, level string, message
 
 	span.SetAttributes(attribute.String("messaging.channel", channelID))
 
-	if sendErr := ds.SendMessageToChannel(channelID, message); sendErr != nil {
+	if sendErr := ds.SendChannelMessage(ctx, channelID, message, opts); sendErr != nil {
 		span.RecordError(sendErr)
 		span.SetStatus(codes.Error, sendErr.Error())
 		return sendErr
@@ -111,7 +125,16 @@ func (ds *DiscordService) SendMessage(ctx context.Context, level string, message
 }
 
 // SendMessageToChannel sends a message to a specific Discord channel
-func (ds *DiscordService) SendMessageToChannel(channelID, message string) error {
+func (ds *DiscordService) SendMessageToChannel(ctx context.Context, channelID, message string) error {
+	return ds.SendChannelMessage(ctx, channelID, message, nil)
+}
+
+// SendChannelMessage sends a message to a specific Discord channel.
+// With embeds set, the embeds are sent instead of the text, paginated across messages to respect
+// Discord's embed limits. Components are attached to the last chunk or page. With AlertData set,
+// a firing notification sent as a single message is recorded in the message reference store,
+// and a resolved notification edits that message in place when edit_on_resolve is enabled
+func (ds *DiscordService) SendChannelMessage(ctx context.Context, channelID, message string, opts *types.DiscordMessageOptions) error {
 	if !ds.config.Enable {
 		return fmt.Errorf("Discord service is disabled")
 	}
@@ -120,16 +143,42 @@ func (ds *DiscordService) SendMessageToChannel(channelID, message string) error
 		return fmt.Errorf("Discord session not initialized")
 	}
 
+	var components []discordgo.MessageComponent
+	var embeds []*discordgo.MessageEmbed
+	var refKey string
+	resolved := false
+	if opts != nil {
+		components = opts.Components
+		embeds = opts.Embeds
+		if opts.AlertData != nil {
+			refKey = msgref.Key("discord", channelID, opts.AlertData)
+			resolved = msgref.IsResolved(opts.AlertData)
+		}
+	}
+
+	if len(embeds) > 0 {
+		return ds.sendEmbeds(ctx, channelID, embeds, components, refKey, resolved)
+	}
+
 	// Discord message length limit is 2000 characters
 	if len(message) > 2000 {
-		return ds.sendLongMessage(channelID, message)
+		return ds.sendLongMessage(ctx, channelID, message, components)
+	}
+
+	// Resolved notifications edit the original firing message; fall back to a new message on failure
+	if resolved && msgref.EditOnResolve() && ds.editReferencedMessage(ctx, refKey, message, nil) {
+		return nil
 	}
 
-	_, err := ds.session.ChannelMessageSend(channelID, message)
+	sent, err := ds.send(ctx, channelID, &discordgo.MessageSend{Content: message, Components: components})
 	if err != nil {
 		return ds.handleDiscordError(err, channelID)
 	}
 
+	if refKey != "" && !resolved {
+		msgref.Put(refKey, msgref.Ref{Provider: "discord", ChatID: sent.ChannelID, MessageID: sent.ID})
+	}
+
 	logger.Info("Discord message sent successfully",
 		"DiscordService",
 		logger.String("channel_id", channelID))
@@ -137,6 +186,51 @@ func (ds *DiscordService) SendMessageToChannel(channelID, message string) error
 	return nil
 }
 
+// sendEmbeds sends embeds paginated into messages of at most 10 embeds and 6000 characters,
+// labelling each page when there is more than one
+func (ds *DiscordService) sendEmbeds(ctx context.Context, channelID string, embeds []*discordgo.MessageEmbed, components []discordgo.MessageComponent, refKey string, resolved bool) error {
+	pages := discordembed.Paginate(embeds)
+
+	// Only a single-page notification can be edited in place
+	if resolved && len(pages) == 1 && msgref.EditOnResolve() && ds.editReferencedMessage(ctx, refKey, "", pages[0]) {
+		return nil
+	}
+
+	var first *discordgo.Message
+	for i, page := range pages {
+		msg := &discordgo.MessageSend{Embeds: page}
+		if len(pages) > 1 {
+			msg.Content = fmt.Sprintf("(Part %d/%d)", i+1, len(pages))
+		}
+		if i == len(pages)-1 {
+			msg.Components = components
+		}
+
+		sent, err := ds.send(ctx, channelID, msg)
+		if err != nil {
+			if i == 0 {
+				return ds.handleDiscordError(err, channelID)
+			}
+			return fmt.Errorf("failed to send embed page %d: %w", i+1, err)
+		}
+		if first == nil {
+			first = sent
+		}
+	}
+
+	if refKey != "" && !resolved && len(pages) == 1 {
+		msgref.Put(refKey, msgref.Ref{Provider: "discord", ChatID: first.ChannelID, MessageID: first.ID})
+	}
+
+	logger.Info("Discord embed message sent successfully",
+		"DiscordService",
+		logger.String("channel_id", channelID),
+		logger.Int("embeds", len(embeds)),
+		logger.Int("pages", len(pages)))
+
+	return nil
+}
+
 // SendMessageToLevel sends message to specified level channel
 func (ds *DiscordService) SendMessageToLevel(ctx context.Context, level string, message string) error {
 	return ds.SendMessage(ctx, level, message)
@@ -259,7 +353,7 @@ func (ds *DiscordService) getChannelForLevel(level string) (string, error) {
 }
 
 // sendLongMessage splits and sends long messages that exceed Discord's 2000 character limit
-func (ds *DiscordService) sendLongMessage(channelID, message string) error {
+func (ds *DiscordService) sendLongMessage(ctx context.Context, channelID, message string, components []discordgo.MessageComponent) error {
 	const maxLength = 2000
 
 	// Split message into chunks
@@ -287,7 +381,12 @@ func (ds *DiscordService) sendLongMessage(channelID, message string) error {
 			chunk = fmt.Sprintf("(Part 1/%d)\n%s", len(chunks), chunk)
 		}
 
-		_, err := ds.session.ChannelMessageSend(channelID, chunk)
+		var chunkComponents []discordgo.MessageComponent
+		if i == len(chunks)-1 {
+			chunkComponents = components
+		}
+
+		_, err := ds.send(ctx, channelID, &discordgo.MessageSend{Content: chunk, Components: chunkComponents})
 		if err != nil {
 			return fmt.Errorf("failed to send message chunk %d: %w", i+1, err)
 		}
@@ -301,8 +400,92 @@ func (ds *DiscordService) sendLongMessage(channelID, message string) error {
 	return nil
 }
 
+// send posts a single message through the per-channel rate limiter; ctx cancels a rate-limit wait
+func (ds *DiscordService) send(ctx context.Context, channelID string, msg *discordgo.MessageSend) (*discordgo.Message, error) {
+	var sent *discordgo.Message
+	err := ds.limiter.Do(ctx, channelID, func() error {
+		var err error
+		if len(msg.Components) > 0 || len(msg.Embeds) > 0 {
+			sent, err = ds.session.ChannelMessageSendComplex(channelID, msg)
+			return err
+		}
+		sent, err = ds.session.ChannelMessageSend(channelID, msg.Content)
+		return err
+	})
+	return sent, err
+}
+
+// editReferencedMessage edits the referenced message and clears its buttons; embeds replace the
+// original embeds when set. The reference is removed on success, false is returned when there is
+// no reference or the edit fails
+func (ds *DiscordService) editReferencedMessage(ctx context.Context, refKey, message string, embeds []*discordgo.MessageEmbed) bool {
+	ref, ok := msgref.Get(refKey)
+	if !ok {
+		return false
+	}
+
+	err := ds.limiter.Do(ctx, ref.ChatID, func() error {
+		edit := &discordgo.MessageEdit{
+			ID:         ref.MessageID,
+			Channel:    ref.ChatID,
+			Content:    &message,
+			Components: &[]discordgo.MessageComponent{},
+		}
+		if embeds != nil {
+			edit.Embeds = &embeds
+		}
+		_, err := ds.session.ChannelMessageEditComplex(edit)
+		return err
+	})
+	if err != nil {
+		logger.Warn("Failed to edit original Discord message on resolve, sending a new message",
+			"DiscordService",
+			logger.String("channel_id", ref.ChatID),
+			logger.String("message_id", ref.MessageID),
+			logger.Err(err))
+		return false
+	}
+
+	msgref.Delete(refKey)
+	logger.Info("Discord message edited on resolve",
+		"DiscordService",
+		logger.String("channel_id", ref.ChatID),
+		logger.String("message_id", ref.MessageID))
+	return true
+}
+
+// EditInteractionMessage edits the message a component interaction was attached to
+// (after a deferred update response)
+func (ds *DiscordService) EditInteractionMessage(interaction *discordgo.Interaction, content string, components []discordgo.MessageComponent) error {
+	if ds.session == nil {
+		return fmt.Errorf("Discord session not initialized")
+	}
+	_, err := ds.session.InteractionResponseEdit(interaction, &discordgo.WebhookEdit{
+		Content:    &content,
+		Components: &components,
+	})
+	return err
+}
+
+// InteractionFollowup sends an ephemeral follow-up message visible only to the user who pressed the button
+func (ds *DiscordService) InteractionFollowup(interaction *discordgo.Interaction, content string) error {
+	if ds.session == nil {
+		return fmt.Errorf("Discord session not initialized")
+	}
+	_, err := ds.session.FollowupMessageCreate(interaction, false, &discordgo.WebhookParams{
+		Content: content,
+		Flags:   discordgo.MessageFlagsEphemeral,
+	})
+	return err
+}
+
 // handleDiscordError provides user-friendly error messages for common Discord API errors
 func (ds *DiscordService) handleDiscordError(err error, channelID string) error {
+	// Keep rate limit errors intact so callers can reschedule
+	if _, limited := AsRateLimitError(err); limited {
+		return err
+	}
+
 	errStr := err.Error()
 
 	switch {
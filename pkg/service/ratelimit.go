@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/logger"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-telegram/bot"
+)
+
+const (
+	defaultRateLimitMaxWait = 10 * time.Second
+
+	// maxRateLimitRetries 單次發送中遇到提供者 429 時最多重試的次數
+	maxRateLimitRetries = 3
+
+	// idleBucketTTL 閒置超過此時間且已補滿的 bucket 會被清除
+	idleBucketTTL = 10 * time.Minute
+)
+
+// 各提供者預設的 token bucket（Telegram 群組約 20 則/分鐘，Slack 與 Discord 每頻道約 1 則/秒）
+var defaultBuckets = map[string]config.BucketConf{
+	"telegram": {PerMinute: 20, Burst: 3},
+	"slack":    {PerMinute: 60, Burst: 3},
+	"discord":  {PerMinute: 60, Burst: 5},
+}
+
+// RateLimitError 提供者速率限制錯誤，RetryAfter 為建議的等待時間
+type RateLimitError struct {
+	Provider   string
+	Key        string
+	RetryAfter time.Duration
+	Err        error
+}
+
+// Error 實作 error 介面
+func (e *RateLimitError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s rate limited on %s, retry after %s: %v", e.Provider, e.Key, e.RetryAfter, e.Err)
+	}
+	return fmt.Sprintf("%s rate limited on %s, retry after %s", e.Provider, e.Key, e.RetryAfter)
+}
+
+// Unwrap 返回原始錯誤
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+// AsRateLimitError 從錯誤鏈中取出速率限制資訊，支援 Telegram retry_after、Discord RateLimitError 與本服務的 RateLimitError
+func AsRateLimitError(err error) (*RateLimitError, bool) {
+	if err == nil {
+		return nil, false
+	}
+
+	var rle *RateLimitError
+	if errors.As(err, &rle) {
+		return rle, true
+	}
+
+	var telegramErr *bot.TooManyRequestsError
+	if errors.As(err, &telegramErr) {
+		return &RateLimitError{
+			Provider:   "telegram",
+			RetryAfter: time.Duration(telegramErr.RetryAfter) * time.Second,
+			Err:        err,
+		}, true
+	}
+
+	var discordErr *discordgo.RateLimitError
+	if errors.As(err, &discordErr) && discordErr.RateLimit != nil && discordErr.TooManyRequests != nil {
+		return &RateLimitError{
+			Provider:   "discord",
+			Key:        discordErr.URL,
+			RetryAfter: discordErr.RetryAfter,
+			Err:        err,
+		}, true
+	}
+
+	return nil, false
+}
+
+// parseRetryAfterHeader 解析 HTTP Retry-After 標頭（秒數或 HTTP 日期）
+func parseRetryAfterHeader(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// tokenBucket 單一 chat / 頻道的 token bucket
+type tokenBucket struct {
+	tokens       float64
+	last         time.Time
+	blockedUntil time.Time // 提供者回報 429 後的解除時間
+}
+
+// RateLimiter 以 key（chat ID 或頻道）區分的 token bucket 速率限制器
+type RateLimiter struct {
+	provider string
+	rate     float64 // 每秒補充的 token
+	burst    float64
+	maxWait  time.Duration
+	disabled bool
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	lastGC  time.Time
+}
+
+// NewRateLimiter 依配置建立指定提供者的速率限制器，未設定的欄位使用預設值
+func NewRateLimiter(provider string, conf config.BucketConf, maxWait time.Duration) *RateLimiter {
+	defaults := defaultBuckets[provider]
+	if conf.PerMinute == 0 {
+		conf.PerMinute = defaults.PerMinute
+	}
+	if conf.Burst <= 0 {
+		conf.Burst = defaults.Burst
+	}
+	if conf.Burst <= 0 {
+		conf.Burst = 1
+	}
+	if maxWait <= 0 {
+		maxWait = defaultRateLimitMaxWait
+	}
+
+	return &RateLimiter{
+		provider: provider,
+		rate:     float64(conf.PerMinute) / 60,
+		burst:    float64(conf.Burst),
+		maxWait:  maxWait,
+		disabled: conf.PerMinute < 0,
+		buckets:  make(map[string]*tokenBucket),
+	}
+}
+
+// newProviderRateLimiter 從全局配置建立提供者速率限制器
+func newProviderRateLimiter(provider string) *RateLimiter {
+	var conf config.BucketConf
+	switch provider {
+	case "telegram":
+		conf = config.RateLimit.Telegram
+	case "slack":
+		conf = config.RateLimit.Slack
+	case "discord":
+		conf = config.RateLimit.Discord
+	}
+	return NewRateLimiter(provider, conf, config.RateLimit.MaxWait)
+}
+
+// Do 在速率限制下執行 send：先取得 token，遇到提供者 429 時若等待時間在 maxWait 內則等待後重試，
+// 否則回傳 RateLimitError 讓呼叫端（例如投遞佇列）延後處理
+func (rl *RateLimiter) Do(ctx context.Context, key string, send func() error) error {
+	if rl == nil {
+		return send()
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := rl.Wait(ctx, key); err != nil {
+			return err
+		}
+
+		err := send()
+		rle, limited := AsRateLimitError(err)
+		if !limited {
+			return err
+		}
+
+		if rle.Provider == "" {
+			rle.Provider = rl.provider
+		}
+		rle.Key = key
+		rl.Block(key, rle.RetryAfter)
+
+		logger.Warn("Provider rate limit hit", "rate_limiter",
+			logger.String("provider", rl.provider),
+			logger.String("key", key),
+			logger.String("retry_after", rle.RetryAfter.String()),
+			logger.Int("attempt", attempt+1))
+
+		if rle.RetryAfter > rl.maxWait || attempt+1 >= maxRateLimitRetries {
+			return rle
+		}
+	}
+}
+
+// Wait 等待 key 可用的 token；所需等待時間超過 maxWait 時不消耗 token 並回傳 RateLimitError
+func (rl *RateLimiter) Wait(ctx context.Context, key string) error {
+	if rl == nil {
+		return nil
+	}
+
+	delay, ok := rl.reserve(key)
+	if !ok {
+		return &RateLimitError{Provider: rl.provider, Key: key, RetryAfter: delay}
+	}
+	if delay <= 0 {
+		return nil
+	}
+
+	logger.Debug("Waiting for rate limit token", "rate_limiter",
+		logger.String("provider", rl.provider),
+		logger.String("key", key),
+		logger.String("delay", delay.String()))
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Block 記錄提供者回報的 retry_after，期間內同一 key 的發送都會等待
+func (rl *RateLimiter) Block(key string, retryAfter time.Duration) {
+	if rl == nil || retryAfter <= 0 {
+		return
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	b := rl.bucket(key, time.Now())
+	until := time.Now().Add(retryAfter)
+	if until.After(b.blockedUntil) {
+		b.blockedUntil = until
+	}
+}
+
+// reserve 預約一個 token 並回傳需等待的時間；等待時間超過 maxWait 時回傳 false 且不預約
+func (rl *RateLimiter) reserve(key string) (time.Duration, bool) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	rl.gc(now)
+	b := rl.bucket(key, now)
+
+	var delay time.Duration
+	if b.blockedUntil.After(now) {
+		delay = b.blockedUntil.Sub(now)
+	}
+
+	// 停用 token bucket 時只遵守提供者回報的 retry_after
+	if rl.disabled {
+		b.last = now
+		if delay > rl.maxWait {
+			return delay, false
+		}
+		return delay, true
+	}
+
+	// 補充 token
+	if rl.rate > 0 {
+		b.tokens = math.Min(rl.burst, b.tokens+now.Sub(b.last).Seconds()*rl.rate)
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		if rl.rate <= 0 {
+			return rl.maxWait + time.Second, false
+		}
+		tokenDelay := time.Duration((1 - b.tokens) / rl.rate * float64(time.Second))
+		if tokenDelay > delay {
+			delay = tokenDelay
+		}
+	}
+
+	if delay > rl.maxWait {
+		return delay, false
+	}
+
+	// token 可為負值，代表已被後續等待中的請求預約
+	b.tokens--
+	return delay, true
+}
+
+// bucket 取得或建立 key 的 bucket（需持有鎖）
+func (rl *RateLimiter) bucket(key string, now time.Time) *tokenBucket {
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: rl.burst, last: now}
+		rl.buckets[key] = b
+	}
+	return b
+}
+
+// gc 清除閒置且已補滿的 bucket，避免 key 無限增長（需持有鎖）
+func (rl *RateLimiter) gc(now time.Time) {
+	if now.Sub(rl.lastGC) < idleBucketTTL {
+		return
+	}
+	rl.lastGC = now
+	for key, b := range rl.buckets {
+		if now.Sub(b.last) > idleBucketTTL && now.After(b.blockedUntil) {
+			delete(rl.buckets, key)
+		}
+	}
+}
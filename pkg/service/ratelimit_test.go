@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"alert-webhooks/config"
+)
+
+func TestReserve(t *testing.T) {
+	// 每秒 1 個 token，burst 2
+	rl := NewRateLimiter("slack", config.BucketConf{PerMinute: 60, Burst: 2}, 1500*time.Millisecond)
+
+	tests := []struct {
+		name     string
+		minDelay time.Duration
+		maxDelay time.Duration
+		ok       bool
+	}{
+		{"first burst token", 0, 0, true},
+		{"second burst token", 0, 0, true},
+		{"waits for the next token", 900 * time.Millisecond, time.Second, true},
+		// 第四個請求需等待約 2 秒，超過 maxWait
+		{"wait beyond max_wait is rejected", 1900 * time.Millisecond, 2 * time.Second, false},
+		{"rejected reservation consumes no token", 1900 * time.Millisecond, 2 * time.Second, false},
+	}
+
+	for _, tt := range tests {
+		delay, ok := rl.reserve("#alerts")
+		if ok != tt.ok || delay < tt.minDelay || delay > tt.maxDelay {
+			t.Errorf("%s: reserve = %s, %t, want %s-%s, %t", tt.name, delay, ok, tt.minDelay, tt.maxDelay, tt.ok)
+		}
+	}
+
+	// 其他 key 有獨立的 bucket
+	if delay, ok := rl.reserve("#other"); !ok || delay != 0 {
+		t.Errorf("reserve for another key = %s, %t, want 0, true", delay, ok)
+	}
+}
+
+func TestReserveZeroRate(t *testing.T) {
+	rl := NewRateLimiter("slack", config.BucketConf{PerMinute: 0, Burst: 1}, time.Second)
+	rl.rate = 0
+
+	if _, ok := rl.reserve("#alerts"); !ok {
+		t.Fatal("first reserve with a burst token was rejected")
+	}
+	if delay, ok := rl.reserve("#alerts"); ok || delay <= time.Second {
+		t.Errorf("reserve without refill = %s, %t, want a delay beyond max_wait and false", delay, ok)
+	}
+}
+
+func TestBlock(t *testing.T) {
+	rl := NewRateLimiter("telegram", config.BucketConf{PerMinute: 600, Burst: 5}, time.Minute)
+
+	rl.Block("chat", 0)
+	if delay, _ := rl.reserve("chat"); delay != 0 {
+		t.Errorf("reserve after Block(0) = %s, want 0", delay)
+	}
+
+	rl.Block("chat", 30*time.Second)
+	// 較短的 retry_after 不會縮短已有的封鎖時間
+	rl.Block("chat", time.Second)
+	delay, ok := rl.reserve("chat")
+	if !ok || delay < 29*time.Second || delay > 30*time.Second {
+		t.Errorf("reserve while blocked = %s, %t, want about 30s, true", delay, ok)
+	}
+
+	rl.Block("chat", 2*time.Minute)
+	if delay, ok := rl.reserve("chat"); ok || delay < time.Minute {
+		t.Errorf("reserve blocked beyond max_wait = %s, %t, want > 1m, false", delay, ok)
+	}
+}
+
+func TestDisabledLimiterOnlyHonoursBlock(t *testing.T) {
+	rl := NewRateLimiter("discord", config.BucketConf{PerMinute: -1}, time.Second)
+
+	for i := 0; i < 100; i++ {
+		if delay, ok := rl.reserve("channel"); !ok || delay != 0 {
+			t.Fatalf("reserve %d on disabled limiter = %s, %t, want 0, true", i, delay, ok)
+		}
+	}
+
+	rl.Block("channel", 5*time.Second)
+	if delay, ok := rl.reserve("channel"); ok || delay < 4*time.Second {
+		t.Errorf("reserve on disabled limiter while blocked = %s, %t, want about 5s, false", delay, ok)
+	}
+}
+
+func TestDo(t *testing.T) {
+	limited := func(retryAfter time.Duration) error {
+		return &RateLimitError{RetryAfter: retryAfter, Err: errors.New("429")}
+	}
+	failure := errors.New("channel not found")
+
+	tests := []struct {
+		name    string
+		results []error
+		calls   int
+		err     string
+	}{
+		{"success", []error{nil}, 1, ""},
+		{"other errors are returned without retry", []error{failure}, 1, "channel not found"},
+		{"retries after a short retry_after", []error{limited(10 * time.Millisecond), nil}, 2, ""},
+		{"gives up after max retries", []error{limited(time.Millisecond), limited(time.Millisecond), limited(time.Millisecond), nil}, maxRateLimitRetries, "slack rate limited on #alerts"},
+		{"retry_after beyond max_wait is returned", []error{limited(time.Minute), nil}, 1, "retry after 1m0s"},
+	}
+
+	for _, tt := range tests {
+		rl := NewRateLimiter("slack", config.BucketConf{PerMinute: 6000, Burst: 10}, time.Second)
+		calls := 0
+		err := rl.Do(context.Background(), "#alerts", func() error {
+			result := tt.results[calls]
+			calls++
+			return result
+		})
+
+		if calls != tt.calls {
+			t.Errorf("%s: send called %d times, want %d", tt.name, calls, tt.calls)
+		}
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: Do returned error: %v", tt.name, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: Do error = %v, want it to contain %q", tt.name, err, tt.err)
+		}
+		if tt.err != "" && err != failure {
+			if rle, ok := AsRateLimitError(err); !ok || rle.Key != "#alerts" || rle.Provider != "slack" {
+				t.Errorf("%s: Do error %v is not a RateLimitError for slack #alerts", tt.name, err)
+			}
+		}
+	}
+}
+
+func TestDoHonoursContext(t *testing.T) {
+	rl := NewRateLimiter("discord", config.BucketConf{PerMinute: 60, Burst: 1}, time.Minute)
+	rl.Block("channel", 30*time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := rl.Do(ctx, "channel", func() error {
+		t.Error("send called while the channel is blocked")
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Do returned after %s, want it to stop at the context deadline", elapsed)
+	}
+}
+
+func TestNilLimiterSendsDirectly(t *testing.T) {
+	var rl *RateLimiter
+	calls := 0
+	if err := rl.Do(context.Background(), "key", func() error { calls++; return nil }); err != nil || calls != 1 {
+		t.Errorf("nil limiter Do = %v with %d calls, want nil and 1", err, calls)
+	}
+}
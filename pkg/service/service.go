@@ -1,12 +1,16 @@
 package service
 
 import (
+	"context"
 	"strings"
 	"sync"
+	"time"
 
 	"alert-webhooks/config"
+	"alert-webhooks/pkg/actions"
 	"alert-webhooks/pkg/logger"
 	"alert-webhooks/pkg/notification"
+	"alert-webhooks/pkg/notification/providers"
 	"alert-webhooks/pkg/template"
 )
 
@@ -40,6 +44,11 @@ func (sm *ServiceManager) InitServices() error {
 	// 初始化模板引擎
 	sm.initTemplateEngine()
 
+	// 停止舊 Telegram 服務的 long polling，避免重新初始化後出現兩個 getUpdates
+	if sm.telegramService != nil {
+		sm.telegramService.StopPolling()
+	}
+
 	// 初始化 Telegram 服務（可選）
 	if config.Telegram.Enable && config.Telegram.Token != "" {
 		telegramService, err := NewTelegramService(config.Telegram.Token)
@@ -54,6 +63,7 @@ func (sm *ServiceManager) InitServices() error {
 		} else {
 			sm.telegramService = telegramService
 			logger.Info("Telegram service initialized successfully", "service_manager")
+			sm.startTelegramActions()
 		}
 	} else {
 		logger.Info("Telegram not enabled or token not configured, skipping Telegram service", "service_manager")
@@ -99,6 +109,34 @@ func (sm *ServiceManager) InitServices() error {
 	return nil
 }
 
+// startTelegramActions 依配置以 long polling 或 webhook 接收 Telegram 互動按鈕的 callback query
+func (sm *ServiceManager) startTelegramActions() {
+	conf := config.Actions.Telegram
+	if !conf.Enable {
+		return
+	}
+
+	switch strings.ToLower(conf.Mode) {
+	case "", "polling":
+		if err := sm.telegramService.StartPolling(actions.HandleTelegramCallback, actions.TelegramCallbackPrefix); err != nil {
+			logger.Error("Failed to start Telegram long polling, action buttons will not respond", "service_manager", logger.Err(err))
+		}
+	case "webhook":
+		if conf.WebhookURL == "" {
+			logger.Info("Telegram actions in webhook mode, webhook_url not set, skipping webhook registration", "service_manager")
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := sm.telegramService.SetWebhook(ctx, conf.WebhookURL, conf.WebhookSecret); err != nil {
+			logger.Error("Failed to register Telegram webhook, action buttons will not respond", "service_manager", logger.Err(err))
+		}
+	default:
+		logger.Warn("Unknown Telegram actions mode, action buttons will not respond", "service_manager",
+			logger.String("mode", conf.Mode))
+	}
+}
+
 // GetTelegramService 獲取 Telegram 服務
 func (sm *ServiceManager) GetTelegramService() *TelegramService {
 	sm.mu.RLock()
@@ -148,10 +186,21 @@ func (sm *ServiceManager) GetTemplateEngine() *template.TemplateEngine {
 	return sm.templateEngine
 }
 
-// initTemplateEngine 初始化模板引擎
+// initTemplateEngine 初始化模板引擎，載入後驗證所有模板
 func (sm *ServiceManager) initTemplateEngine() {
 	logger.Info("Initializing template engine", "service_manager")
 	
+	templateEngine, loaded := LoadTemplateEngine()
+	if loaded {
+		checkTemplates(templateEngine)
+	}
+	
+	sm.templateEngine = templateEngine
+	logger.Info("Template engine initialized successfully", "service_manager")
+}
+
+// LoadTemplateEngine 建立模板引擎並從預設路徑載入配置與模板，loaded 表示是否成功載入模板
+func LoadTemplateEngine() (*template.TemplateEngine, bool) {
 	templateEngine := template.NewTemplateEngine()
 	
 	// 不再在初始化時選擇特定配置，而是加載默認的 full 配置
@@ -185,16 +234,38 @@ func (sm *ServiceManager) initTemplateEngine() {
 		logger.Warn("Failed to load templates from all paths", "service_manager")
 	}
 	
-	sm.templateEngine = templateEngine
-	logger.Info("Template engine initialized successfully", "service_manager")
+	return templateEngine, loaded
+}
+
+// checkTemplates 以範例資料驗證所有模板：錯誤逐筆記錄，警告只記錄數量，
+// 完整報告可透過 validate-templates 子命令取得
+func checkTemplates(templateEngine *template.TemplateEngine) {
+	report := templateEngine.ValidateTemplates(providers.MessageLengthLimits())
+	for _, issue := range report.Issues {
+		if issue.Severity == template.SeverityError {
+			logger.Error("Template validation failed", "service_manager",
+				logger.String("issue", issue.String()))
+		}
+	}
+	
+	logger.Info("Template validation completed", "service_manager",
+		logger.Int("templates", report.Templates),
+		logger.Int("errors", report.Errors()),
+		logger.Int("warnings", report.Warnings()))
 }
 
-// ReloadTemplateEngine 重新載入模板引擎
+// ReloadTemplateEngine 重新載入模板引擎，並以新的模板引擎重新初始化通知管理器與各提供者；
+// 路由處理器每次請求都從 GetTemplateEngine 取得模板引擎，因此重新載入後立即生效
 func (sm *ServiceManager) ReloadTemplateEngine() {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 	
 	logger.Info("Reloading template engine", "service_manager")
 	sm.initTemplateEngine()
+
+	if err := notification.GetNotificationManager().Reload(sm.templateEngine, sm.telegramService, sm.slackService, sm.discordService); err != nil {
+		logger.Error("Failed to reload notification manager with the new template engine", "service_manager", logger.Err(err))
+		return
+	}
 	logger.Info("Template engine reloaded successfully", "service_manager")
 }
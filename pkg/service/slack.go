@@ -12,7 +12,10 @@ import (
 	"time"
 
 	"alert-webhooks/config"
+	"alert-webhooks/pkg/blockkit"
 	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/msgref"
+	"alert-webhooks/pkg/notification/types"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -25,6 +28,7 @@ type SlackService struct {
 	client   *http.Client
 	mu       sync.RWMutex
 	channels map[string]string // level -> channel 映射
+	limiter  *RateLimiter      // 每個頻道的速率限制
 }
 
 // SlackMessage Slack message structure
@@ -35,11 +39,12 @@ type SlackMessage struct {
 	IconURL     string       `json:"icon_url,omitempty"`
 	IconEmoji   string       `json:"icon_emoji,omitempty"`
 	ThreadTS    string       `json:"thread_ts,omitempty"`
+	ReplyBroadcast bool      `json:"reply_broadcast,omitempty"` // thread 回覆同時發送到頻道
 	LinkNames   bool         `json:"link_names,omitempty"`
 	UnfurlLinks bool         `json:"unfurl_links,omitempty"`
 	UnfurlMedia bool         `json:"unfurl_media,omitempty"`
-	Attachments []Attachment `json:"attachments,omitempty"`
-	Blocks      []Block      `json:"blocks,omitempty"`
+	Attachments []Attachment  `json:"attachments,omitempty"`
+	Blocks      []interface{} `json:"blocks,omitempty"` // Block 或任意 Block Kit 結構
 }
 
 // Attachment Slack 附件結構
@@ -61,16 +66,15 @@ type Field struct {
 	Short bool   `json:"short"`
 }
 
-// Block Slack 塊結構（簡化版）
-type Block struct {
-	Type string      `json:"type"`
-	Text interface{} `json:"text,omitempty"`
-}
+// Block Slack Block Kit block，完整模型見 pkg/blockkit
+type Block = blockkit.Block
 
 // SlackResponse Slack API 響應
 type SlackResponse struct {
-	OK    bool   `json:"ok"`
-	Error string `json:"error,omitempty"`
+	OK      bool   `json:"ok"`
+	Error   string `json:"error,omitempty"`
+	Channel string `json:"channel,omitempty"` // chat.postMessage / chat.update 返回的頻道 ID
+	TS      string `json:"ts,omitempty"`      // 訊息時間戳
 }
 
 // NewSlackService 創建新的 Slack 服務
@@ -98,6 +102,7 @@ func NewSlackService(token string) (*SlackService, error) {
 		token:    token,
 		client:   client,
 		channels: channels,
+		limiter:  newProviderRateLimiter("slack"),
 	}
 
 	// 測試連接
@@ -120,10 +125,11 @@ func (ss *SlackService) TestConnection() error {
 		return err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+ss.token)
+	token, client := ss.apiClient()
+	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := ss.client.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return err
 	}
@@ -149,6 +155,19 @@ func (ss *SlackService) TestConnection() error {
 
 // SendMessage send message to specified channel
 func (ss *SlackService) SendMessage(ctx context.Context, channel, message string) error {
+	return ss.SendChannelMessage(ctx, channel, message, nil)
+}
+
+// SendChannelMessage 發送訊息到指定頻道，blocks 不為空時以 blocks 顯示、message 作為通知預覽文字；
+// 未指定 blocks 時，slack.template_mode 為 blocks 則以模板渲染 AlertData 的 blocks，並附加互動按鈕。
+// 帶有 AlertData 時記錄 firing 訊息的參照：resolved 時可編輯原訊息（message_ref.edit_on_resolve），
+// 啟用 slack.thread_ts 時同一警報群組的後續通知會回覆到 firing 訊息的 thread
+func (ss *SlackService) SendChannelMessage(ctx context.Context, channel, message string, opts *types.SlackMessageOptions) error {
+	return ss.sendChannelMessage(ctx, channel, "", message, opts)
+}
+
+// sendChannelMessage 發送訊息到指定頻道；level 用於選擇 blocks 模式的模板集與 time_format，空值不套用 level 設定
+func (ss *SlackService) sendChannelMessage(ctx context.Context, channel, level, message string, opts *types.SlackMessageOptions) error {
 	ctx, span := otel.Tracer("slack").Start(ctx, "SlackService.SendMessage")
 	defer span.End()
 
@@ -157,19 +176,89 @@ func (ss *SlackService) SendMessage(ctx context.Context, channel, message string
 		attribute.String("messaging.channel", channel),
 	)
 
-	err := ss.SendMessageWithOptions(channel, message, nil)
+	var options *SlackMessage
+	var refKey string
+	resolved := false
+	if opts != nil {
+		options = &SlackMessage{
+			Username:  opts.Username,
+			IconURL:   opts.IconURL,
+			IconEmoji: opts.IconEmoji,
+			ThreadTS:  opts.ThreadTS,
+			Blocks:    ss.messageBlocks(level, message, opts),
+		}
+		if opts.AlertData != nil {
+			refKey = msgref.Key("slack", channel, opts.AlertData)
+			resolved = msgref.IsResolved(opts.AlertData)
+		}
+	}
+
+	ref, hasRef := msgref.Get(refKey)
+	// 呼叫端自行指定 thread_ts 時不自動串接
+	threaded := hasRef && config.Slack.ThreadTS && options.ThreadTS == ""
+
+	// resolved 通知優先編輯原本的 firing 訊息；未使用 thread 時編輯成功即結束，失敗時改為發送新訊息
+	if resolved && hasRef && msgref.EditOnResolve() {
+		if ss.editReferencedMessage(ctx, ref, message, options) && !threaded {
+			msgref.Delete(refKey)
+			return nil
+		}
+	}
+
+	// 同一警報群組的後續通知（重送、部分恢復、最終恢復）回覆到 firing 訊息的 thread
+	if threaded {
+		channel = ref.ChatID
+		options.ThreadTS = ref.MessageID
+		options.ReplyBroadcast = config.Slack.ReplyBroadcast
+		span.SetAttributes(attribute.String("messaging.slack.thread_ts", ref.MessageID))
+	}
+
+	resp, err := ss.sendMessageWithOptions(ctx, channel, message, options)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
+		return err
 	}
-	return err
+
+	switch {
+	case refKey == "":
+	case resolved:
+		// 群組已恢復，下一次觸發重新開始新的訊息 / thread
+		msgref.Delete(refKey)
+	case threaded:
+		// 延長 thread 根訊息的參照有效期
+		msgref.Put(refKey, ref)
+	case resp.TS != "":
+		// 記錄 firing 訊息的參照（chat.update 需要頻道 ID，因此使用回應中的頻道）
+		msgref.Put(refKey, msgref.Ref{Provider: "slack", ChatID: resp.Channel, MessageID: resp.TS})
+	}
+	return nil
+}
+
+// editReferencedMessage 以訊息參照更新原本的訊息，更新失敗時返回 false
+func (ss *SlackService) editReferencedMessage(ctx context.Context, ref msgref.Ref, message string, options *SlackMessage) bool {
+	var blocks []interface{}
+	if options != nil {
+		blocks = options.Blocks
+	}
+	if err := ss.UpdateMessage(ctx, ref.ChatID, ref.MessageID, message, blocks); err != nil {
+		logger.Warn("Failed to update original Slack message on resolve, sending a new message", "slack",
+			logger.String("channel", ref.ChatID),
+			logger.String("ts", ref.MessageID),
+			logger.Err(err))
+		return false
+	}
+	return true
 }
 
 // SendMessageWithOptions send message to specified channel with options
 func (ss *SlackService) SendMessageWithOptions(channel, message string, options *SlackMessage) error {
-	ss.mu.RLock()
-	defer ss.mu.RUnlock()
+	_, err := ss.sendMessageWithOptions(context.Background(), channel, message, options)
+	return err
+}
 
+// sendMessageWithOptions 依頻道的速率限制發送訊息，返回 Slack API 回應
+func (ss *SlackService) sendMessageWithOptions(ctx context.Context, channel, message string, options *SlackMessage) (*SlackResponse, error) {
 	// Build message
 	msg := &SlackMessage{
 		Channel: channel,
@@ -203,6 +292,7 @@ func (ss *SlackService) SendMessageWithOptions(channel, message string, options
 		}
 		if options.ThreadTS != "" {
 			msg.ThreadTS = options.ThreadTS
+			msg.ReplyBroadcast = options.ReplyBroadcast
 		}
 		if len(options.Attachments) > 0 {
 			msg.Attachments = options.Attachments
@@ -212,11 +302,32 @@ func (ss *SlackService) SendMessageWithOptions(channel, message string, options
 		}
 	}
 
-	return ss.sendSlackMessage(msg)
+	// 等待速率限制時不持有鎖，token 與 client 在實際呼叫 API 時才於讀鎖下取得
+	var resp *SlackResponse
+	err := ss.limiter.Do(ctx, channel, func() error {
+		var sendErr error
+		resp, sendErr = ss.sendSlackMessage(msg)
+		return sendErr
+	})
+	return resp, err
 }
 
 // SendMessageToLevel send message to specified level channel
 func (ss *SlackService) SendMessageToLevel(ctx context.Context, level string, message string) error {
+	return ss.SendLevelMessage(ctx, level, message, nil)
+}
+
+// SendLevelMessage 發送訊息到指定等級對應的頻道，選項同 SendChannelMessage
+func (ss *SlackService) SendLevelMessage(ctx context.Context, level, message string, opts *types.SlackMessageOptions) error {
+	channel, err := ss.LevelChannel(level)
+	if err != nil {
+		return err
+	}
+	return ss.sendChannelMessage(ctx, channel, level, message, opts)
+}
+
+// LevelChannel 返回等級對應的頻道，未配置時使用預設頻道
+func (ss *SlackService) LevelChannel(level string) (string, error) {
 	// 動態讀取最新的配置以支持熱重載
 	var channel string
 	var exists bool
@@ -252,11 +363,11 @@ func (ss *SlackService) SendMessageToLevel(ctx context.Context, level string, me
 		if config.Slack.Channel != "" {
 			channel = config.Slack.Channel
 		} else {
-			return fmt.Errorf("no channel configured for level %s and no default channel", level)
+			return "", fmt.Errorf("no channel configured for level %s and no default channel", level)
 		}
 	}
 
-	return ss.SendMessage(ctx, channel, message)
+	return channel, nil
 }
 
 // SendRichMessage send rich text message
@@ -278,54 +389,124 @@ func (ss *SlackService) SendRichMessage(channel, title, message, color string, f
 }
 
 // sendSlackMessage actually send Slack message
-func (ss *SlackService) sendSlackMessage(msg *SlackMessage) error {
-	url := "https://slack.com/api/chat.postMessage"
+func (ss *SlackService) sendSlackMessage(msg *SlackMessage) (*SlackResponse, error) {
+	resp, err := ss.callAPI("chat.postMessage", msg.Channel, msg)
+	if err != nil {
+		logger.Error("Failed to send Slack message", "slack",
+			logger.String("channel", msg.Channel),
+			logger.String("text", msg.Text),
+			logger.Err(err))
+		return nil, err
+	}
+
+	logger.Info("Slack message sent successfully", "slack",
+		logger.String("channel", msg.Channel),
+		logger.String("ts", resp.TS),
+		logger.String("text", msg.Text))
+
+	return resp, nil
+}
 
-	jsonData, err := json.Marshal(msg)
+// UpdateMessage 以 chat.update 更新已發送的訊息（channel 為頻道 ID，ts 為訊息時間戳）；
+// blocks 為空時會清除原訊息的 blocks（包含按鈕），只保留文字
+func (ss *SlackService) UpdateMessage(ctx context.Context, channel, ts, message string, blocks []interface{}) error {
+	if blocks == nil {
+		blocks = []interface{}{}
+	}
+	payload := struct {
+		Channel string        `json:"channel"`
+		TS      string        `json:"ts"`
+		Text    string        `json:"text"`
+		Blocks  []interface{} `json:"blocks"`
+	}{channel, ts, message, blocks}
+
+	err := ss.limiter.Do(ctx, channel, func() error {
+		_, apiErr := ss.callAPI("chat.update", channel, payload)
+		return apiErr
+	})
 	if err != nil {
-		return fmt.Errorf("failed to marshal message: %v", err)
+		logger.Error("Failed to update Slack message", "slack",
+			logger.String("channel", channel),
+			logger.String("ts", ts),
+			logger.Err(err))
+		return err
+	}
+
+	logger.Info("Slack message updated successfully", "slack",
+		logger.String("channel", channel),
+		logger.String("ts", ts))
+
+	return nil
+}
+
+// apiClient 在讀鎖下取得 token 與 HTTP client，呼叫端不會在等待速率限制時持有鎖
+func (ss *SlackService) apiClient() (string, *http.Client) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	return ss.token, ss.client
+}
+
+// callAPI 呼叫 Slack Web API，處理速率限制與錯誤回應
+func (ss *SlackService) callAPI(method, channel string, payload interface{}) (*SlackResponse, error) {
+	url := "https://slack.com/api/" + method
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal message: %v", err)
 	}
 
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %v", err)
+		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+ss.token)
+	token, client := ss.apiClient()
+	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := ss.client.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		logger.Error("Failed to send Slack message", "slack",
-			logger.String("channel", msg.Channel),
-			logger.String("text", msg.Text),
-			logger.Err(err))
-		return fmt.Errorf("failed to send request: %v", err)
+		return nil, fmt.Errorf("failed to send request: %v", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response: %v", err)
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+
+	// Slack 以 HTTP 429 與 Retry-After 標頭回報速率限制
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter := parseRetryAfterHeader(resp.Header.Get("Retry-After"))
+		if retryAfter <= 0 {
+			retryAfter = time.Second
+		}
+		return nil, &RateLimitError{
+			Provider:   "slack",
+			Key:        channel,
+			RetryAfter: retryAfter,
+			Err:        fmt.Errorf("slack API returned %d", resp.StatusCode),
+		}
 	}
 
 	var slackResp SlackResponse
 	if err := json.Unmarshal(body, &slackResp); err != nil {
-		return fmt.Errorf("failed to parse response: %v", err)
+		return nil, fmt.Errorf("failed to parse response: %v", err)
 	}
 
 	if !slackResp.OK {
 		logger.Error("Slack API returned error", "slack",
-			logger.String("channel", msg.Channel),
+			logger.String("method", method),
+			logger.String("channel", channel),
 			logger.String("error", slackResp.Error))
 
 		// Provide more friendly error message
 		var friendlyError string
 		switch slackResp.Error {
 		case "not_in_channel":
-			friendlyError = fmt.Sprintf("Bot is not in channel %s. Please invite the bot to this channel in Slack: /invite @your_bot_name", msg.Channel)
+			friendlyError = fmt.Sprintf("Bot is not in channel %s. Please invite the bot to this channel in Slack: /invite @your_bot_name", channel)
 		case "channel_not_found":
-			friendlyError = fmt.Sprintf("Channel %s does not exist. Please check if the channel name is correct", msg.Channel)
+			friendlyError = fmt.Sprintf("Channel %s does not exist. Please check if the channel name is correct", channel)
 		case "invalid_auth":
 			friendlyError = "Invalid Slack token. Please check if the token in configuration is correct"
 		case "missing_scope":
@@ -334,14 +515,10 @@ func (ss *SlackService) sendSlackMessage(msg *SlackMessage) error {
 			friendlyError = fmt.Sprintf("Slack API error: %s", slackResp.Error)
 		}
 
-		return fmt.Errorf("%s", friendlyError)
+		return nil, fmt.Errorf("%s", friendlyError)
 	}
 
-	logger.Info("Slack message sent successfully", "slack",
-		logger.String("channel", msg.Channel),
-		logger.String("text", msg.Text))
-
-	return nil
+	return &slackResp, nil
 }
 
 // SetChannelForLevel 設定指定等級的頻道
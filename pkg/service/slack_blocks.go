@@ -0,0 +1,78 @@
+package service
+
+import (
+	"strings"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/actions"
+	"alert-webhooks/pkg/alertmodel"
+	"alert-webhooks/pkg/blockkit"
+	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/notification/types"
+	"alert-webhooks/pkg/template"
+)
+
+// slackBlocksMode 檢查 Slack 是否使用 Block Kit 模板模式（slack.template_mode: blocks）
+func slackBlocksMode() bool {
+	return strings.EqualFold(config.Slack.TemplateMode, "blocks")
+}
+
+// messageBlocks 返回警報訊息的 blocks：Block Kit 模式下渲染模板的 slack.blocks 區塊，
+// 並附加觸發中群組的互動按鈕；呼叫端已指定 blocks 或沒有 AlertData 時沿用 opts.Blocks
+func (ss *SlackService) messageBlocks(level, message string, opts *types.SlackMessageOptions) []interface{} {
+	if opts == nil {
+		return nil
+	}
+	if opts.Blocks != nil || opts.AlertData == nil {
+		return opts.Blocks
+	}
+
+	var blocks []blockkit.Block
+	if slackBlocksMode() {
+		blocks = ss.alertBlocks(level, opts)
+	}
+	return actions.SlackBlocks(message, blocks, opts.AlertData)
+}
+
+// alertBlocks 以模板渲染警報群組的 Block Kit blocks，渲染或解析失敗時返回 nil，改為發送文字訊息
+func (ss *SlackService) alertBlocks(level string, opts *types.SlackMessageOptions) []blockkit.Block {
+	te := GetServiceManager().GetTemplateEngine()
+	if te == nil {
+		return nil
+	}
+
+	data := opts.AlertData
+	templateData := alertmodel.BuildTemplateData(
+		data.Status,
+		data.Alerts,
+		data.GroupLabels,
+		data.CommonLabels,
+		data.CommonAnnotations,
+		data.ExternalURL,
+		te.GetCurrentFormatOptions(),
+	)
+	templateData.Level = level
+
+	language := opts.Language
+	if language == "" {
+		language = config.Slack.TemplateLanguage
+	}
+	if language == "" {
+		language = "eng"
+	}
+	name := template.SelectTemplateSet(opts.TemplateName, "slack", level)
+	language = te.GetTemplateSetLanguage(name, language)
+
+	output, err := te.RenderNamedBlockForPlatform(name, template.SlackBlocksTemplate, language, "slack", templateData)
+	if err == nil {
+		var blocks []blockkit.Block
+		if blocks, err = blockkit.Parse([]byte(output)); err == nil {
+			return blocks
+		}
+	}
+	logger.Warn("Failed to render Slack blocks, sending the text message", "slack",
+		logger.String("template_set", name),
+		logger.String("language", language),
+		logger.Err(err))
+	return nil
+}
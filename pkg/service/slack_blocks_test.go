@@ -0,0 +1,91 @@
+package service
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/notification/types"
+	"alert-webhooks/pkg/template"
+)
+
+func TestMessageBlocks(t *testing.T) {
+	te := template.NewTemplateEngine()
+	if err := te.LoadTemplates(filepath.Join("..", "..", "templates", "alerts")); err != nil {
+		t.Fatalf("LoadTemplates returned error: %v", err)
+	}
+	sm := GetServiceManager()
+	sm.mu.Lock()
+	previous := sm.templateEngine
+	sm.templateEngine = te
+	sm.mu.Unlock()
+	defer func() {
+		sm.mu.Lock()
+		sm.templateEngine = previous
+		sm.mu.Unlock()
+	}()
+
+	mode := config.Slack.TemplateMode
+	defer func() { config.Slack.TemplateMode = mode }()
+
+	data := &types.AlertManagerData{
+		Status:       "firing",
+		CommonLabels: map[string]interface{}{"alertname": "HighCPU", "severity": "warning"},
+		Alerts: []map[string]interface{}{
+			{"status": "firing", "labels": map[string]interface{}{"alertname": "HighCPU", "severity": "warning", "pod": "api-1"}},
+		},
+	}
+	ss := &SlackService{}
+
+	config.Slack.TemplateMode = "full"
+	if blocks := ss.messageBlocks("L1", "text", &types.SlackMessageOptions{AlertData: data}); blocks != nil {
+		t.Errorf("full mode returned %d blocks, want nil", len(blocks))
+	}
+
+	config.Slack.TemplateMode = "blocks"
+	preset := []interface{}{map[string]string{"type": "divider"}}
+	if blocks := ss.messageBlocks("L1", "text", &types.SlackMessageOptions{Blocks: preset, AlertData: data}); len(blocks) != 1 {
+		t.Errorf("blocks given by the caller were replaced: %v", blocks)
+	}
+	if blocks := ss.messageBlocks("L1", "text", &types.SlackMessageOptions{}); blocks != nil {
+		t.Errorf("message without AlertData returned %d blocks, want nil", len(blocks))
+	}
+
+	blocks := ss.messageBlocks("L1", "text", &types.SlackMessageOptions{AlertData: data, Language: "tw"})
+	out, err := json.Marshal(blocks)
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+	if !strings.Contains(string(out), `"type":"header"`) || !strings.Contains(string(out), "🟡 [FIRING:1] HighCPU") {
+		t.Errorf("blocks have no header for the group: %s", out)
+	}
+	if !strings.Contains(string(out), "觸發中") {
+		t.Errorf("blocks are not rendered in the requested language: %s", out)
+	}
+
+	// 不存在的模板集使用預設模板集
+	if blocks := ss.messageBlocks("L1", "text", &types.SlackMessageOptions{AlertData: data, TemplateName: "missing"}); blocks == nil {
+		t.Error("unknown template set did not fall back to the default set")
+	}
+
+	// 輸出不是 blocks 陣列時改為發送文字訊息
+	dir := t.TempDir()
+	broken := `{{ define "slack.blocks" }}not json{{ end }}{{ .AlertName }}`
+	if err := os.WriteFile(filepath.Join(dir, "alert_template_eng.tmpl"), []byte(broken), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := te.LoadTemplates(dir); err != nil {
+		t.Fatalf("LoadTemplates returned error: %v", err)
+	}
+	if blocks := ss.messageBlocks("L1", "text", &types.SlackMessageOptions{AlertData: data}); blocks != nil {
+		t.Errorf("invalid blocks template returned %d blocks, want nil", len(blocks))
+	}
+
+	config.Slack.TemplateMode = "BLOCKS"
+	if !slackBlocksMode() {
+		t.Error("slackBlocksMode is case sensitive")
+	}
+}
@@ -6,11 +6,15 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"alert-webhooks/config"
 	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/msgref"
+	"alert-webhooks/pkg/notification/types"
+	"alert-webhooks/pkg/telegramhtml"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
@@ -24,6 +28,9 @@ type TelegramService struct {
 	bot  *bot.Bot
 	mu   sync.RWMutex
 	chatIDs map[int]int64 // level -> chat_id 映射
+	topics  map[int]int   // level -> forum topic（message_thread_id）映射
+	limiter *RateLimiter  // 每個 chat 的速率限制
+	stopUpdates context.CancelFunc // 停止 long polling
 }
 
 // NewTelegramService 創建新的 Telegram 服務
@@ -40,6 +47,9 @@ func NewTelegramService(token string) (*TelegramService, error) {
 	// 使用自定義 HTTP 客戶端創建 bot
 	opts := []bot.Option{
 		bot.WithHTTPClient(30*time.Second, httpClient),
+		// 只接收互動按鈕的 callback query，其他更新直接忽略
+		bot.WithAllowedUpdates(bot.AllowedUpdates{models.AllowedUpdateCallbackQuery}),
+		bot.WithDefaultHandler(func(ctx context.Context, b *bot.Bot, update *models.Update) {}),
 	}
 
 	logger.Info("Creating Telegram bot with extended timeout", "telegram_service",
@@ -78,8 +88,9 @@ func NewTelegramService(token string) (*TelegramService, error) {
 		return nil, fmt.Errorf("failed to create bot after %d attempts: %v", maxRetries, err)
 	}
 
-	// 從配置檔案讀取 chat_id 映射
+	// 從配置檔案讀取 chat_id 映射（格式為 chat_id 或 chat_id:topic_id）
 	chatIDs := make(map[int]int64)
+	topics := make(map[int]int)
 
 	// 讀取 ChatIDs1-6
 	chatIDConfigs := []struct {
@@ -96,7 +107,7 @@ func NewTelegramService(token string) (*TelegramService, error) {
 
 	for _, cfg := range chatIDConfigs {
 		if cfg.value != "" {
-			chatID, err := strconv.ParseInt(cfg.value, 10, 64)
+			chatID, topicID, err := ParseTelegramChatTarget(cfg.value)
 			if err != nil {
 				logger.Warn("Invalid chat ID in config", "telegram",
 					logger.Int("level", cfg.level),
@@ -106,9 +117,13 @@ func NewTelegramService(token string) (*TelegramService, error) {
 			}
 
 			chatIDs[cfg.level] = chatID
+			if topicID > 0 {
+				topics[cfg.level] = topicID
+			}
 			logger.Info("Loaded chat ID from config", "telegram",
 				logger.Int("level", cfg.level),
-				logger.Int64("chat_id", chatID))
+				logger.Int64("chat_id", chatID),
+				logger.Int("topic_id", topicID))
 		}
 	}
 
@@ -127,6 +142,8 @@ func NewTelegramService(token string) (*TelegramService, error) {
 	ts := &TelegramService{
 		bot:     b,
 		chatIDs: chatIDs,
+		topics:  topics,
+		limiter: newProviderRateLimiter("telegram"),
 	}
 
 	// 測試機器人連接
@@ -159,6 +176,12 @@ func (ts *TelegramService) testConnection() error {
 
 // SendMessage send message to specified level chat
 func (ts *TelegramService) SendMessage(ctx context.Context, level int, message string) error {
+	_, err := ts.SendMessageWithOptions(ctx, level, message, nil)
+	return err
+}
+
+// SendMessageWithOptions 發送訊息到指定等級的 chat，可附加 inline keyboard，返回已發送的訊息
+func (ts *TelegramService) SendMessageWithOptions(ctx context.Context, level int, message string, opts *types.TelegramMessageOptions) (*models.Message, error) {
 	ctx, span := otel.Tracer("telegram").Start(ctx, "TelegramService.SendMessage")
 	defer span.End()
 
@@ -167,36 +190,45 @@ func (ts *TelegramService) SendMessage(ctx context.Context, level int, message s
 		attribute.Int("messaging.level", level),
 	)
 
+	var alertData *types.AlertManagerData
+	if opts != nil {
+		alertData = opts.AlertData
+	}
+
+	// 只在讀鎖下取得 bot、chat 與 topic；等待速率限制時不可持有鎖，否則會阻塞需要寫鎖的配置更新
 	ts.mu.RLock()
-	defer ts.mu.RUnlock()
+	degraded := ts.bot == nil
+	chatID, exists := ts.chatIDs[level]
+	var configuredLevels []int
+	if !exists {
+		for l := range ts.chatIDs {
+			configuredLevels = append(configuredLevels, l)
+		}
+	}
+	topicID := ts.topicFor(level, alertData)
+	ts.mu.RUnlock()
 
 	// 檢查是否為降級模式
-	if ts.bot == nil {
+	if degraded {
 		err := fmt.Errorf("telegram service is in degraded mode - bot initialization failed")
 		logger.Error("Telegram service is in degraded mode - bot not available", "telegram_service",
 			logger.Int("level", level),
 			logger.String("message_preview", getMessagePreview(message)))
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
-		return err
+		return nil, err
 	}
 
-	chatID, exists := ts.chatIDs[level]
 	if !exists {
 		// 記錄所有已配置的 level
-		configuredLevels := make([]int, 0, len(ts.chatIDs))
-		for l := range ts.chatIDs {
-			configuredLevels = append(configuredLevels, l)
-		}
-
 		logger.Error("No chat ID configured for requested level", "telegram_service",
 			logger.Int("requested_level", level),
 			logger.Any("configured_levels", configuredLevels),
-			logger.Int("total_configured", len(ts.chatIDs)))
+			logger.Int("total_configured", len(configuredLevels)))
 		err := fmt.Errorf("no chat ID configured for level %d", level)
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
-		return err
+		return nil, err
 	}
 
 	logger.Debug("Found chat ID for level", "telegram_service",
@@ -205,32 +237,115 @@ func (ts *TelegramService) SendMessage(ctx context.Context, level int, message s
 
 	span.SetAttributes(attribute.Int64("messaging.chat_id", chatID))
 
-	params := &bot.SendMessageParams{
-		ChatID:    chatID,
-		Text:      message,
-		ParseMode: models.ParseModeHTML, // 使用 HTML 格式支持連結
+	// forum 群組依 namespace 或等級發送到對應的 topic
+	destination := strconv.FormatInt(chatID, 10)
+	if topicID > 0 {
+		destination += ":" + strconv.Itoa(topicID)
+		span.SetAttributes(attribute.Int("messaging.telegram.topic_id", topicID))
 	}
 
+	refKey := msgref.Key("telegram", destination, alertData)
+	resolved := msgref.IsResolved(alertData)
+	ref, hasRef := msgref.Get(refKey)
+
+	// 超過 4096 字元的訊息切分為多則，每段標籤完整並標示 (part n/m)
+	chunks := telegramhtml.Split(message, telegramhtml.MaxMessageLength)
+
+	// resolved 通知優先編輯原本的 firing 訊息（僅限單則訊息），失敗時改為發送新訊息
+	if resolved && hasRef && len(chunks) == 1 && msgref.EditOnResolve() {
+		if edited, ok := ts.editReferencedMessage(ctx, refKey, ref, message); ok {
+			return edited, nil
+		}
+	}
+
+	var first *models.Message
+	for i, chunk := range chunks {
+		params := &bot.SendMessageParams{
+			ChatID:          chatID,
+			MessageThreadID: topicID,
+			Text:            chunk,
+			ParseMode:       models.ParseModeHTML, // 使用 HTML 格式支持連結
+		}
+		// 按鈕附加在最後一則
+		if opts != nil && opts.ReplyMarkup != nil && i == len(chunks)-1 {
+			params.ReplyMarkup = opts.ReplyMarkup
+		}
+
+		// resolved 通知以回覆方式接在同一警報群組的 firing 訊息之後，原訊息已刪除時仍正常發送
+		if resolved && hasRef && i == 0 {
+			if replyTo, convErr := strconv.Atoi(ref.MessageID); convErr == nil {
+				params.ReplyParameters = &models.ReplyParameters{
+					MessageID:                replyTo,
+					AllowSendingWithoutReply: true,
+				}
+			}
+		}
+
+		response, err := ts.sendChunk(ctx, level, chatID, params)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			if i > 0 {
+				return first, fmt.Errorf("failed to send part %d/%d: %w", i+1, len(chunks), err)
+			}
+			return nil, err
+		}
+		if first == nil {
+			first = response
+		}
+	}
+
+	logger.Info("Telegram message sent successfully", "telegram_service",
+		logger.Int("level", level),
+		logger.Int64("chat_id", chatID),
+		logger.Int("message_id", first.ID),
+		logger.Int("parts", len(chunks)),
+		logger.String("message_preview", getMessagePreview(message)))
+
+	// 記錄 firing 訊息的參照，供 resolved 時編輯或回覆；重送時保留第一則 firing 訊息並延長有效期；
+	// 切分為多則的訊息無法整則編輯，不記錄參照
+	switch {
+	case refKey == "":
+	case resolved:
+		msgref.Delete(refKey)
+	case hasRef:
+		msgref.Put(refKey, ref)
+	case len(chunks) == 1:
+		msgref.Put(refKey, msgref.Ref{
+			Provider:  "telegram",
+			ChatID:    strconv.FormatInt(chatID, 10),
+			MessageID: strconv.Itoa(first.ID),
+		})
+	}
+
+	return first, nil
+}
+
+// sendChunk 依 chat 的 token bucket 發送單則訊息，遇到 429 時遵守 retry_after
+func (ts *TelegramService) sendChunk(ctx context.Context, level int, chatID int64, params *bot.SendMessageParams) (*models.Message, error) {
 	// 在 debug 模式下記錄發送請求的詳細資訊
 	if config.IsDevelopment() || config.App.Mode == "debug" || config.Log.Level == "debug" {
 		logger.Debug("Sending Telegram message request", "telegram_service",
 			logger.Int("level", level),
 			logger.Int64("chat_id", chatID),
 			logger.String("parse_mode", string(models.ParseModeHTML)),
-			logger.String("message_length", fmt.Sprintf("%d", len(message))),
-			logger.String("message_preview", getMessagePreview(message)))
+			logger.String("message_length", fmt.Sprintf("%d", len(params.Text))),
+			logger.String("message_preview", getMessagePreview(params.Text)))
 	}
 
-	response, err := ts.bot.SendMessage(ctx, params)
+	var response *models.Message
+	err := ts.limiter.Do(ctx, strconv.FormatInt(chatID, 10), func() error {
+		var sendErr error
+		response, sendErr = ts.bot.SendMessage(ctx, params)
+		return sendErr
+	})
 	if err != nil {
 		logger.Error("Failed to send Telegram message", "telegram_service",
 			logger.Int("level", level),
 			logger.Int64("chat_id", chatID),
-			logger.String("message_preview", getMessagePreview(message)),
+			logger.String("message_preview", getMessagePreview(params.Text)),
 			logger.Err(err))
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		return err
+		return nil, err
 	}
 
 	// 在 debug 模式下記錄 Telegram server 的詳細回應
@@ -249,12 +364,101 @@ func (ts *TelegramService) SendMessage(ctx context.Context, level int, message s
 			logger.String("response_text_preview", getMessagePreview(response.Text)))
 	}
 
-	logger.Info("Telegram message sent successfully", "telegram_service",
-		logger.Int("level", level),
-		logger.Int64("chat_id", chatID),
-		logger.Int("message_id", response.ID),
-		logger.String("message_preview", getMessagePreview(message)))
+	return response, nil
+}
+
+// editReferencedMessage 以訊息參照編輯原本的訊息並移除按鈕；成功時刪除參照，編輯失敗時返回 false
+func (ts *TelegramService) editReferencedMessage(ctx context.Context, refKey string, ref msgref.Ref, message string) (*models.Message, bool) {
+	messageID, err := strconv.Atoi(ref.MessageID)
+	if err != nil {
+		msgref.Delete(refKey)
+		return nil, false
+	}
+
+	var edited *models.Message
+	err = ts.limiter.Do(ctx, ref.ChatID, func() error {
+		var editErr error
+		// 未指定 reply_markup 時 Telegram 會移除原本的 inline keyboard
+		edited, editErr = ts.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    ref.ChatID,
+			MessageID: messageID,
+			Text:      message,
+			ParseMode: models.ParseModeHTML,
+		})
+		return editErr
+	})
+	if err != nil {
+		logger.Warn("Failed to edit original Telegram message on resolve, sending a new message", "telegram_service",
+			logger.String("chat_id", ref.ChatID),
+			logger.Int("message_id", messageID),
+			logger.Err(err))
+		return nil, false
+	}
+
+	msgref.Delete(refKey)
+	logger.Info("Telegram message edited on resolve", "telegram_service",
+		logger.String("chat_id", ref.ChatID),
+		logger.Int("message_id", messageID))
+	return edited, true
+}
+
+// StartPolling 以 long polling 接收 callback query 並交由 handler 處理
+func (ts *TelegramService) StartPolling(handler bot.HandlerFunc, prefix string) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.bot == nil {
+		return fmt.Errorf("telegram service is in degraded mode - bot initialization failed")
+	}
+	if ts.stopUpdates != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
 
+	// getUpdates 與 webhook 互斥，先移除已註冊的 webhook
+	if _, err := ts.bot.DeleteWebhook(ctx, &bot.DeleteWebhookParams{}); err != nil {
+		cancel()
+		return fmt.Errorf("failed to delete webhook before polling: %v", err)
+	}
+
+	ts.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, prefix, bot.MatchTypePrefix, handler)
+	ts.stopUpdates = cancel
+	go ts.bot.Start(ctx)
+
+	logger.Info("Telegram long polling started", "telegram_service")
+	return nil
+}
+
+// StopPolling 停止 long polling
+func (ts *TelegramService) StopPolling() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.stopUpdates != nil {
+		ts.stopUpdates()
+		ts.stopUpdates = nil
+		logger.Info("Telegram long polling stopped", "telegram_service")
+	}
+}
+
+// SetWebhook 向 Telegram 註冊接收 callback query 的 webhook
+func (ts *TelegramService) SetWebhook(ctx context.Context, url, secret string) error {
+	if ts.bot == nil {
+		return fmt.Errorf("telegram service is in degraded mode - bot initialization failed")
+	}
+
+	_, err := ts.bot.SetWebhook(ctx, &bot.SetWebhookParams{
+		URL:            url,
+		SecretToken:    secret,
+		AllowedUpdates: []string{models.AllowedUpdateCallbackQuery},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set webhook: %v", err)
+	}
+
+	logger.Info("Telegram webhook registered", "telegram_service",
+		logger.String("url", url))
 	return nil
 }
 
@@ -286,6 +490,38 @@ func (ts *TelegramService) GetChatID(level int) (int64, bool) {
 	return chatID, exists
 }
 
+// topicFor 返回訊息應發送到的 forum topic：優先使用 namespace 對應的 topic，其次為等級的 topic，0 表示不指定
+func (ts *TelegramService) topicFor(level int, data *types.AlertManagerData) int {
+	if data != nil && len(config.Telegram.NamespaceTopics) > 0 {
+		if namespace, ok := data.CommonLabels["namespace"].(string); ok && namespace != "" {
+			// viper 會將 map 的鍵轉為小寫
+			if topicID := config.Telegram.NamespaceTopics[strings.ToLower(namespace)]; topicID > 0 {
+				return topicID
+			}
+		}
+	}
+	return ts.topics[level]
+}
+
+// ParseTelegramChatTarget 解析 chat_id 或 chat_id:topic_id 格式的聊天目標，topic_id 為 forum 群組的 message_thread_id
+func ParseTelegramChatTarget(value string) (int64, int, error) {
+	chatPart, topicPart, hasTopic := strings.Cut(strings.TrimSpace(value), ":")
+
+	chatID, err := strconv.ParseInt(strings.TrimSpace(chatPart), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid chat ID %q: %v", chatPart, err)
+	}
+	if !hasTopic {
+		return chatID, 0, nil
+	}
+
+	topicID, err := strconv.Atoi(strings.TrimSpace(topicPart))
+	if err != nil || topicID <= 0 {
+		return 0, 0, fmt.Errorf("invalid topic ID %q", topicPart)
+	}
+	return chatID, topicID, nil
+}
+
 // GetBot 獲取 bot 實例
 func (ts *TelegramService) GetBot() *bot.Bot {
 	return ts.bot
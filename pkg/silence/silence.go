@@ -0,0 +1,359 @@
+// Package silence 提供以標籤匹配器為基礎的本地靜音，靜音以 bbolt 持久化並快取於記憶體中供每則警報比對
+package silence
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/matcher"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	dbFileName = "silences.db"
+
+	defaultDataDir   = "./data"
+	defaultRetention = 120 * time.Hour
+)
+
+// 靜音狀態
+const (
+	StateActive  = "active"
+	StatePending = "pending"
+	StateExpired = "expired"
+)
+
+var silenceBucket = []byte("silences")
+
+var (
+	// ErrNotFound 靜音不存在
+	ErrNotFound = errors.New("silence not found")
+
+	// ErrInvalid 靜音內容無效
+	ErrInvalid = errors.New("invalid silence")
+)
+
+// Silence 單筆靜音
+type Silence struct {
+	ID        string    `json:"id"`
+	Matchers  []string  `json:"matchers"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	CreatedBy string    `json:"created_by"`
+	Comment   string    `json:"comment"`
+	CreatedAt time.Time `json:"created_at"`
+	State     string    `json:"state,omitempty"` // 查詢時依目前時間計算，不持久化
+
+	matchers matcher.Matchers
+}
+
+// StateAt 返回靜音在指定時間的狀態
+func (s *Silence) StateAt(t time.Time) string {
+	switch {
+	case t.Before(s.StartsAt):
+		return StatePending
+	case t.Before(s.EndsAt):
+		return StateActive
+	default:
+		return StateExpired
+	}
+}
+
+// Matches 檢查標籤是否被此靜音的所有匹配器命中
+func (s *Silence) Matches(labels map[string]string) bool {
+	return len(s.matchers) > 0 && s.matchers.Matches(labels)
+}
+
+// compile 解析並驗證靜音的匹配器
+func (s *Silence) compile() error {
+	if len(s.Matchers) == 0 {
+		return fmt.Errorf("%w: at least one matcher is required", ErrInvalid)
+	}
+	matchers, err := matcher.ParseAll(s.Matchers)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	// 與 Alertmanager 相同，所有匹配器都匹配空字串（例如 foo="" 或 foo=~".*"）的靜音會命中所有警報
+	if matchers.Matches(map[string]string{}) {
+		return fmt.Errorf("%w: at least one matcher must not match the empty string", ErrInvalid)
+	}
+	s.matchers = matchers
+	s.Matchers = matchers.Strings()
+	return nil
+}
+
+// snapshot 返回帶有目前狀態的副本
+func (s *Silence) snapshot(now time.Time) *Silence {
+	c := *s
+	c.Matchers = append([]string(nil), s.Matchers...)
+	c.State = s.StateAt(now)
+	return &c
+}
+
+// Store 持久化靜音存放區
+type Store struct {
+	db        *bolt.DB
+	retention time.Duration
+
+	mu       sync.RWMutex
+	silences map[string]*Silence
+}
+
+var (
+	instance   *Store
+	instanceMu sync.RWMutex
+)
+
+// Open 開啟（或建立）資料目錄下的靜音資料庫並載入所有靜音
+func Open(conf config.SilenceConf) (*Store, error) {
+	if conf.DataDir == "" {
+		conf.DataDir = defaultDataDir
+	}
+	if conf.Retention <= 0 {
+		conf.Retention = defaultRetention
+	}
+
+	if err := os.MkdirAll(conf.DataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create silence data dir: %v", err)
+	}
+
+	db, err := bolt.Open(filepath.Join(conf.DataDir, dbFileName), 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open silence database: %v", err)
+	}
+
+	store := &Store{
+		db:        db,
+		retention: conf.Retention,
+		silences:  make(map[string]*Silence),
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(silenceBucket)
+		if err != nil {
+			return err
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			s := &Silence{}
+			if err := json.Unmarshal(v, s); err != nil {
+				logger.Warn("Skipping undecodable silence", "silence",
+					logger.String("id", string(k)),
+					logger.Err(err))
+				return nil
+			}
+			if err := s.compile(); err != nil {
+				logger.Warn("Skipping invalid silence", "silence",
+					logger.String("id", string(k)),
+					logger.Err(err))
+				return nil
+			}
+			store.silences[s.ID] = s
+			return nil
+		})
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to load silences: %v", err)
+	}
+
+	store.gc(time.Now())
+
+	logger.Info("Silence store opened", "silence",
+		logger.String("data_dir", conf.DataDir),
+		logger.Int("silences", len(store.silences)))
+
+	return store, nil
+}
+
+// SetStore 設置全局靜音存放區
+func SetStore(s *Store) {
+	instanceMu.Lock()
+	defer instanceMu.Unlock()
+	instance = s
+}
+
+// GetStore 獲取全局靜音存放區，未啟用時返回 nil
+func GetStore() *Store {
+	instanceMu.RLock()
+	defer instanceMu.RUnlock()
+	return instance
+}
+
+// Close 關閉靜音資料庫
+func (st *Store) Close() error {
+	return st.db.Close()
+}
+
+// Create 驗證並新增靜音，StartsAt 為空時從現在開始
+func (st *Store) Create(s *Silence) (*Silence, error) {
+	now := time.Now()
+	if s.StartsAt.IsZero() {
+		s.StartsAt = now
+	}
+	if s.EndsAt.IsZero() {
+		return nil, fmt.Errorf("%w: ends_at is required", ErrInvalid)
+	}
+	if !s.EndsAt.After(s.StartsAt) {
+		return nil, fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalid)
+	}
+	if !s.EndsAt.After(now) {
+		return nil, fmt.Errorf("%w: ends_at must be in the future", ErrInvalid)
+	}
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+
+	s.ID = newID()
+	s.CreatedAt = now
+	s.State = ""
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode silence: %v", err)
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if err := st.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(silenceBucket).Put([]byte(s.ID), data)
+	}); err != nil {
+		return nil, err
+	}
+	st.silences[s.ID] = s
+	st.gcLocked(now)
+
+	logger.Info("Silence created", "silence",
+		logger.String("id", s.ID),
+		logger.Any("matchers", s.Matchers),
+		logger.String("starts_at", s.StartsAt.Format(time.RFC3339)),
+		logger.String("ends_at", s.EndsAt.Format(time.RFC3339)),
+		logger.String("created_by", s.CreatedBy))
+
+	return s.snapshot(now), nil
+}
+
+// List 依開始時間列出靜音，state 為空時返回全部
+func (st *Store) List(state string) []*Silence {
+	now := time.Now()
+
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	silences := make([]*Silence, 0, len(st.silences))
+	for _, s := range st.silences {
+		if state != "" && s.StateAt(now) != state {
+			continue
+		}
+		silences = append(silences, s.snapshot(now))
+	}
+	sort.Slice(silences, func(i, j int) bool {
+		return silences[i].StartsAt.Before(silences[j].StartsAt)
+	})
+	return silences
+}
+
+// Get 查詢單筆靜音
+func (st *Store) Get(id string) (*Silence, error) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	s, ok := st.silences[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return s.snapshot(time.Now()), nil
+}
+
+// Delete 刪除單筆靜音
+func (st *Store) Delete(id string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if _, ok := st.silences[id]; !ok {
+		return ErrNotFound
+	}
+	if err := st.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(silenceBucket).Delete([]byte(id))
+	}); err != nil {
+		return err
+	}
+	delete(st.silences, id)
+
+	logger.Info("Silence deleted", "silence", logger.String("id", id))
+	return nil
+}
+
+// Silenced 返回目前命中標籤的有效靜音 ID，未命中時返回 nil
+func (st *Store) Silenced(labels map[string]string) []string {
+	now := time.Now()
+
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	var ids []string
+	for _, s := range st.silences {
+		if s.StateAt(now) == StateActive && s.Matches(labels) {
+			ids = append(ids, s.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// gc 清除過期超過保留時間的靜音
+func (st *Store) gc(now time.Time) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.gcLocked(now)
+}
+
+// gcLocked 清除過期超過保留時間的靜音（需持有鎖）
+func (st *Store) gcLocked(now time.Time) {
+	var expired [][]byte
+	for id, s := range st.silences {
+		if now.Sub(s.EndsAt) > st.retention {
+			expired = append(expired, []byte(id))
+		}
+	}
+	if len(expired) == 0 {
+		return
+	}
+
+	if err := st.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(silenceBucket)
+		for _, id := range expired {
+			if err := bucket.Delete(id); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		logger.Warn("Failed to purge expired silences", "silence", logger.Err(err))
+		return
+	}
+	for _, id := range expired {
+		delete(st.silences, string(id))
+	}
+
+	logger.Info("Expired silences purged", "silence", logger.Int("count", len(expired)))
+}
+
+// newID 產生隨機靜音 ID
+func newID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%016x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,69 @@
+package silence
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"alert-webhooks/config"
+)
+
+func TestCreateRejectsSilencesMatchingEverything(t *testing.T) {
+	store, err := Open(config.SilenceConf{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer store.Close()
+
+	tests := []struct {
+		matchers []string
+		valid    bool
+	}{
+		{nil, false},
+		{[]string{`team=""`}, false},
+		{[]string{`team=~".*"`}, false},
+		{[]string{`team!="payments"`}, false},
+		{[]string{`team!~".+"`, `env=~".*"`}, false},
+		{[]string{`team="payments"`}, true},
+		{[]string{`team=~".+"`}, true},
+		{[]string{`team=~".*"`, `severity="critical"`}, true},
+		{[]string{`team!=""`}, true},
+	}
+
+	for _, tt := range tests {
+		_, err := store.Create(&Silence{Matchers: tt.matchers, EndsAt: time.Now().Add(time.Hour)})
+		switch {
+		case tt.valid && err != nil:
+			t.Errorf("Create(%q) returned error: %v", tt.matchers, err)
+		case !tt.valid && !errors.Is(err, ErrInvalid):
+			t.Errorf("Create(%q) error = %v, want ErrInvalid", tt.matchers, err)
+		}
+	}
+}
+
+func TestSilenced(t *testing.T) {
+	store, err := Open(config.SilenceConf{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer store.Close()
+
+	active, err := store.Create(&Silence{Matchers: []string{`alertname="HighCPU"`, `env=~"dev|staging"`}, EndsAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if _, err := store.Create(&Silence{Matchers: []string{`alertname="DiskFull"`}, StartsAt: time.Now().Add(time.Hour), EndsAt: time.Now().Add(2 * time.Hour)}); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+
+	if ids := store.Silenced(map[string]string{"alertname": "HighCPU", "env": "dev"}); len(ids) != 1 || ids[0] != active.ID {
+		t.Errorf("Silenced(HighCPU, dev) = %v, want [%s]", ids, active.ID)
+	}
+	if ids := store.Silenced(map[string]string{"alertname": "HighCPU", "env": "prod"}); len(ids) != 0 {
+		t.Errorf("Silenced(HighCPU, prod) = %v, want none", ids)
+	}
+	// 尚未開始的靜音不生效
+	if ids := store.Silenced(map[string]string{"alertname": "DiskFull"}); len(ids) != 0 {
+		t.Errorf("Silenced(DiskFull) = %v, want none for a pending silence", ids)
+	}
+}
@@ -0,0 +1,312 @@
+// Package telegramhtml 處理 Telegram HTML parse mode：轉義使用者資料，
+// 以及將超過長度上限的訊息切分為標籤完整（不會截斷未閉合標籤）的多則訊息
+package telegramhtml
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxMessageLength Telegram 單則訊息的字元上限
+const MaxMessageLength = 4096
+
+// partMarkerReserve 每段保留給 "(part n/m)" 標記的字元數
+const partMarkerReserve = 20
+
+var escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Escape 轉義 Telegram HTML 中具有特殊意義的 &、< 與 >
+func Escape(text string) string {
+	return escaper.Replace(text)
+}
+
+// EscapeAttr 轉義 HTML 屬性值（例如 href），額外轉義雙引號
+func EscapeAttr(text string) string {
+	return strings.ReplaceAll(Escape(text), "\"", "&quot;")
+}
+
+// Split 將 HTML 訊息切分為每段不超過 limit 個字元的片段，並在多段時於每段末尾附加 "(part n/m)"。
+// 優先在換行處切分；切分點仍有未閉合的標籤時，在該段末尾補上閉合標籤並於下一段重新開啟，
+// 標籤與 HTML 實體不會被截斷。limit 小於等於 0 時使用 MaxMessageLength
+func Split(text string, limit int) []string {
+	if limit <= 0 {
+		limit = MaxMessageLength
+	}
+	if utf8.RuneCountInString(text) <= limit {
+		return []string{text}
+	}
+
+	budget := limit - partMarkerReserve
+	if budget < 1 {
+		budget = 1
+	}
+
+	s := &splitter{budget: budget}
+	lines := strings.SplitAfter(text, "\n")
+	for _, line := range lines {
+		s.addLine(line)
+	}
+	s.flush()
+
+	chunks := s.chunks
+	if len(chunks) > 1 {
+		for i := range chunks {
+			chunks[i] += fmt.Sprintf("\n\n(part %d/%d)", i+1, len(chunks))
+		}
+	}
+	return chunks
+}
+
+// openTag 尚未閉合的標籤
+type openTag struct {
+	name string
+	raw  string // 原始開啟標籤（含屬性），用於在下一段重新開啟
+}
+
+// splitter 依序累積片段並追蹤未閉合的標籤
+type splitter struct {
+	budget    int
+	chunks    []string
+	cur       strings.Builder
+	curLen    int
+	prefixLen int // 段首重新開啟標籤佔用的字元數
+	open      []openTag
+}
+
+// addLine 加入一行；整行放得下時直接加入，新段放得下時先切到新段，
+// 單行超過上限時填滿目前的段並逐個片段切分；單一標籤在新段也放不下時捨棄該標籤，只保留文字
+func (s *splitter) addLine(line string) {
+	atoms := tokenize(line)
+	if s.fits(atoms) {
+		s.appendAtoms(atoms)
+		return
+	}
+	if s.curLen > s.prefixLen && s.prefixLen+utf8.RuneCountInString(line) <= s.budget {
+		s.flush()
+		if s.fits(atoms) {
+			s.appendAtoms(atoms)
+			return
+		}
+	}
+	for _, atom := range atoms {
+		size := utf8.RuneCountInString(atom)
+		if s.curLen > s.prefixLen && s.curLen+size+closingLen(applyTag(s.open, atom)) > s.budget {
+			s.flush()
+		}
+		if isTag(atom) && s.curLen+size+closingLen(applyTag(s.open, atom)) > s.budget {
+			continue
+		}
+		s.appendAtoms([]string{atom})
+	}
+}
+
+// fits 檢查加入 atoms 並補上閉合標籤後是否仍在 budget 內
+func (s *splitter) fits(atoms []string) bool {
+	n := s.curLen
+	open := s.open
+	for _, atom := range atoms {
+		n += utf8.RuneCountInString(atom)
+		open = applyTag(open, atom)
+	}
+	return n+closingLen(open) <= s.budget
+}
+
+// appendAtoms 加入片段；沒有對應開啟標籤的閉合標籤（開啟標籤已被捨棄）不輸出
+func (s *splitter) appendAtoms(atoms []string) {
+	for _, atom := range atoms {
+		if isTag(atom) && atom[1] == '/' && !isOpen(s.open, tagName(atom[2:len(atom)-1])) {
+			continue
+		}
+		s.cur.WriteString(atom)
+		s.curLen += utf8.RuneCountInString(atom)
+		s.open = applyTag(s.open, atom)
+	}
+}
+
+// flush 以閉合標籤結束目前的段，並在新段開頭重新開啟仍未閉合的標籤
+func (s *splitter) flush() {
+	if s.curLen <= s.prefixLen {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(s.cur.String(), "\n"))
+	for i := len(s.open) - 1; i >= 0; i-- {
+		b.WriteString("</" + s.open[i].name + ">")
+	}
+	s.chunks = append(s.chunks, b.String())
+
+	s.cur.Reset()
+	s.curLen = 0
+	for _, tag := range s.open {
+		s.cur.WriteString(tag.raw)
+		s.curLen += utf8.RuneCountInString(tag.raw)
+	}
+	// 重新開啟的標籤本身超過 budget 時放棄保留格式
+	if s.curLen >= s.budget {
+		s.cur.Reset()
+		s.curLen = 0
+		s.open = nil
+	}
+	s.prefixLen = s.curLen
+}
+
+// tokenize 將文字拆為不可切分的片段：完整標籤、HTML 實體或單一字元
+func tokenize(text string) []string {
+	var atoms []string
+	for i := 0; i < len(text); {
+		switch text[i] {
+		case '<':
+			if end := strings.IndexByte(text[i:], '>'); end > 0 {
+				atoms = append(atoms, text[i:i+end+1])
+				i += end + 1
+				continue
+			}
+		case '&':
+			if end := strings.IndexByte(text[i:], ';'); end > 1 && end <= 10 && isEntityName(text[i+1:i+end]) {
+				atoms = append(atoms, text[i:i+end+1])
+				i += end + 1
+				continue
+			}
+		}
+		_, size := utf8.DecodeRuneInString(text[i:])
+		atoms = append(atoms, text[i:i+size])
+		i += size
+	}
+	return atoms
+}
+
+// isEntityName 檢查是否為實體名稱（例如 amp、#39、#x27）
+func isEntityName(name string) bool {
+	for _, r := range name {
+		if !(r == '#' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// isTag 檢查 atom 是否為標籤
+func isTag(atom string) bool {
+	return len(atom) >= 3 && atom[0] == '<' && atom[len(atom)-1] == '>'
+}
+
+// isOpen 檢查標籤是否尚未閉合
+func isOpen(open []openTag, name string) bool {
+	for _, tag := range open {
+		if tag.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// applyTag 返回套用 atom 後的未閉合標籤堆疊；非標籤的 atom 不影響堆疊
+func applyTag(open []openTag, atom string) []openTag {
+	if !isTag(atom) {
+		return open
+	}
+
+	closing := atom[1] == '/'
+	name := tagName(strings.TrimPrefix(atom[1:len(atom)-1], "/"))
+	if name == "" {
+		return open
+	}
+
+	if !closing {
+		result := make([]openTag, len(open), len(open)+1)
+		copy(result, open)
+		return append(result, openTag{name: name, raw: atom})
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		if open[i].name == name {
+			result := make([]openTag, i)
+			copy(result, open[:i])
+			return result
+		}
+	}
+	return open
+}
+
+// tagName 取得標籤名稱（轉為小寫）
+func tagName(body string) string {
+	end := 0
+	for end < len(body) {
+		c := body[end]
+		if !(c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			break
+		}
+		end++
+	}
+	return strings.ToLower(body[:end])
+}
+
+// closingLen 閉合所有未閉合標籤所需的字元數
+func closingLen(open []openTag) int {
+	n := 0
+	for _, tag := range open {
+		n += len(tag.name) + 3
+	}
+	return n
+}
+
+// supportedTags Telegram HTML parse mode 支援的標籤
+var supportedTags = map[string]bool{
+	"b": true, "strong": true, "i": true, "em": true, "u": true, "ins": true,
+	"s": true, "strike": true, "del": true, "span": true, "tg-spoiler": true,
+	"a": true, "tg-emoji": true, "code": true, "pre": true, "blockquote": true,
+}
+
+// supportedEntities Telegram 支援的具名 HTML 實體，數字實體（&#39;、&#x27;）一律支援
+var supportedEntities = map[string]bool{"lt": true, "gt": true, "amp": true, "quot": true}
+
+// Validate 檢查訊息是否為 Telegram 可接受的 HTML：只使用支援的標籤且正確巢狀閉合，
+// 不屬於標籤或實體的 <、> 與 & 必須轉義。返回的錯誤包含行號
+func Validate(text string) error {
+	var open []string
+	line := 1
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\n':
+			line++
+		case '<':
+			end := strings.IndexByte(text[i:], '>')
+			if end < 0 {
+				return fmt.Errorf("line %d: unescaped '<'", line)
+			}
+			body := text[i+1 : i+end]
+			name := tagName(strings.TrimPrefix(body, "/"))
+			switch {
+			case name == "":
+				return fmt.Errorf("line %d: unescaped '<'", line)
+			case !supportedTags[name]:
+				return fmt.Errorf("line %d: unsupported tag <%s>", line, name)
+			case strings.HasPrefix(body, "/"):
+				if len(open) == 0 || open[len(open)-1] != name {
+					return fmt.Errorf("line %d: unexpected closing tag </%s>", line, name)
+				}
+				open = open[:len(open)-1]
+			default:
+				open = append(open, name)
+			}
+			line += strings.Count(body, "\n")
+			i += end
+		case '>':
+			return fmt.Errorf("line %d: unescaped '>'", line)
+		case '&':
+			end := strings.IndexByte(text[i:], ';')
+			if end <= 1 || end > 10 || !isEntityName(text[i+1:i+end]) {
+				return fmt.Errorf("line %d: unescaped '&'", line)
+			}
+			if name := text[i+1 : i+end]; name[0] != '#' && !supportedEntities[name] {
+				return fmt.Errorf("line %d: unsupported entity &%s;", line, name)
+			}
+			i += end
+		}
+	}
+	if len(open) > 0 {
+		return fmt.Errorf("unclosed tag <%s>", open[len(open)-1])
+	}
+	return nil
+}
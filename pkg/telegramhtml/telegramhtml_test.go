@@ -0,0 +1,194 @@
+package telegramhtml
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		attr  string
+	}{
+		{"plain text", "plain text", "plain text"},
+		{"a < b && c > d", "a &lt; b &amp;&amp; c &gt; d", "a &lt; b &amp;&amp; c &gt; d"},
+		{"<b>not bold</b>", "&lt;b&gt;not bold&lt;/b&gt;", "&lt;b&gt;not bold&lt;/b&gt;"},
+		{"&amp; stays literal", "&amp;amp; stays literal", "&amp;amp; stays literal"},
+		{`say "hi"`, `say "hi"`, "say &quot;hi&quot;"},
+		{"https://example.com/?a=1&b=2", "https://example.com/?a=1&amp;b=2", "https://example.com/?a=1&amp;b=2"},
+	}
+
+	for _, tt := range tests {
+		if got := Escape(tt.input); got != tt.want {
+			t.Errorf("Escape(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+		if got := EscapeAttr(tt.input); got != tt.attr {
+			t.Errorf("EscapeAttr(%q) = %q, want %q", tt.input, got, tt.attr)
+		}
+		if err := Validate(Escape(tt.input)); err != nil {
+			t.Errorf("Validate(Escape(%q)) returned error: %v", tt.input, err)
+		}
+	}
+}
+
+// checkChunks 檢查每段都不超過 limit，且本身是合法的 Telegram HTML
+func checkChunks(t *testing.T, name string, chunks []string, limit int) {
+	t.Helper()
+	for i, chunk := range chunks {
+		if n := utf8.RuneCountInString(chunk); n > limit {
+			t.Errorf("%s: chunk %d has %d characters, want at most %d", name, i+1, n, limit)
+		}
+		if err := Validate(chunk); err != nil {
+			t.Errorf("%s: chunk %d is not valid HTML: %v\n%s", name, i+1, err, chunk)
+		}
+	}
+}
+
+// stripMarkers 移除 "(part n/m)" 標記
+func stripMarkers(chunks []string) []string {
+	stripped := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		stripped[i] = strings.TrimSuffix(chunk, fmt.Sprintf("\n\n(part %d/%d)", i+1, len(chunks)))
+	}
+	return stripped
+}
+
+func TestSplitShortMessage(t *testing.T) {
+	text := "<b>Alert</b>\nall good"
+	chunks := Split(text, 0)
+	if len(chunks) != 1 || chunks[0] != text {
+		t.Errorf("Split(%q) = %q, want the message unchanged", text, chunks)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		parts int
+		// contains 每段去掉標記後的內容須依序包含的字串
+		contains []string
+	}{
+		{
+			name:     "prefers line breaks",
+			text:     strings.Repeat("a", 30) + "\n" + strings.Repeat("b", 30) + "\n" + strings.Repeat("c", 30),
+			limit:    60,
+			parts:    3,
+			contains: []string{strings.Repeat("a", 30), strings.Repeat("b", 30), strings.Repeat("c", 30)},
+		},
+		{
+			name:     "nested tags across a split boundary",
+			text:     "<b>bold <i>" + strings.Repeat("x", 60) + "</i> tail</b>",
+			limit:    60,
+			parts:    3,
+			contains: []string{"<b>bold <i>xx", "<b><i>xx", "</i> tail</b>"},
+		},
+		{
+			name:     "link reopened with its attributes",
+			text:     `<a href="https://example.com/?a=1&amp;b=2">` + strings.Repeat("y", 200) + "</a>",
+			limit:    150,
+			parts:    3,
+			contains: []string{`<a href="https://example.com/?a=1&amp;b=2">yy`, `<a href="https://example.com/?a=1&amp;b=2">yy`, `<a href="https://example.com/?a=1&amp;b=2">yy`},
+		},
+		{
+			name:     "tag longer than the limit is dropped",
+			text:     "before\n" + `<a href="https://example.com/` + strings.Repeat("p", 80) + `">link</a>` + "\nafter",
+			limit:    50,
+			parts:    2,
+			contains: []string{"before", "link\nafter"},
+		},
+	}
+
+	for _, tt := range tests {
+		chunks := Split(tt.text, tt.limit)
+		checkChunks(t, tt.name, chunks, tt.limit)
+		if len(chunks) != tt.parts {
+			t.Errorf("%s: Split returned %d chunks, want %d\n%q", tt.name, len(chunks), tt.parts, chunks)
+			continue
+		}
+		for i, chunk := range stripMarkers(chunks) {
+			if i < len(tt.contains) && !strings.Contains(chunk, tt.contains[i]) {
+				t.Errorf("%s: chunk %d = %q, want it to contain %q", tt.name, i+1, chunk, tt.contains[i])
+			}
+		}
+	}
+}
+
+func TestSplitKeepsEntitiesAtLimit(t *testing.T) {
+	// 讓實體落在 MaxMessageLength 的切分點附近（扣除 part 標記保留的字元），每個位置都不應被截斷
+	for offset := 0; offset < 12; offset++ {
+		text := strings.Repeat("x", MaxMessageLength-partMarkerReserve-offset) + strings.Repeat("&amp;&lt;&#39;", 4) + strings.Repeat("y", 100)
+		chunks := Split(text, 0)
+		name := fmt.Sprintf("offset %d", offset)
+		checkChunks(t, name, chunks, MaxMessageLength)
+		if len(chunks) != 2 {
+			t.Errorf("%s: Split returned %d chunks, want 2", name, len(chunks))
+			continue
+		}
+		if joined := strings.Join(stripMarkers(chunks), ""); joined != text {
+			t.Errorf("%s: joined chunks differ from the original message", name)
+		}
+	}
+}
+
+func TestSplitSingleTokenLongerThanLimit(t *testing.T) {
+	// 沒有換行的長字串只能逐字切分
+	text := "<code>" + strings.Repeat("z", 9000) + "</code>"
+	chunks := Split(text, 0)
+	checkChunks(t, "long code block", chunks, MaxMessageLength)
+	if len(chunks) != 3 {
+		t.Fatalf("Split returned %d chunks, want 3", len(chunks))
+	}
+	var z int
+	for _, chunk := range stripMarkers(chunks) {
+		if !strings.HasPrefix(chunk, "<code>") || !strings.HasSuffix(chunk, "</code>") {
+			t.Errorf("chunk %.20q... does not keep the <code> tag", chunk)
+		}
+		z += strings.Count(chunk, "z")
+	}
+	if z != 9000 {
+		t.Errorf("chunks contain %d characters of text, want 9000", z)
+	}
+
+	// 標籤本身超過上限時捨棄標籤與其閉合標籤，只保留文字
+	tag := `<a href="https://example.com/` + strings.Repeat("q", MaxMessageLength) + `">`
+	chunks = Split("see "+tag+"runbook</a> now", 0)
+	checkChunks(t, "oversized tag", chunks, MaxMessageLength)
+	if joined := strings.Join(stripMarkers(chunks), ""); joined != "see runbook now" {
+		t.Errorf("Split with an oversized tag = %q, want %q", joined, "see runbook now")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"<b>ok</b> &amp; <i>fine</i>", ""},
+		{`<a href="https://example.com">x</a>`, ""},
+		{"1 &#60; 2", ""},
+		{"a < b", "line 1: unescaped '<'"},
+		{"a > b", "line 1: unescaped '>'"},
+		{"fish & chips", "line 1: unescaped '&'"},
+		{"&nbsp;", "unsupported entity &nbsp;"},
+		{"line\n<div>x</div>", "line 2: unsupported tag <div>"},
+		{"<b><i>x</b></i>", "unexpected closing tag </b>"},
+		{"<b>x", "unclosed tag <b>"},
+	}
+
+	for _, tt := range tests {
+		err := Validate(tt.input)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("Validate(%q) returned error: %v", tt.input, err)
+		case tt.err != "" && err == nil:
+			t.Errorf("Validate(%q) returned no error, want %q", tt.input, tt.err)
+		case tt.err != "" && !strings.Contains(err.Error(), tt.err):
+			t.Errorf("Validate(%q) error = %q, want it to contain %q", tt.input, err, tt.err)
+		}
+	}
+}
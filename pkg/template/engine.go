@@ -5,11 +5,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
-	"time"
 
 	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/telegramhtml"
 
 	"gopkg.in/yaml.v3"
 )
@@ -73,9 +74,20 @@ type FormatOptions struct {
 // TemplateEngine 模板引擎
 type TemplateEngine struct {
 	templates map[string]*template.Template
+	sets      map[string]map[string]*template.Template // 具名模板集：名稱 -> 語言 -> 模板
 	config    *TemplateConfig
+	dir       string // 最近一次載入的模板目錄，供模板驗證使用
+	partials  *template.Template // partials 目錄的共用區塊，解析每個模板時複製後加入
+	catalogs  map[string]catalog // i18n 目錄的翻譯：語言 -> 翻譯目錄
 }
 
+// DefaultTemplateSet 預設模板集名稱，對應模板目錄根目錄中的模板
+const DefaultTemplateSet = "default"
+
+// sharedTemplate 所有語言共用的模板（alert_template.tmpl 或 alert_template.j2）在模板集中的 key；
+// 文字由翻譯目錄提供，alert_template_{lang} 檔案只用於覆寫單一語言
+const sharedTemplate = "*"
+
 // TemplateData 模板數據結構
 type TemplateData struct {
 	Status        string
@@ -87,9 +99,12 @@ type TemplateData struct {
 	FiringCount   int
 	ResolvedCount int
 	Alerts        []AlertData
+	CommonLabels  map[string]string // 群組內所有警報共同的標籤
 	ExternalURL   string
 	FormatOptions FormatOptions
 	Platform      string // 目標平台：telegram, slack
+	Language      string // 請求的語言，t / tn 以此語言翻譯；沒有該語言的模板時仍使用回退語言的模板結構
+	Level         string // 通知的 level（L0-L6），format_time 依此選擇 time_format.levels 的時區與 layout；空值不套用 level 設定
 }
 
 // AlertData 警報數據結構
@@ -106,6 +121,7 @@ type AlertData struct {
 func NewTemplateEngine() *TemplateEngine {
 	return &TemplateEngine{
 		templates: make(map[string]*template.Template),
+		sets:      make(map[string]map[string]*template.Template),
 		config:    getDefaultConfig(),
 	}
 }
@@ -372,6 +388,11 @@ func (te *TemplateEngine) LoadTemplates(templateDir string) error {
 		return fmt.Errorf("no template files found in directory: %s", templateDir)
 	}
 	
+	// 先載入共用區塊，模板解析時需要；.j2 的 include 也可以從模板目錄根目錄讀取
+	te.dir = templateDir
+	te.loadPartials(templateDir)
+	te.loadCatalogs(templateDir)
+
 	// 載入找到的模板檔案
 	loadedCount := 0
 	for language, templatePath := range templateFiles {
@@ -398,9 +419,63 @@ func (te *TemplateEngine) LoadTemplates(templateDir string) error {
 		logger.Int("loaded_count", loadedCount),
 		logger.Int("total_found", len(templateFiles)))
 
+	te.loadTemplateSets(templateDir)
+
 	return nil
 }
 
+// loadTemplateSets 將模板目錄下的每個子目錄載入為具名模板集（例如 templates/alerts/kubernetes_pod），
+// 子目錄內的檔案命名規則與根目錄相同；沒有可用模板的子目錄會被略過
+func (te *TemplateEngine) loadTemplateSets(templateDir string) {
+	sets := make(map[string]map[string]*template.Template)
+
+	entries, err := os.ReadDir(templateDir)
+	if err != nil {
+		logger.Warn("Failed to scan template sets", "template_engine",
+			logger.String("template_dir", templateDir),
+			logger.Err(err))
+		te.sets = sets
+		return
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || strings.HasPrefix(name, ".") || name == DefaultTemplateSet || name == PartialsDir || name == I18nDir {
+			continue
+		}
+
+		setDir := filepath.Join(templateDir, name)
+		templateFiles, err := te.scanTemplateFiles(setDir)
+		if err != nil || len(templateFiles) == 0 {
+			continue
+		}
+
+		set := make(map[string]*template.Template)
+		for language, templatePath := range templateFiles {
+			tmpl, err := te.loadTemplate(templatePath)
+			if err != nil {
+				logger.Warn("Failed to load named template", "template_engine",
+					logger.String("template_set", name),
+					logger.String("language", language),
+					logger.String("path", templatePath),
+					logger.Err(err))
+				continue
+			}
+			set[language] = tmpl
+		}
+		if len(set) == 0 {
+			continue
+		}
+
+		sets[name] = set
+		logger.Info("Template set loaded", "template_engine",
+			logger.String("template_set", name),
+			logger.Int("languages", len(set)))
+	}
+
+	te.sets = sets
+}
+
 // scanTemplateFiles 掃描模板目錄，找到所有語系的模板檔案
 func (te *TemplateEngine) scanTemplateFiles(templateDir string) (map[string]string, error) {
 	templateFiles := make(map[string]string)
@@ -423,11 +498,6 @@ func (te *TemplateEngine) scanTemplateFiles(templateDir string) (map[string]stri
 		
 		fileName := file.Name()
 		
-		// 檢查是否是模板檔案
-		if !strings.HasPrefix(fileName, templatePrefix) {
-			continue
-		}
-		
 		// 檢查副檔名
 		var isSupported bool
 		var ext string
@@ -444,9 +514,15 @@ func (te *TemplateEngine) scanTemplateFiles(templateDir string) (map[string]stri
 		}
 		
 		// 提取語言代碼
-		// 檔案名格式: alert_template_{language}.{ext}
-		baseName := strings.TrimPrefix(fileName, templatePrefix)
-		language := strings.TrimSuffix(baseName, ext)
+		// 檔案名格式: alert_template_{language}.{ext}；alert_template.{ext} 為所有語言共用的模板
+		var language string
+		if fileName == strings.TrimSuffix(templatePrefix, "_")+ext {
+			language = sharedTemplate
+		} else if strings.HasPrefix(fileName, templatePrefix) {
+			language = strings.TrimSuffix(strings.TrimPrefix(fileName, templatePrefix), ext)
+		} else {
+			continue
+		}
 		
 		if language == "" {
 			logger.Warn("Invalid template file name format", "template_engine",
@@ -514,9 +590,12 @@ func (te *TemplateEngine) loadTemplate(templatePath string) (*template.Template,
 		logger.Info("Using direct Go template", "template_engine",
 			logger.String("template_path", templatePath))
 	} else {
-		// 將 Jinja2 語法轉換為 Go template 語法
-		goTemplateContent = te.convertJinja2ToGoTemplate(string(content))
-		logger.Info("Using converted Jinja2 template", "template_engine",
+		// 將 Jinja2 語法編譯為 Go template 語法，include 相對於模板所在目錄，其次為模板目錄根目錄
+		goTemplateContent, err = te.compileJinja2(filepath.Base(templatePath), string(content), includeLoader(filepath.Dir(templatePath), te.dir))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %v", templatePath, err)
+		}
+		logger.Info("Using compiled Jinja2 template", "template_engine",
 			logger.String("template_path", templatePath))
 	}
 	
@@ -533,18 +612,7 @@ func (te *TemplateEngine) loadTemplate(templatePath string) (*template.Template,
 			logger.String("preview", strings.Join(lines[:5], "\n")))
 	}
 
-	tmpl, err := template.New(filepath.Base(templatePath)).Funcs(template.FuncMap{
-		"format_time": te.formatTimeForPlatform,
-		"format_time_simple": te.formatTime,
-		"add": func(a, b int) int { return a + b },
-		"index": func(m map[string]string, key string) string { return m[key] },
-		"format_text": te.formatTextForPlatform,
-		"format_bold": te.formatBoldForPlatform,
-		"format_italic": te.formatItalicForPlatform,
-		"format_code": te.formatCodeForPlatform,
-		"format_link": te.formatLinkForPlatform,
-		"printf": fmt.Sprintf,
-	}).Parse(goTemplateContent)
+	tmpl, err := te.parse(filepath.Base(templatePath), goTemplateContent)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse template %s: %v", templatePath, err)
 	}
@@ -552,64 +620,56 @@ func (te *TemplateEngine) loadTemplate(templatePath string) (*template.Template,
 	return tmpl, nil
 }
 
-// convertJinja2ToGoTemplate 將 Jinja2 語法轉換為 Go template 語法
-func (te *TemplateEngine) convertJinja2ToGoTemplate(content string) string {
-	// 簡化的轉換邏輯
-	result := content
-	
-	// 處理變數引用（先處理，避免與控制結構衝突）
-	result = strings.ReplaceAll(result, "{{ status }}", "{{ .Status }}")
-	result = strings.ReplaceAll(result, "{{ alert_name }}", "{{ .AlertName }}")
-	result = strings.ReplaceAll(result, "{{ env }}", "{{ .Env }}")
-	result = strings.ReplaceAll(result, "{{ severity }}", "{{ .Severity }}")
-	result = strings.ReplaceAll(result, "{{ namespace }}", "{{ .Namespace }}")
-	result = strings.ReplaceAll(result, "{{ total_alerts }}", "{{ .TotalAlerts }}")
-	result = strings.ReplaceAll(result, "{{ firing_count }}", "{{ .FiringCount }}")
-	result = strings.ReplaceAll(result, "{{ resolved_count }}", "{{ .ResolvedCount }}")
-	result = strings.ReplaceAll(result, "{{ externalURL }}", "{{ .ExternalURL }}")
-	
-	// 處理簡單的 if 語句
-	result = strings.ReplaceAll(result, "{% if firing_count > 0 %}", "{{ if gt .FiringCount 0 }}")
-	result = strings.ReplaceAll(result, "{% if resolved_count > 0 %}", "{{ if gt .ResolvedCount 0 }}")
-	result = strings.ReplaceAll(result, "{% elif resolved_count > 0 %}", "{{ else if gt .ResolvedCount 0 }}")
-	result = strings.ReplaceAll(result, "{% else %}", "{{ else }}")
-	result = strings.ReplaceAll(result, "{% endif %}", "{{ end }}")
-	
-	// 處理 for 迴圈
-	result = strings.ReplaceAll(result, "{% for alert in alerts %}", "{{ range $index, $alert := .Alerts }}")
-	result = strings.ReplaceAll(result, "{% endfor %}", "{{ end }}")
-	
-	// 處理迴圈內的變數
-	result = strings.ReplaceAll(result, "{{ loop.index }}", "{{ add $index 1 }}")
-	result = strings.ReplaceAll(result, "alert.status", "$alert.Status")
-	result = strings.ReplaceAll(result, "alert.annotations.summary", "index $alert.Annotations \"summary\"")
-	result = strings.ReplaceAll(result, "alert.annotations.description", "index $alert.Annotations \"description\"")
-	result = strings.ReplaceAll(result, "alert.labels.pod", "index $alert.Labels \"pod\"")
-	result = strings.ReplaceAll(result, "alert.startsAt", "$alert.StartsAt")
-	result = strings.ReplaceAll(result, "alert.endsAt", "$alert.EndsAt")
-	result = strings.ReplaceAll(result, "alert.generatorURL", "$alert.GeneratorURL")
-	
-	// 處理條件語句
-	result = strings.ReplaceAll(result, "{% if alert.status == \"firing\" %}", "{{ if eq $alert.Status \"firing\" }}")
-	result = strings.ReplaceAll(result, "{% if alert.status == \"resolved\" %}", "{{ if eq $alert.Status \"resolved\" }}")
-	result = strings.ReplaceAll(result, "{% if alert.endsAt != \"0001-01-01T00:00:00Z\" %}", "{{ if ne $alert.EndsAt \"0001-01-01T00:00:00Z\" }}")
-	result = strings.ReplaceAll(result, "{% if alert.generatorURL %}", "{{ if $alert.GeneratorURL }}")
-	result = strings.ReplaceAll(result, "{% if externalURL %}", "{{ if .ExternalURL }}")
-	
-	// 處理函數調用
-	result = strings.ReplaceAll(result, "format_time(alert.startsAt)", "format_time $alert.StartsAt")
-	result = strings.ReplaceAll(result, "format_time(alert.endsAt)", "format_time $alert.EndsAt")
-
-	return result
+// parse 以模板函數庫解析 Go template 內容；有共用區塊時加入共用區塊的副本，模板內同名的 define 會覆寫共用區塊
+func (te *TemplateEngine) parse(name, content string) (*template.Template, error) {
+	if te.partials == nil {
+		return template.New(name).Funcs(te.funcMap()).Parse(content)
+	}
+	set, err := te.partials.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return set.New(name).Parse(content)
 }
 
 // RenderTemplate 渲染模板
 func (te *TemplateEngine) RenderTemplate(language string, data TemplateData) (string, error) {
-	tmpl, exists := te.templates[language]
+	return te.renderTemplate("", language, data)
+}
+
+// renderTemplate 以指定模板集渲染模板；沒有該語言的覆寫檔案時使用共用模板，
+// 也沒有共用模板時具名模板集在模板集內套用語言回退
+func (te *TemplateEngine) renderTemplate(name, language string, data TemplateData) (string, error) {
+	return te.renderBlock(name, "", language, data)
+}
+
+// renderBlock 以 renderTemplate 相同的方式選擇模板，block 不為空時只執行模板中該名稱的區塊
+func (te *TemplateEngine) renderBlock(name, block, language string, data TemplateData) (string, error) {
+	templates := te.templateSet(name)
+	tmpl, exists := templates[language]
+	if !exists {
+		tmpl, exists = templates[sharedTemplate]
+	}
+	if !exists && (!isDefaultSet(name) || te.hasCatalog(language)) {
+		// 有翻譯目錄的語言使用回退語言的模板結構，文字由 t / tn 翻譯
+		tmpl, exists = templates[te.languageIn(templates, language)]
+	}
 	if !exists {
 		return "", fmt.Errorf("template for language '%s' not found", language)
 	}
 
+	data.Language = language
+	return te.executeLocalized(tmpl, block, data, nil)
+}
+
+// execute 執行已解析的模板
+func (te *TemplateEngine) execute(tmpl *template.Template, data TemplateData) (string, error) {
+	return te.executeLocalized(tmpl, "", data, nil)
+}
+
+// executeLocalized 以資料語言的翻譯函數與時間函數執行模板，block 不為空時執行該名稱的區塊；
+// missing 不為 nil 時回報所有翻譯目錄都找不到的 key
+func (te *TemplateEngine) executeLocalized(tmpl *template.Template, block string, data TemplateData, missing func(key string)) (string, error) {
 	// 只有在 FormatOptions 為空時才使用配置文件的默認值
 	// 這樣可以保留平台 handler 傳遞的自定義 FormatOptions
 	if te.config != nil && data.FormatOptions == (FormatOptions{}) {
@@ -619,8 +679,20 @@ func (te *TemplateEngine) RenderTemplate(language string, data TemplateData) (st
 		logger.Debug("Using provided FormatOptions (not overriding)", "template_engine")
 	}
 
+	tmpl, err := te.localize(tmpl, data, missing)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute template: %v", err)
+	}
+
 	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
+	if block == "" {
+		err = tmpl.Execute(&buf, data)
+	} else if tmpl.Lookup(block) == nil {
+		err = fmt.Errorf("template block %q not defined", block)
+	} else {
+		err = tmpl.ExecuteTemplate(&buf, block, data)
+	}
+	if err != nil {
 		return "", fmt.Errorf("failed to execute template: %v", err)
 	}
 
@@ -629,6 +701,11 @@ func (te *TemplateEngine) RenderTemplate(language string, data TemplateData) (st
 
 // RenderTemplateForPlatform 為特定平台渲染模板
 func (te *TemplateEngine) RenderTemplateForPlatform(language, platform string, data TemplateData) (string, error) {
+	return te.RenderNamedTemplateForPlatform("", language, platform, data)
+}
+
+// RenderNamedTemplateForPlatform 以具名模板集為特定平台渲染模板；name 為空或模板集不存在時使用預設模板
+func (te *TemplateEngine) RenderNamedTemplateForPlatform(name, language, platform string, data TemplateData) (string, error) {
 	// 設置平台信息
 	data.Platform = platform
 	
@@ -655,9 +732,11 @@ func (te *TemplateEngine) RenderTemplateForPlatform(language, platform string, d
 		logger.Int("FiringCount", data.FiringCount),
 		logger.Bool("FormatOptions.ShowEmoji.Enabled", data.FormatOptions.ShowEmoji.Enabled))
 	
-	renderedMessage, err := te.RenderTemplate(language, data)
+	renderedMessage, err := te.renderTemplate(name, language, data)
 	if err != nil {
-		logger.Error("Template rendering failed", "template_engine", logger.Err(err))
+		logger.Error("Template rendering failed", "template_engine",
+			logger.String("template_set", name),
+			logger.Err(err))
 		return "", err
 	}
 	
@@ -676,12 +755,29 @@ func (te *TemplateEngine) RenderTemplateForPlatform(language, platform string, d
 	return renderedMessage, nil
 }
 
-// GetAvailableLanguages 獲取已載入模板的語言列表
+// RenderNamedBlockForPlatform 以具名模板集為特定平台渲染模板中的區塊，例如 Slack Block Kit 使用的 slack.blocks；
+// 模板集的選擇與語言回退同 RenderNamedTemplateForPlatform，模板集內同名的 define 會覆寫共用區塊
+func (te *TemplateEngine) RenderNamedBlockForPlatform(name, block, language, platform string, data TemplateData) (string, error) {
+	data.Platform = platform
+	return te.renderBlock(name, block, language, data)
+}
+
+// GetAvailableLanguages 獲取已載入模板的語言列表；有共用模板時包含所有有翻譯目錄的語言
 func (te *TemplateEngine) GetAvailableLanguages() []string {
 	var languages []string
 	for lang := range te.templates {
-		languages = append(languages, lang)
+		if lang != sharedTemplate {
+			languages = append(languages, lang)
+		}
 	}
+	if _, shared := te.templates[sharedTemplate]; shared {
+		for _, lang := range te.GetCatalogLanguages() {
+			if _, exists := te.templates[lang]; !exists {
+				languages = append(languages, lang)
+			}
+		}
+	}
+	sort.Strings(languages)
 	return languages
 }
 
@@ -706,65 +802,138 @@ func (te *TemplateEngine) GetSupportedLanguageDetails() []LanguageConfig {
 	return te.config.SupportedLanguages
 }
 
-// HasLanguage 檢查是否支援指定語言
+// HasLanguage 檢查是否支援指定語言：有該語言的模板，或有共用模板與該語言的翻譯目錄
 func (te *TemplateEngine) HasLanguage(language string) bool {
-	_, exists := te.templates[language]
-	return exists
+	if language == sharedTemplate {
+		return false
+	}
+	if _, exists := te.templates[language]; exists {
+		return true
+	}
+	_, shared := te.templates[sharedTemplate]
+	return shared && te.hasCatalog(language)
 }
 
 // GetDefaultLanguage 獲取預設語言（如果指定語言不存在）
 func (te *TemplateEngine) GetDefaultLanguage(preferredLanguage string) string {
+	return te.languageFor(te.templates, preferredLanguage)
+}
+
+// GetTemplateSetLanguage 在指定模板集內套用語言回退，取得實際使用的語言
+func (te *TemplateEngine) GetTemplateSetLanguage(name, preferredLanguage string) string {
+	return te.languageFor(te.templateSet(name), preferredLanguage)
+}
+
+// languageFor 與 languageIn 相同，但有翻譯目錄的語言即使沒有模板也直接使用，
+// 渲染時以回退語言的模板搭配該語言的翻譯
+func (te *TemplateEngine) languageFor(templates map[string]*template.Template, preferredLanguage string) string {
+	if te.hasCatalog(preferredLanguage) {
+		return preferredLanguage
+	}
+	return te.languageIn(templates, preferredLanguage)
+}
+
+// languageIn 在模板集內依指定語言、配置的回退順序與第一個可用語言選擇語言；
+// 有共用模板時，回退順序中有翻譯目錄的語言也可以使用
+func (te *TemplateEngine) languageIn(templates map[string]*template.Template, preferredLanguage string) string {
 	// 如果指定語言存在，直接返回
-	if te.HasLanguage(preferredLanguage) {
+	if _, exists := templates[preferredLanguage]; exists && preferredLanguage != sharedTemplate {
 		return preferredLanguage
 	}
 	
 	// 使用配置的語言回退順序
+	_, shared := templates[sharedTemplate]
 	for _, fallback := range te.config.FallbackOrder {
-		if te.HasLanguage(fallback) {
+		if _, exists := templates[fallback]; exists || (shared && te.hasCatalog(fallback)) {
 			return fallback
 		}
 	}
 	
 	// 如果都沒有，返回第一個可用的語言
-	languages := te.GetAvailableLanguages()
+	languages := make([]string, 0, len(templates))
+	for lang := range templates {
+		if lang != sharedTemplate {
+			languages = append(languages, lang)
+		}
+	}
 	if len(languages) > 0 {
+		sort.Strings(languages)
 		return languages[0]
 	}
 	
 	return preferredLanguage // 如果沒有任何模板，返回原始語言
 }
 
+// HasTemplateSet 檢查具名模板集是否存在；空值與 default 代表預設模板
+func (te *TemplateEngine) HasTemplateSet(name string) bool {
+	if isDefaultSet(name) {
+		return true
+	}
+	_, exists := te.sets[name]
+	return exists
+}
+
+// GetTemplateSets 返回已載入的模板集名稱（含 default），依名稱排序
+func (te *TemplateEngine) GetTemplateSets() []string {
+	names := []string{DefaultTemplateSet}
+	for name := range te.sets {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// GetTemplateDir 返回最近一次載入的模板目錄，尚未載入時為空
+func (te *TemplateEngine) GetTemplateDir() string {
+	return te.dir
+}
+
+// templateSet 取得模板集；name 為空、default 或不存在時返回預設模板
+func (te *TemplateEngine) templateSet(name string) map[string]*template.Template {
+	if isDefaultSet(name) {
+		return te.templates
+	}
+	if set, exists := te.sets[name]; exists {
+		return set
+	}
+	logger.Warn("Template set not found, using default templates", "template_engine",
+		logger.String("template_set", name))
+	return te.templates
+}
+
+// isDefaultSet 檢查名稱是否代表預設模板集
+func isDefaultSet(name string) bool {
+	return name == "" || name == DefaultTemplateSet
+}
+
 // ReloadTemplates 重新載入模板（用於動態更新）
 func (te *TemplateEngine) ReloadTemplates(templateDir string) error {
 	// 清空現有模板
 	te.templates = make(map[string]*template.Template)
+	te.sets = make(map[string]map[string]*template.Template)
 	
 	// 重新載入
 	return te.LoadTemplates(templateDir)
 }
 
-// ValidateTemplate 驗證模板語法
+// ValidateTemplate 驗證單一模板檔案：解析後以驗證範例資料為各平台執行，返回第一個錯誤
 func (te *TemplateEngine) ValidateTemplate(templatePath string) error {
-	_, err := te.loadTemplate(templatePath)
-	return err
-}
-
-// formatTime 格式化時間字符串
-func (te *TemplateEngine) formatTime(timeStr string) string {
-	if timeStr == "" || timeStr == "0001-01-01T00:00:00Z" {
-		return "未設定"
-	}
-
-	// 嘗試解析 ISO 8601 格式的時間
-	t, err := time.Parse(time.RFC3339, timeStr)
+	tmpl, err := te.loadTemplate(templatePath)
 	if err != nil {
-		// 如果解析失敗，返回原始字符串
-		return timeStr
+		return err
 	}
 
-	// 格式化為本地時間
-	return t.Format("2006-01-02 15:04:05")
+	for _, platform := range []string{"telegram", "slack", "discord"} {
+		for _, fixture := range ValidationFixtures() {
+			for _, issue := range te.validateRender(tmpl, platform, 0, fixture) {
+				if issue.Severity == SeverityError {
+					issue.Path = templatePath
+					return fmt.Errorf("%s", issue.String())
+				}
+			}
+		}
+	}
+	return nil
 }
 
 // escapeMarkdownV2 轉義 MarkdownV2 特殊字符
@@ -784,7 +953,11 @@ func (te *TemplateEngine) escapeMarkdownV2(text string) string {
 
 // formatTextForPlatform 根據平台格式化普通文字
 func (te *TemplateEngine) formatTextForPlatform(platform, text string) string {
-	// 回退到簡單處理，避免過度轉義
+	if platform == "telegram" {
+		// Telegram 使用 HTML parse mode，標籤與註解中的 <、> 與 & 必須轉義
+		return telegramhtml.Escape(text)
+	}
+	// 其他平台回退到簡單處理，避免過度轉義
 	return text
 }
 
@@ -793,7 +966,7 @@ func (te *TemplateEngine) formatBoldForPlatform(platform, text string) string {
 	switch platform {
 	case "telegram":
 		// Telegram HTML 格式
-		return "<b>" + text + "</b>"
+		return "<b>" + telegramhtml.Escape(text) + "</b>"
 	default:
 		// 其他平台使用標準 Markdown 粗體格式
 		return "*" + text + "*"
@@ -805,7 +978,7 @@ func (te *TemplateEngine) formatItalicForPlatform(platform, text string) string
 	switch platform {
 	case "telegram":
 		// Telegram HTML 格式
-		return "<i>" + text + "</i>"
+		return "<i>" + telegramhtml.Escape(text) + "</i>"
 	default:
 		// 其他平台使用標準 Markdown 斜體格式
 		return "_" + text + "_"
@@ -817,7 +990,7 @@ func (te *TemplateEngine) formatCodeForPlatform(platform, text string) string {
 	switch platform {
 	case "telegram":
 		// Telegram HTML 格式
-		return "<code>" + text + "</code>"
+		return "<code>" + telegramhtml.Escape(text) + "</code>"
 	default:
 		// 其他平台使用標準 Markdown 代碼格式
 		return "`" + text + "`"
@@ -832,12 +1005,8 @@ func (te *TemplateEngine) formatLinkForPlatform(platform, url, text string) stri
 	
 	switch platform {
 	case "telegram":
-		// Telegram HTML 格式：<a href="url">text</a>
-		// HTML 實體轉義 URL 中的特殊字符
-		escapedURL := strings.ReplaceAll(url, "&", "&amp;")
-		escapedURL = strings.ReplaceAll(escapedURL, "<", "&lt;")
-		escapedURL = strings.ReplaceAll(escapedURL, ">", "&gt;")
-		return "<a href=\"" + escapedURL + "\">" + text + "</a>"
+		// Telegram HTML 格式：<a href="url">text</a>，URL 與連結文字皆需 HTML 實體轉義
+		return "<a href=\"" + telegramhtml.EscapeAttr(url) + "\">" + telegramhtml.Escape(text) + "</a>"
 	case "slack":
 		// Slack 格式：<url|text>
 		return "<" + url + "|" + text + ">"
@@ -871,12 +1040,6 @@ func (te *TemplateEngine) escapeMarkdownV2Text(text string) string {
 	return result
 }
 
-// formatTimeForPlatform 根據平台格式化時間
-func (te *TemplateEngine) formatTimeForPlatform(platform, timeStr string) string {
-	// 所有平台都使用標準 Markdown，不需要特殊轉義
-	return te.formatTime(timeStr)
-}
-
 // GetMinimalDefaultConfig returns the minimal mode default configuration
 func (te *TemplateEngine) GetMinimalDefaultConfig() *TemplateConfig {
 	// Try to load from alert_config.minimal.yaml first
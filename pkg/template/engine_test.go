@@ -0,0 +1,213 @@
+package template
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTemplateDir 在暫存目錄建立模板檔案，key 為相對路徑
+func writeTemplateDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestSharedTemplate(t *testing.T) {
+	dir := writeTemplateDir(t, map[string]string{
+		"alert_template.tmpl":    `{{ t "title.notification" }}`,
+		"alert_template_ja.tmpl": `override {{ t "title.notification" }}`,
+		"i18n/eng.yaml":          "title:\n  notification: \"Alert Notification\"\n",
+		"i18n/tw.yaml":           "title:\n  notification: \"警報通知\"\n",
+		"i18n/ja.yaml":           "title:\n  notification: \"アラート通知\"\n",
+	})
+
+	te := NewTemplateEngine()
+	if err := te.LoadTemplates(dir); err != nil {
+		t.Fatalf("LoadTemplates returned error: %v", err)
+	}
+
+	tests := []struct {
+		language string
+		want     string
+	}{
+		{"eng", "Alert Notification"},
+		{"tw", "警報通知"},
+		{"ja", "override アラート通知"},
+		// 沒有翻譯目錄的語言以共用模板渲染，文字依回退順序翻譯
+		{"fr", "Alert Notification"},
+	}
+	for _, tt := range tests {
+		got, err := te.RenderTemplateForPlatform(tt.language, "slack", TemplateData{})
+		if err != nil {
+			t.Errorf("RenderTemplateForPlatform(%q) returned error: %v", tt.language, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("RenderTemplateForPlatform(%q) = %q, want %q", tt.language, got, tt.want)
+		}
+	}
+
+	if got, want := te.GetAvailableLanguages(), []string{"eng", "ja", "tw"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetAvailableLanguages() = %v, want %v", got, want)
+	}
+	for language, want := range map[string]bool{"eng": true, "tw": true, "ja": true, "fr": false, sharedTemplate: false} {
+		if got := te.HasLanguage(language); got != want {
+			t.Errorf("HasLanguage(%q) = %t, want %t", language, got, want)
+		}
+	}
+	for preferred, want := range map[string]string{"tw": "tw", "ja": "ja", "fr": "eng"} {
+		if got := te.GetDefaultLanguage(preferred); got != want {
+			t.Errorf("GetDefaultLanguage(%q) = %q, want %q", preferred, got, want)
+		}
+	}
+}
+
+func TestShippedTemplates(t *testing.T) {
+	te := NewTemplateEngine()
+	if err := te.LoadTemplates(filepath.Join("..", "..", "templates", "alerts")); err != nil {
+		t.Fatalf("LoadTemplates returned error: %v", err)
+	}
+
+	data := ValidationFixtures()[0].Data
+	for _, language := range te.GetCatalogLanguages() {
+		title, ok := te.translate(language, "title.notification", nil, nil)
+		if !ok {
+			t.Errorf("%s: catalog has no title.notification", language)
+			continue
+		}
+		for _, platform := range []string{"telegram", "slack", "discord"} {
+			got, err := te.RenderTemplateForPlatform(language, platform, data)
+			if err != nil {
+				t.Errorf("%s (%s): RenderTemplateForPlatform returned error: %v", language, platform, err)
+				continue
+			}
+			if !strings.Contains(got, title) {
+				t.Errorf("%s (%s): output does not contain %q:\n%s", language, platform, title, got)
+			}
+		}
+	}
+}
+
+func TestShippedSlackBlocks(t *testing.T) {
+	te := NewTemplateEngine()
+	if err := te.LoadTemplates(filepath.Join("..", "..", "templates", "alerts")); err != nil {
+		t.Fatalf("LoadTemplates returned error: %v", err)
+	}
+
+	type block struct {
+		Type     string                   `json:"type"`
+		Text     map[string]interface{}   `json:"text"`
+		Fields   []map[string]interface{} `json:"fields"`
+		Elements []map[string]interface{} `json:"elements"`
+	}
+
+	for _, language := range te.GetCatalogLanguages() {
+		for _, fixture := range ValidationFixtures() {
+			output, err := te.RenderNamedBlockForPlatform("", SlackBlocksTemplate, language, "slack", fixture.Data)
+			if err != nil {
+				t.Errorf("%s (%s): RenderNamedBlockForPlatform returned error: %v", language, fixture.Name, err)
+				continue
+			}
+			var blocks []block
+			if err := json.Unmarshal([]byte(output), &blocks); err != nil {
+				t.Errorf("%s (%s): output is not a JSON array of blocks: %v\n%s", language, fixture.Name, err, output)
+				continue
+			}
+			if strings.Contains(output, "blocks.") {
+				t.Errorf("%s (%s): output contains an untranslated key:\n%s", language, fixture.Name, output)
+			}
+
+			header := blocks[0]
+			if header.Type != "header" || !strings.Contains(header.Text["text"].(string), "HighCPUUsage") {
+				t.Errorf("%s (%s): first block = %+v, want the header", language, fixture.Name, header)
+			}
+			if blocks[1].Type != "section" || len(blocks[1].Fields) != 3 {
+				t.Errorf("%s (%s): second block = %+v, want 3 label fields", language, fixture.Name, blocks[1])
+			}
+
+			// 每筆警報一個 section 與一個 context，最多 10 筆，其餘以一行摘要
+			shown := len(fixture.Data.Alerts)
+			if shown > 10 {
+				shown = 10
+			}
+			want := 3 + shown*2 + 1
+			if len(fixture.Data.Alerts) > 10 {
+				want++
+				more, _ := te.translate(language, "blocks.more", &[]int{len(fixture.Data.Alerts) - 10}[0], nil)
+				if !strings.Contains(output, more) {
+					t.Errorf("%s (%s): output does not contain %q", language, fixture.Name, more)
+				}
+			}
+			if len(blocks) != want {
+				t.Errorf("%s (%s): %d blocks, want %d", language, fixture.Name, len(blocks), want)
+			}
+			if footer := blocks[len(blocks)-1]; footer.Type != "context" || len(footer.Elements) != 3 {
+				t.Errorf("%s (%s): last block = %+v, want the footer", language, fixture.Name, footer)
+			}
+		}
+	}
+
+	// mrkdwn 特殊字元在模板中轉義
+	data := ValidationFixtures()[0].Data
+	output, err := te.RenderNamedBlockForPlatform("", SlackBlocksTemplate, "eng", "slack", data)
+	if err != nil {
+		t.Fatalf("RenderNamedBlockForPlatform returned error: %v", err)
+	}
+	var decoded []block
+	if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+		t.Fatalf("output is not a JSON array of blocks: %v", err)
+	}
+	if text := decoded[3].Text["text"].(string); !strings.Contains(text, "Container &lt;app&gt; &amp; sidecar") {
+		t.Errorf("alert section = %q, want mrkdwn escaped description", text)
+	}
+}
+
+func TestSlackBlocksOverride(t *testing.T) {
+	dir := writeTemplateDir(t, map[string]string{
+		"alert_template.tmpl":          `{{ .AlertName }}`,
+		"partials/slack_blocks.tmpl":   `{{ define "slack.blocks" }}[{"type":"divider"}]{{ end }}`,
+		"short/alert_template.tmpl":    `{{ define "slack.blocks" }}[{"type":"section","text":{"type":"mrkdwn","text":{{ toJSON .AlertName }}}}]{{ end }}{{ .AlertName }}`,
+		"nolayout/alert_template.tmpl": `{{ .AlertName }}`,
+	})
+	te := NewTemplateEngine()
+	if err := te.LoadTemplates(dir); err != nil {
+		t.Fatalf("LoadTemplates returned error: %v", err)
+	}
+
+	data := TemplateData{AlertName: "HighCPU"}
+	tests := []struct {
+		set  string
+		want string
+	}{
+		{"", `[{"type":"divider"}]`},
+		{"nolayout", `[{"type":"divider"}]`},
+		{"short", `[{"type":"section","text":{"type":"mrkdwn","text":"HighCPU"}}]`},
+	}
+	for _, tt := range tests {
+		got, err := te.RenderNamedBlockForPlatform(tt.set, SlackBlocksTemplate, "eng", "slack", data)
+		if err != nil {
+			t.Errorf("%q: RenderNamedBlockForPlatform returned error: %v", tt.set, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: RenderNamedBlockForPlatform = %q, want %q", tt.set, got, tt.want)
+		}
+	}
+
+	if _, err := te.RenderNamedBlockForPlatform("", "missing.block", "eng", "slack", data); err == nil || !strings.Contains(err.Error(), "not defined") {
+		t.Errorf("undefined block error = %v, want not defined", err)
+	}
+}
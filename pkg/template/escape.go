@@ -0,0 +1,96 @@
+package template
+
+import (
+	"fmt"
+	"text/template"
+	"text/template/parse"
+
+	"alert-webhooks/pkg/telegramhtml"
+)
+
+// telegramEscapeFunc 附加在 Telegram 模板輸出動作結尾的轉義函數名稱（以底線開頭，避免與模板函數衝突）
+const telegramEscapeFunc = "_telegram_escape"
+
+// telegramMarkupFuncs 已自行轉義內容並產生 Telegram HTML 標籤的格式化函數
+var telegramMarkupFuncs = map[string]bool{
+	"format_text":   true,
+	"format_bold":   true,
+	"format_italic": true,
+	"format_code":   true,
+	"format_link":   true,
+	"format_time":   true,
+}
+
+// telegramEscape 將輸出動作的結果轉為文字並轉義 Telegram HTML 的特殊字元
+func telegramEscape(value interface{}) string {
+	if value == nil {
+		return telegramhtml.Escape("<no value>")
+	}
+	return telegramhtml.Escape(fmt.Sprint(value))
+}
+
+// escapeTelegramOutput 複製模板集中每個模板的語法樹，並在每個輸出動作結尾加上 Telegram HTML 轉義，
+// 與 html/template 的做法相同：{{ .Labels.pod }}、{{ .AlertName | upper }}、{{ t "alert.item" "name" .AlertName }}
+// 等輸出都會轉義，標籤與註解中的 <、> 與 & 不會被 Telegram 當成 HTML。
+// 以 format_* 或 format_time 結尾的動作已自行轉義並產生標籤，不再轉義；變數宣告與 if / range / with 的條件不是輸出，不受影響。
+// tmpl 必須是副本，原模板的語法樹不會被修改
+func escapeTelegramOutput(tmpl *template.Template) *template.Template {
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		tree := t.Tree.Copy()
+		escapeOutputNode(tree.Root)
+		t.Tree = tree
+	}
+	return tmpl.Funcs(template.FuncMap{telegramEscapeFunc: telegramEscape})
+}
+
+// escapeOutputNode 遞迴處理語法樹中的輸出動作
+func escapeOutputNode(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			escapeOutputNode(child)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) > 0 || producesMarkup(n.Pipe) {
+			return
+		}
+		n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+			NodeType: parse.NodeCommand,
+			Pos:      n.Pipe.Pos,
+			Args:     []parse.Node{parse.NewIdentifier(telegramEscapeFunc).SetTree(nil).SetPos(n.Pipe.Pos)},
+		})
+	case *parse.IfNode:
+		escapeOutputNode(n.List)
+		escapeOutputNode(n.ElseList)
+	case *parse.RangeNode:
+		escapeOutputNode(n.List)
+		escapeOutputNode(n.ElseList)
+	case *parse.WithNode:
+		escapeOutputNode(n.List)
+		escapeOutputNode(n.ElseList)
+	}
+}
+
+// producesMarkup 檢查 pipeline 的最後一個命令是否為產生 Telegram HTML 的格式化函數（可包在括號中）
+func producesMarkup(pipe *parse.PipeNode) bool {
+	if pipe == nil || len(pipe.Cmds) == 0 {
+		return false
+	}
+	args := pipe.Cmds[len(pipe.Cmds)-1].Args
+	if len(args) == 0 {
+		return false
+	}
+	switch arg := args[0].(type) {
+	case *parse.IdentifierNode:
+		return telegramMarkupFuncs[arg.Ident]
+	case *parse.PipeNode:
+		return len(args) == 1 && producesMarkup(arg)
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package template
+
+import (
+	"testing"
+)
+
+func TestTelegramOutputIsEscaped(t *testing.T) {
+	data := TemplateData{
+		AlertName: "Disk <80%> & rising",
+		Alerts: []AlertData{{
+			Status:       "firing",
+			Labels:       map[string]string{"pod": "<script>", "team": "a&b"},
+			Annotations:  map[string]string{"summary": "x > y"},
+			GeneratorURL: "https://prom/graph?g0.expr=a&b=<c>",
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		source   string
+		telegram string
+		slack    string
+	}{
+		{"direct field", `{{ .AlertName }}`, "Disk &lt;80%&gt; &amp; rising", "Disk <80%> & rising"},
+		{"label index", `{{ range .Alerts }}{{ index .Labels "pod" }}{{ end }}`, "&lt;script&gt;", "<script>"},
+		{"range over labels", `{{ range .Alerts }}{{ .Labels.team }}|{{ .Annotations.summary }}{{ end }}`, "a&amp;b|x &gt; y", "a&b|x > y"},
+		{"helper pipeline", `{{ .AlertName | upper | truncate 8 }}`, "DISK &lt;8…", "DISK <8…"},
+		{"join", `{{ split "," "<a>,b" | join " & " }}`, "&lt;a&gt; &amp; b", "<a> & b"},
+		{"default", `{{ .Namespace | default "<none>" }}`, "&lt;none&gt;", "<none>"},
+		{"replace", `{{ replace "&" "and" .AlertName }}`, "Disk &lt;80%&gt; and rising", "Disk <80%> and rising"},
+		{"printf", `{{ printf "%s!" .AlertName }}`, "Disk &lt;80%&gt; &amp; rising!", "Disk <80%> & rising!"},
+		{"comparison uses raw values", `{{ if eq (index (first .Alerts).Labels "team") "a&b" }}yes{{ end }}`, "yes", "yes"},
+		{"variable keeps raw value", `{{ $name := .AlertName }}{{ len $name }}`, "19", "19"},
+		{"format helpers are not escaped twice", `{{ format_bold .Platform .AlertName }}`, "<b>Disk &lt;80%&gt; &amp; rising</b>", "*Disk <80%> & rising*"},
+		{"parenthesized format helper", `{{ (format_code .Platform "a<b") }}`, "<code>a&lt;b</code>", "`a<b`"},
+		{"format link", `{{ format_link .Platform (first .Alerts).GeneratorURL "View" }}`, `<a href="https://prom/graph?g0.expr=a&amp;b=&lt;c&gt;">View</a>`, "<https://prom/graph?g0.expr=a&b=<c>|View>"},
+		{"define block", `{{ define "name" }}[{{ .AlertName }}]{{ end }}{{ template "name" . }}`, "[Disk &lt;80%&gt; &amp; rising]", "[Disk <80%> & rising]"},
+	}
+
+	te := NewTemplateEngine()
+	for _, tt := range tests {
+		tmpl, err := te.parse(tt.name, tt.source)
+		if err != nil {
+			t.Fatalf("%s: parse returned error: %v", tt.name, err)
+		}
+		for platform, want := range map[string]string{"telegram": tt.telegram, "slack": tt.slack} {
+			data.Platform = platform
+			got, err := te.execute(tmpl, data)
+			if err != nil {
+				t.Errorf("%s (%s): execute returned error: %v", tt.name, platform, err)
+				continue
+			}
+			if got != want {
+				t.Errorf("%s (%s) = %q, want %q", tt.name, platform, got, want)
+			}
+		}
+	}
+
+	// 原模板的語法樹不受 Telegram 轉義影響
+	tmpl, err := te.parse("reuse", `{{ .AlertName }}`)
+	if err != nil {
+		t.Fatalf("parse returned error: %v", err)
+	}
+	for _, platform := range []string{"telegram", "slack", "telegram"} {
+		data.Platform = platform
+		got, _ := te.execute(tmpl, data)
+		want := data.AlertName
+		if platform == "telegram" {
+			want = "Disk &lt;80%&gt; &amp; rising"
+		}
+		if got != want {
+			t.Errorf("reused template (%s) = %q, want %q", platform, got, want)
+		}
+	}
+}
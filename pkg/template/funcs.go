@@ -0,0 +1,536 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"alert-webhooks/config"
+)
+
+// funcMap 返回模板可用的函數：平台格式化函數與擴充函數庫（字串、集合、時間、數字、預設值、JSON 與 URL）。
+// .tmpl 與編譯後的 Jinja2 模板共用同一組函數
+func (te *TemplateEngine) funcMap() template.FuncMap {
+	funcs := template.FuncMap{
+		"add":           func(a, b int) int { return a + b },
+		"index":         func(m map[string]string, key string) string { return m[key] },
+		"format_text":   te.formatTextForPlatform,
+		"format_bold":   te.formatBoldForPlatform,
+		"format_italic": te.formatItalicForPlatform,
+		"format_code":   te.formatCodeForPlatform,
+		"format_link":   te.formatLinkForPlatform,
+		"printf":        fmt.Sprintf,
+
+		// Slack Block Kit（slack.blocks 區塊）
+		"slack_escape":       slackEscape,
+		"slack_block_fields": slackBlockFields,
+	}
+	for name, fn := range libraryFuncs {
+		funcs[name] = fn
+	}
+	for name, fn := range jinja2Funcs {
+		funcs[name] = fn
+	}
+	// 翻譯與時間函數在執行時依資料的語言、平台與 level 重新綁定，這裡使用回退語言與全局時間格式
+	for name, fn := range te.localizedFuncs("", nil) {
+		funcs[name] = fn
+	}
+	for name, fn := range te.timeFuncs("", "", "") {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// libraryFuncs 擴充函數庫；參數順序與 sprig 相同，被操作的值放在最後以便使用 pipeline，
+// 例如 {{ .AlertName | truncate 20 | upper }}
+var libraryFuncs = template.FuncMap{
+	// 字串
+	"upper":        strings.ToUpper,
+	"lower":        strings.ToLower,
+	"title":        titleCase,
+	"trim":         strings.TrimSpace,
+	"truncate":     truncateText,
+	"replace":      func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"regexReplace": regexReplace,
+	"join":         joinList,
+	"split":        func(sep, s string) []string { return strings.Split(s, sep) },
+
+	// 集合
+	"sortAlertsBy":    sortAlertsBy,
+	"groupAlertsBy":   groupAlertsBy,
+	"filterByStatus":  filterByStatus,
+	"uniqLabelValues": uniqLabelValues,
+	"first":           first,
+	"last":            last,
+
+	// 時間
+	"since":            since,
+	"duration":         duration,
+	"humanizeDuration": humanizeDuration,
+	"toTimezone":       toTimezone,
+	"now":              time.Now,
+
+	// 數字
+	"humanize":     humanize,
+	"humanize1024": humanize1024,
+
+	// 預設值與序列化
+	"default":  defaultValue,
+	"coalesce": coalesce,
+	"toJSON":   toJSON,
+
+	// URL
+	"queryEscape": url.QueryEscape,
+	"pathEscape":  url.PathEscape,
+	"buildURL":    buildURL,
+}
+
+// defaultSlackBlockFields slack.block_fields 未配置時 fields 欄位格顯示的標籤
+var defaultSlackBlockFields = []string{"severity", "env", "namespace", "cluster", "instance", "job"}
+
+// slackEscaper 轉義 Slack mrkdwn 中具有特殊意義的 &、< 與 >
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackEscape 轉義 Slack mrkdwn 文字，輸出仍需以 toJSON 編碼後放入 blocks
+func slackEscape(s string) string {
+	return slackEscaper.Replace(s)
+}
+
+// slackBlockFields 返回 blocks 模式下 fields 欄位格顯示的標籤（slack.block_fields），未配置時使用預設標籤
+func slackBlockFields() []string {
+	if len(config.Slack.BlockFields) > 0 {
+		return config.Slack.BlockFields
+	}
+	return defaultSlackBlockFields
+}
+
+// titleCase 將每個單字的首字母轉為大寫
+func titleCase(s string) string {
+	prev := ' '
+	return strings.Map(func(r rune) rune {
+		isStart := unicode.IsSpace(prev) || prev == '-' || prev == '_'
+		prev = r
+		if isStart {
+			return unicode.ToTitle(r)
+		}
+		return r
+	}, s)
+}
+
+// truncateText 將文字截斷到 length 個字元（以 rune 計算），截斷時以 … 結尾
+func truncateText(length int, s string) string {
+	if length <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= length {
+		return s
+	}
+	runes := []rune(s)
+	if length == 1 {
+		return string(runes[:1])
+	}
+	return string(runes[:length-1]) + "…"
+}
+
+// regexCache 快取已編譯的正規表達式，模板每次渲染都會重複使用相同的 pattern
+var regexCache sync.Map
+
+// regexReplace 以正規表達式取代文字，repl 可使用 $1 等群組參照
+func regexReplace(pattern, repl, s string) (string, error) {
+	if cached, ok := regexCache.Load(pattern); ok {
+		return cached.(*regexp.Regexp).ReplaceAllString(s, repl), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("regexReplace: %v", err)
+	}
+	regexCache.Store(pattern, re)
+	return re.ReplaceAllString(s, repl), nil
+}
+
+// joinList 以分隔符串接任意 slice 的元素
+func joinList(sep string, list interface{}) string {
+	if list == nil {
+		return ""
+	}
+	if strs, ok := list.([]string); ok {
+		return strings.Join(strs, sep)
+	}
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return fmt.Sprint(list)
+	}
+	parts := make([]string, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		parts = append(parts, fmt.Sprint(v.Index(i).Interface()))
+	}
+	return strings.Join(parts, sep)
+}
+
+// alertField 取得警報排序與分組使用的值：status、startsAt、endsAt、generatorURL，其他名稱視為標籤
+func alertField(alert AlertData, key string) string {
+	switch key {
+	case "status":
+		return alert.Status
+	case "startsAt":
+		return alert.StartsAt
+	case "endsAt":
+		return alert.EndsAt
+	case "generatorURL":
+		return alert.GeneratorURL
+	}
+	return alert.Labels[key]
+}
+
+// sortAlertsBy 依欄位或標籤排序警報（穩定排序，不修改原 slice）；key 以 - 開頭時反向排序
+func sortAlertsBy(key string, alerts []AlertData) []AlertData {
+	desc := strings.HasPrefix(key, "-")
+	key = strings.TrimPrefix(key, "-")
+
+	sorted := make([]AlertData, len(alerts))
+	copy(sorted, alerts)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := alertField(sorted[i], key), alertField(sorted[j], key)
+		if desc {
+			return a > b
+		}
+		return a < b
+	})
+	return sorted
+}
+
+// groupAlertsBy 依標籤值將警報分組；缺少該標籤的警報歸在空字串下
+func groupAlertsBy(label string, alerts []AlertData) map[string][]AlertData {
+	groups := make(map[string][]AlertData)
+	for _, alert := range alerts {
+		value := alertField(alert, label)
+		groups[value] = append(groups[value], alert)
+	}
+	return groups
+}
+
+// filterByStatus 返回指定狀態（firing / resolved）的警報
+func filterByStatus(status string, alerts []AlertData) []AlertData {
+	var result []AlertData
+	for _, alert := range alerts {
+		if strings.EqualFold(alert.Status, status) {
+			result = append(result, alert)
+		}
+	}
+	return result
+}
+
+// uniqLabelValues 返回警報中某標籤排序後的不重複值，忽略空值
+func uniqLabelValues(label string, alerts []AlertData) []string {
+	seen := make(map[string]bool)
+	var values []string
+	for _, alert := range alerts {
+		value := alertField(alert, label)
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
+
+// first 返回 slice 或字串的第一個元素，空值返回 nil
+func first(list interface{}) interface{} {
+	return element(list, 0)
+}
+
+// last 返回 slice 或字串的最後一個元素，空值返回 nil
+func last(list interface{}) interface{} {
+	return element(list, -1)
+}
+
+// element 取得 slice、array 或字串的第 i 個元素，負數從尾端計算
+func element(list interface{}, i int) interface{} {
+	if list == nil {
+		return nil
+	}
+	v := reflect.ValueOf(list)
+	switch v.Kind() {
+	case reflect.String:
+		runes := []rune(v.String())
+		if len(runes) == 0 {
+			return nil
+		}
+		if i < 0 {
+			i += len(runes)
+		}
+		return string(runes[i])
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			return nil
+		}
+		if i < 0 {
+			i += v.Len()
+		}
+		return v.Index(i).Interface()
+	}
+	return nil
+}
+
+// toTime 將 RFC3339 字串、time.Time 或 Unix 秒數轉為時間；AlertManager 的零值時間視為無效
+func toTime(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case *time.Time:
+		if v != nil {
+			return *v, nil
+		}
+	case string:
+		if v == "" || v == "0001-01-01T00:00:00Z" {
+			return time.Time{}, fmt.Errorf("time is not set")
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time %q: %v", v, err)
+		}
+		return t, nil
+	case int:
+		return time.Unix(int64(v), 0), nil
+	case int64:
+		return time.Unix(v, 0), nil
+	case float64:
+		return time.Unix(int64(v), 0), nil
+	}
+	return time.Time{}, fmt.Errorf("unsupported time value %v", value)
+}
+
+// since 返回從指定時間到現在經過的時間；無法解析時返回 0
+func since(value interface{}) time.Duration {
+	t, err := toTime(value)
+	if err != nil {
+		return 0
+	}
+	return time.Since(t)
+}
+
+// duration 返回兩個時間之間的間隔；結束時間未設定（警報仍在觸發）時計算到現在
+func duration(start, end interface{}) time.Duration {
+	startTime, err := toTime(start)
+	if err != nil {
+		return 0
+	}
+	endTime, err := toTime(end)
+	if err != nil {
+		endTime = time.Now()
+	}
+	return endTime.Sub(startTime)
+}
+
+// humanizeDuration 將時間間隔轉為易讀格式，例如 1d 2h 3m 4s；數字參數視為秒數
+func humanizeDuration(value interface{}) string {
+	var d time.Duration
+	switch v := value.(type) {
+	case time.Duration:
+		d = v
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return v
+		}
+		d = parsed
+	default:
+		seconds, ok := toFloat(value)
+		if !ok {
+			return fmt.Sprint(value)
+		}
+		d = time.Duration(seconds * float64(time.Second))
+	}
+
+	if d < 0 {
+		return "-" + humanizeDuration(-d)
+	}
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+
+	d = d.Round(time.Second)
+	units := []struct {
+		suffix string
+		size   time.Duration
+	}{
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	}
+	var parts []string
+	for _, unit := range units {
+		if n := d / unit.size; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d%s", n, unit.suffix))
+			d -= n * unit.size
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// toTimezone 將時間轉換到指定時區（IANA 名稱，例如 Asia/Taipei），可再呼叫 .Format 格式化
+func toTimezone(zone string, value interface{}) (time.Time, error) {
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("toTimezone: %v", err)
+	}
+	t, err := toTime(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("toTimezone: %v", err)
+	}
+	return t.In(loc), nil
+}
+
+// humanize 以 SI 前綴格式化數字，例如 1234 → 1.234k、0.0012 → 1.2m
+func humanize(value interface{}) string {
+	v, ok := toFloat(value)
+	if !ok {
+		return fmt.Sprint(value)
+	}
+	if v == 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return fmt.Sprintf("%.4g", v)
+	}
+
+	if math.Abs(v) >= 1 {
+		prefix := ""
+		for _, p := range []string{"k", "M", "G", "T", "P", "E", "Z", "Y"} {
+			if math.Abs(v) < 1000 {
+				break
+			}
+			prefix = p
+			v /= 1000
+		}
+		return fmt.Sprintf("%.4g%s", v, prefix)
+	}
+
+	prefix := ""
+	for _, p := range []string{"m", "u", "n", "p", "f", "a", "z", "y"} {
+		if math.Abs(v) >= 1 {
+			break
+		}
+		prefix = p
+		v *= 1000
+	}
+	return fmt.Sprintf("%.4g%s", v, prefix)
+}
+
+// humanize1024 以二進位前綴格式化數字，例如 3565158 → 3.4Mi
+func humanize1024(value interface{}) string {
+	v, ok := toFloat(value)
+	if !ok {
+		return fmt.Sprint(value)
+	}
+	if math.Abs(v) < 1024 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return fmt.Sprintf("%.4g", v)
+	}
+
+	prefix := ""
+	for _, p := range []string{"ki", "Mi", "Gi", "Ti", "Pi", "Ei", "Zi", "Yi"} {
+		if math.Abs(v) < 1024 {
+			break
+		}
+		prefix = p
+		v /= 1024
+	}
+	return fmt.Sprintf("%.4g%s", v, prefix)
+}
+
+// toFloat 將數字或數字字串轉為 float64
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	case time.Duration:
+		return v.Seconds(), true
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// isEmpty 檢查值是否為空：nil、零值、空字串、空 slice 或 map
+func isEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return v.IsZero()
+}
+
+// defaultValue 值為空時返回預設值，例如 {{ index .Labels "team" | default "unknown" }}
+func defaultValue(def, value interface{}) interface{} {
+	if isEmpty(value) {
+		return def
+	}
+	return value
+}
+
+// coalesce 返回第一個非空的值，全部為空時返回 nil
+func coalesce(values ...interface{}) interface{} {
+	for _, value := range values {
+		if !isEmpty(value) {
+			return value
+		}
+	}
+	return nil
+}
+
+// toJSON 將值序列化為 JSON 字串
+func toJSON(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("toJSON: %v", err)
+	}
+	return string(data), nil
+}
+
+// buildURL 在 base URL 上附加經過編碼的查詢參數，參數以 key、value 成對傳入，
+// 例如 {{ buildURL "https://grafana/d/pods" "var-namespace" .Namespace "var-pod" $pod }}
+func buildURL(base string, pairs ...interface{}) (string, error) {
+	if len(pairs)%2 != 0 {
+		return "", fmt.Errorf("buildURL: query parameters must be key/value pairs")
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("buildURL: %v", err)
+	}
+	if u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("buildURL: unsupported scheme %q", u.Scheme)
+	}
+
+	query := u.Query()
+	for i := 0; i < len(pairs); i += 2 {
+		query.Add(fmt.Sprint(pairs[i]), fmt.Sprint(pairs[i+1]))
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
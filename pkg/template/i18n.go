@@ -0,0 +1,288 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"alert-webhooks/pkg/logger"
+
+	"gopkg.in/yaml.v3"
+)
+
+// I18nDir 模板目錄中翻譯目錄的名稱，每個語言一個 {lang}.yaml，例如 templates/alerts/i18n/eng.yaml。
+// 巢狀的 key 以 . 連接（alert.firing.title）；值為 zero/one/two/few/many/other 的 map 時為複數形式，必須包含 other
+const I18nDir = "i18n"
+
+// pluralCategories 複數形式的分類名稱
+var pluralCategories = map[string]bool{
+	"zero":  true,
+	"one":   true,
+	"two":   true,
+	"few":   true,
+	"many":  true,
+	"other": true,
+}
+
+// singularLanguages 沒有單複數變化的語言，tn 總是使用 other（有 zero 時數量為 0 仍使用 zero）
+var singularLanguages = map[string]bool{
+	"tw": true,
+	"zh": true,
+	"ja": true,
+	"ko": true,
+}
+
+// catalogEntry 翻譯條目；plural 不為空時為複數形式
+type catalogEntry struct {
+	text   string
+	plural map[string]string
+}
+
+// catalog 單一語言的翻譯目錄：key -> 條目
+type catalog map[string]catalogEntry
+
+// parseCatalog 解析 YAML 翻譯目錄並將巢狀 key 扁平化
+func parseCatalog(content []byte) (catalog, error) {
+	var root map[string]interface{}
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, err
+	}
+	entries := make(catalog)
+	if err := flattenCatalog("", root, entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// flattenCatalog 遞迴展開巢狀 map，字串為一般條目，只包含複數分類的 map 為複數條目
+func flattenCatalog(prefix string, node map[string]interface{}, entries catalog) error {
+	for name, value := range node {
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if forms, ok := pluralForms(v); ok {
+				if _, hasOther := forms["other"]; !hasOther {
+					return fmt.Errorf("plural key %q must define 'other'", key)
+				}
+				entries[key] = catalogEntry{plural: forms}
+				continue
+			}
+			if err := flattenCatalog(key, v, entries); err != nil {
+				return err
+			}
+		case nil:
+			return fmt.Errorf("key %q has no value", key)
+		case []interface{}:
+			return fmt.Errorf("key %q must be a string or a map, not a list", key)
+		default:
+			entries[key] = catalogEntry{text: fmt.Sprint(v)}
+		}
+	}
+	return nil
+}
+
+// pluralForms 檢查 map 是否只包含複數分類與字串值
+func pluralForms(node map[string]interface{}) (map[string]string, bool) {
+	if len(node) == 0 {
+		return nil, false
+	}
+	forms := make(map[string]string, len(node))
+	for name, value := range node {
+		text, ok := value.(string)
+		if !pluralCategories[name] || !ok {
+			return nil, false
+		}
+		forms[name] = text
+	}
+	return forms, true
+}
+
+// catalogFiles 返回翻譯目錄中每個語言的檔案，目錄不存在時返回空 map
+func catalogFiles(templateDir string) (map[string]string, error) {
+	files := make(map[string]string)
+	entries, err := os.ReadDir(filepath.Join(templateDir, I18nDir))
+	if os.IsNotExist(err) {
+		return files, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		ext := filepath.Ext(name)
+		if entry.IsDir() || strings.HasPrefix(name, ".") || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		files[strings.TrimSuffix(name, ext)] = filepath.Join(templateDir, I18nDir, name)
+	}
+	return files, nil
+}
+
+// loadCatalogs 載入模板目錄的翻譯目錄；解析失敗的語言會被略過，t 改用回退語言的翻譯
+func (te *TemplateEngine) loadCatalogs(templateDir string) {
+	te.catalogs = nil
+
+	files, err := catalogFiles(templateDir)
+	if err != nil {
+		logger.Warn("Failed to scan translation catalogs", "template_engine",
+			logger.String("template_dir", templateDir),
+			logger.Err(err))
+		return
+	}
+
+	catalogs := make(map[string]catalog)
+	for language, path := range files {
+		content, err := os.ReadFile(path)
+		if err == nil {
+			var entries catalog
+			if entries, err = parseCatalog(content); err == nil {
+				catalogs[language] = entries
+				logger.Info("Translation catalog loaded", "template_engine",
+					logger.String("language", language),
+					logger.String("path", path),
+					logger.Int("keys", len(entries)))
+				continue
+			}
+		}
+		logger.Warn("Failed to load translation catalog", "template_engine",
+			logger.String("language", language),
+			logger.String("path", path),
+			logger.Err(err))
+	}
+	if len(catalogs) > 0 {
+		te.catalogs = catalogs
+	}
+}
+
+// hasCatalog 檢查語言是否有翻譯目錄
+func (te *TemplateEngine) hasCatalog(language string) bool {
+	_, exists := te.catalogs[language]
+	return exists
+}
+
+// GetCatalogLanguages 返回有翻譯目錄的語言，依名稱排序
+func (te *TemplateEngine) GetCatalogLanguages() []string {
+	languages := make([]string, 0, len(te.catalogs))
+	for language := range te.catalogs {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// lookupTranslation 依指定語言與配置的回退順序查找 key，返回條目與實際找到的語言
+func (te *TemplateEngine) lookupTranslation(language, key string) (catalogEntry, string, bool) {
+	languages := []string{language}
+	if te.config != nil {
+		languages = append(languages, te.config.FallbackOrder...)
+	}
+	for _, lang := range languages {
+		if entry, ok := te.catalogs[lang][key]; ok {
+			return entry, lang, true
+		}
+	}
+	return catalogEntry{}, "", false
+}
+
+// translate 依語言與回退順序翻譯 key 並取代參數；count 不為 nil 時選擇複數形式，所有語言都找不到時 ok 為 false
+func (te *TemplateEngine) translate(language, key string, count *int, args []interface{}) (string, bool) {
+	entry, found, ok := te.lookupTranslation(language, key)
+	if !ok {
+		return "", false
+	}
+
+	text := entry.text
+	if entry.plural != nil {
+		n := 0
+		if count != nil {
+			n = *count
+		}
+		text = pluralForm(found, n, entry.plural)
+	}
+	return fillPlaceholders(text, count, args), true
+}
+
+// fillPlaceholders 以成對的名稱與值取代 {NAME}，count 不為 nil 時取代 {count}
+func fillPlaceholders(text string, count *int, args []interface{}) string {
+	replacements := make([]string, 0, len(args)+2)
+	if count != nil {
+		replacements = append(replacements, "{count}", fmt.Sprint(*count))
+	}
+	for i := 0; i+1 < len(args); i += 2 {
+		replacements = append(replacements, "{"+fmt.Sprint(args[i])+"}", fmt.Sprint(args[i+1]))
+	}
+	return strings.NewReplacer(replacements...).Replace(text)
+}
+
+// localizedFuncs 返回綁定語言的翻譯函數：
+//   - t KEY [NAME VALUE ...]：翻譯 key，並以參數取代 {NAME}
+//   - tn KEY COUNT [NAME VALUE ...]：依數量選擇複數形式，{count} 自動帶入數量
+//
+// 所有語言都找不到的 key 輸出 key 本身；missing 不為 nil 時回報這些 key
+func (te *TemplateEngine) localizedFuncs(language string, missing func(key string)) template.FuncMap {
+	translate := func(key string, count *int, args []interface{}) (string, error) {
+		if len(args)%2 != 0 {
+			return "", fmt.Errorf("translation arguments for %q must be name and value pairs", key)
+		}
+		text, ok := te.translate(language, key, count, args)
+		if !ok {
+			if missing != nil {
+				missing(key)
+			}
+			return key, nil
+		}
+		return text, nil
+	}
+
+	return template.FuncMap{
+		"t": func(key string, args ...interface{}) (string, error) {
+			return translate(key, nil, args)
+		},
+		"tn": func(key string, count interface{}, args ...interface{}) (string, error) {
+			n, ok := toInt(count)
+			if !ok {
+				f, isNumber := toFloat(count)
+				if !isNumber {
+					return "", fmt.Errorf("tn count for %q must be a number, got %T", key, count)
+				}
+				n = int(f)
+			}
+			return translate(key, &n, args)
+		},
+	}
+}
+
+// pluralForm 依語言與數量選擇複數形式，沒有對應分類時使用 other
+func pluralForm(language string, n int, forms map[string]string) string {
+	if text, ok := forms["zero"]; ok && n == 0 {
+		return text
+	}
+	if text, ok := forms["one"]; ok && n == 1 && !singularLanguages[language] {
+		return text
+	}
+	return forms["other"]
+}
+
+// localize 複製模板並綁定資料語言的翻譯函數，以及依資料平台、level 與語言設定時區的時間函數；
+// Telegram 平台的輸出動作會加上 HTML 轉義。missing 不為 nil 時回報所有翻譯目錄都找不到的 key
+func (te *TemplateEngine) localize(tmpl *template.Template, data TemplateData, missing func(key string)) (*template.Template, error) {
+	localized, err := tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+	funcs := te.localizedFuncs(data.Language, missing)
+	for name, fn := range te.timeFuncs(data.Platform, data.Level, data.Language) {
+		funcs[name] = fn
+	}
+	if data.Platform == "telegram" {
+		localized = escapeTelegramOutput(localized)
+	}
+	return localized.Funcs(funcs), nil
+}
@@ -0,0 +1,1082 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Jinja2 子集的詞法分析與語法樹。支援的語法：
+//   - {{ expr }} 輸出與 {# ... #} 註解，{{- / -}} 等空白控制
+//   - 表達式：字串、數字、true/false/none、變數、屬性（a.b）、下標（a["b"]、a[0]）、
+//     過濾器（a | upper、a | truncate(20)）、函數呼叫、比較（== != < > <= >=）、
+//     and / or / not、+ 與字串串接 ~
+//   - {% if %} / {% elif %} / {% else %} / {% endif %}
+//   - {% for x in xs %} 與 {% for k, v in mapping %}，含 {% else %} 與 loop.index / index0 / first / last / length
+//   - {% set x = expr %}、{% macro name(a, b="x") %} ... {% endmacro %}、{% include "file.j2" %}、{% raw %} ... {% endraw %}
+// 其他語法（extends、block、filter、測試 is、行內 if 等）會返回含位置的錯誤
+
+// Jinja2Error Jinja2 模板錯誤；格式與 text/template 錯誤相同（template: name:line:col: message），
+// 因此 NewRenderError 可以取出位置
+type Jinja2Error struct {
+	Name    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *Jinja2Error) Error() string {
+	return fmt.Sprintf("template: %s:%d:%d: %s", e.Name, e.Line, e.Column, e.Message)
+}
+
+// jinjaPos 原始碼位置
+type jinjaPos struct {
+	line, col int
+}
+
+// jinjaTokenKind 詞法單元類型
+type jinjaTokenKind int
+
+const (
+	tokText jinjaTokenKind = iota
+	tokVarBegin
+	tokVarEnd
+	tokBlockBegin
+	tokBlockEnd
+	tokName
+	tokString
+	tokNumber
+	tokOp
+	tokEOF
+)
+
+// jinjaToken 詞法單元；開始與結束標記的 trim 表示帶有 "-" 空白控制
+type jinjaToken struct {
+	kind  jinjaTokenKind
+	value string
+	trim  bool
+	pos   jinjaPos
+}
+
+// jinjaOperators 運算子，較長的放在前面以優先比對
+var jinjaOperators = []string{"==", "!=", "<=", ">=", "<", ">", "+", "-", "*", "/", "%", "~", "|", ".", ",", "(", ")", "[", "]", "=", ":"}
+
+// jinjaLexer 詞法分析器
+type jinjaLexer struct {
+	name   string
+	src    string
+	offset int
+	pos    jinjaPos
+	tokens []jinjaToken
+}
+
+// tokenizeJinja2 將原始碼切分為詞法單元；{% raw %} 區塊的內容作為文字返回
+func tokenizeJinja2(name, src string) ([]jinjaToken, error) {
+	lx := &jinjaLexer{name: name, src: src, pos: jinjaPos{line: 1, col: 1}}
+	for lx.offset < len(lx.src) {
+		if err := lx.lexText(); err != nil {
+			return nil, err
+		}
+	}
+	lx.tokens = append(lx.tokens, jinjaToken{kind: tokEOF, pos: lx.pos})
+	return lx.tokens, nil
+}
+
+func (lx *jinjaLexer) errorf(pos jinjaPos, format string, args ...interface{}) error {
+	return &Jinja2Error{Name: lx.name, Line: pos.line, Column: pos.col, Message: fmt.Sprintf(format, args...)}
+}
+
+// advance 前進 n 個位元組並更新行列
+func (lx *jinjaLexer) advance(n int) {
+	for _, r := range lx.src[lx.offset : lx.offset+n] {
+		if r == '\n' {
+			lx.pos.line++
+			lx.pos.col = 1
+		} else {
+			lx.pos.col++
+		}
+	}
+	lx.offset += n
+}
+
+// lexText 讀取下一個標籤之前的文字，再讀取標籤本身
+func (lx *jinjaLexer) lexText() error {
+	rest := lx.src[lx.offset:]
+	next := -1
+	for i := 0; i+1 < len(rest); i++ {
+		if rest[i] == '{' && (rest[i+1] == '{' || rest[i+1] == '%' || rest[i+1] == '#') {
+			next = i
+			break
+		}
+	}
+	if next < 0 {
+		next = len(rest)
+	}
+	if next > 0 {
+		lx.tokens = append(lx.tokens, jinjaToken{kind: tokText, value: rest[:next], pos: lx.pos})
+		lx.advance(next)
+	}
+	if lx.offset >= len(lx.src) {
+		return nil
+	}
+
+	start := lx.pos
+	switch lx.src[lx.offset+1] {
+	case '#':
+		end := strings.Index(lx.src[lx.offset:], "#}")
+		if end < 0 {
+			return lx.errorf(start, "unclosed comment")
+		}
+		comment := lx.src[lx.offset : lx.offset+end+2]
+		if strings.HasPrefix(comment, "{#-") {
+			lx.trimLastText()
+		}
+		lx.advance(end + 2)
+		if strings.HasSuffix(comment, "-#}") {
+			lx.trimNextText()
+		}
+		return nil
+	case '{':
+		return lx.lexTag(tokVarBegin, tokVarEnd, "}}")
+	default:
+		return lx.lexTag(tokBlockBegin, tokBlockEnd, "%}")
+	}
+}
+
+// trimLastText 移除前一段文字結尾的空白（{#- 註解）
+func (lx *jinjaLexer) trimLastText() {
+	if n := len(lx.tokens); n > 0 && lx.tokens[n-1].kind == tokText {
+		lx.tokens[n-1].value = strings.TrimRightFunc(lx.tokens[n-1].value, unicode.IsSpace)
+	}
+}
+
+// trimNextText 跳過接下來的空白（-#} 註解）
+func (lx *jinjaLexer) trimNextText() {
+	rest := lx.src[lx.offset:]
+	lx.advance(len(rest) - len(strings.TrimLeftFunc(rest, unicode.IsSpace)))
+}
+
+// lexTag 讀取 {{ ... }} 或 {% ... %} 標籤內的詞法單元
+func (lx *jinjaLexer) lexTag(begin, end jinjaTokenKind, closer string) error {
+	start := lx.pos
+	lx.advance(2)
+	trim := false
+	if lx.offset < len(lx.src) && lx.src[lx.offset] == '-' {
+		trim = true
+		lx.advance(1)
+	}
+	lx.tokens = append(lx.tokens, jinjaToken{kind: begin, trim: trim, pos: start})
+	first := len(lx.tokens)
+
+	for {
+		rest := lx.src[lx.offset:]
+		trimmed := strings.TrimLeftFunc(rest, unicode.IsSpace)
+		lx.advance(len(rest) - len(trimmed))
+		rest = trimmed
+		if rest == "" {
+			return lx.errorf(start, "unclosed tag, expected %q", closer)
+		}
+
+		if strings.HasPrefix(rest, "-"+closer) || strings.HasPrefix(rest, closer) {
+			pos := lx.pos
+			trimEnd := rest[0] == '-'
+			if trimEnd {
+				lx.advance(1)
+			}
+			lx.advance(2)
+			lx.tokens = append(lx.tokens, jinjaToken{kind: end, trim: trimEnd, pos: pos})
+			// {% raw %} 的內容直接作為文字
+			if begin == tokBlockBegin && len(lx.tokens) == first+2 && lx.tokens[first].kind == tokName && lx.tokens[first].value == "raw" {
+				return lx.lexRaw(start)
+			}
+			return nil
+		}
+
+		pos := lx.pos
+		r, _ := utf8.DecodeRuneInString(rest)
+		switch {
+		case r == '"' || r == '\'':
+			value, n, err := unquoteJinja(rest)
+			if err != nil {
+				return lx.errorf(pos, "%v", err)
+			}
+			lx.tokens = append(lx.tokens, jinjaToken{kind: tokString, value: value, pos: pos})
+			lx.advance(n)
+		case r >= '0' && r <= '9':
+			n := 0
+			for n < len(rest) && (rest[n] >= '0' && rest[n] <= '9' || rest[n] == '.' && n+1 < len(rest) && rest[n+1] >= '0' && rest[n+1] <= '9') {
+				n++
+			}
+			lx.tokens = append(lx.tokens, jinjaToken{kind: tokNumber, value: rest[:n], pos: pos})
+			lx.advance(n)
+		case r == '_' || unicode.IsLetter(r):
+			n := 0
+			for n < len(rest) {
+				c, size := utf8.DecodeRuneInString(rest[n:])
+				if c != '_' && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+					break
+				}
+				n += size
+			}
+			lx.tokens = append(lx.tokens, jinjaToken{kind: tokName, value: rest[:n], pos: pos})
+			lx.advance(n)
+		default:
+			op := ""
+			for _, candidate := range jinjaOperators {
+				if strings.HasPrefix(rest, candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return lx.errorf(pos, "unexpected character %q", r)
+			}
+			lx.tokens = append(lx.tokens, jinjaToken{kind: tokOp, value: op, pos: pos})
+			lx.advance(len(op))
+		}
+	}
+}
+
+// lexRaw 讀取 {% raw %} 到 {% endraw %} 之間的內容作為文字
+func (lx *jinjaLexer) lexRaw(start jinjaPos) error {
+	rest := lx.src[lx.offset:]
+	for i := 0; i < len(rest); i++ {
+		if !strings.HasPrefix(rest[i:], "{%") {
+			continue
+		}
+		j := i + 2
+		if j < len(rest) && rest[j] == '-' {
+			j++
+		}
+		inner := strings.TrimLeftFunc(rest[j:], unicode.IsSpace)
+		if !strings.HasPrefix(inner, "endraw") {
+			continue
+		}
+		inner = strings.TrimLeftFunc(inner[len("endraw"):], unicode.IsSpace)
+		if !strings.HasPrefix(inner, "%}") && !strings.HasPrefix(inner, "-%}") {
+			continue
+		}
+
+		// raw 內容作為文字，endraw 標籤由下一次 lexText 讀取
+		lx.tokens = append(lx.tokens, jinjaToken{kind: tokText, value: rest[:i], pos: lx.pos})
+		lx.advance(i)
+		return nil
+	}
+	return lx.errorf(start, "unclosed 'raw' (missing endraw)")
+}
+
+// unquoteJinja 解析以單引號或雙引號包住的字串，返回內容與消耗的位元組數
+func unquoteJinja(s string) (string, int, error) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; c {
+		case quote:
+			return b.String(), i + 1, nil
+		case '\\':
+			if i+1 >= len(s) {
+				break
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(s[i])
+			}
+		case '\n':
+			return "", 0, fmt.Errorf("unterminated string")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+// ---- 語法樹 ----
+
+// jinjaNode 語句節點
+type jinjaNode interface{}
+
+// jinjaTag 標籤的位置與空白控制
+type jinjaTag struct {
+	pos          jinjaPos
+	trimL, trimR bool
+	lines        int // 標籤內的換行數，編譯時保留以維持行號
+}
+
+type (
+	jinjaText struct {
+		text string
+	}
+	jinjaRaw struct {
+		tag  jinjaTag
+		text string
+		end  jinjaTag
+	}
+	jinjaOutput struct {
+		tag  jinjaTag
+		expr jinjaExpr
+	}
+	jinjaIfBranch struct {
+		tag  jinjaTag
+		cond jinjaExpr // else 分支為 nil
+		body []jinjaNode
+	}
+	jinjaIf struct {
+		branches []jinjaIfBranch
+		end      jinjaTag
+	}
+	jinjaFor struct {
+		tag      jinjaTag
+		key, val string // 單一變數時 key 為空
+		iter     jinjaExpr
+		body     []jinjaNode
+		elseTag  *jinjaTag
+		elseBody []jinjaNode
+		end      jinjaTag
+	}
+	jinjaSet struct {
+		tag  jinjaTag
+		name string
+		expr jinjaExpr
+	}
+	jinjaMacroParam struct {
+		name string
+		def  jinjaExpr
+	}
+	jinjaMacro struct {
+		tag    jinjaTag
+		name   string
+		params []jinjaMacroParam
+		body   []jinjaNode
+		end    jinjaTag
+	}
+	jinjaInclude struct {
+		tag  jinjaTag
+		path string
+	}
+)
+
+// jinjaExpr 表達式節點
+type jinjaExpr interface{}
+
+type (
+	jinjaLiteral struct {
+		pos   jinjaPos
+		kind  jinjaTokenKind // tokString、tokNumber 或 tokName（true、false、none）
+		value string
+	}
+	jinjaName struct {
+		pos  jinjaPos
+		name string
+	}
+	jinjaAttr struct {
+		pos  jinjaPos
+		x    jinjaExpr
+		name string
+	}
+	jinjaItem struct {
+		pos jinjaPos
+		x   jinjaExpr
+		key jinjaExpr
+	}
+	jinjaFilter struct {
+		pos  jinjaPos
+		x    jinjaExpr
+		name string
+		args []jinjaExpr
+	}
+	jinjaKwarg struct {
+		pos   jinjaPos
+		name  string
+		value jinjaExpr
+	}
+	jinjaCall struct {
+		pos  jinjaPos
+		name string
+		args []jinjaExpr // 可能包含 *jinjaKwarg
+	}
+	jinjaBinary struct {
+		pos  jinjaPos
+		op   string
+		l, r jinjaExpr
+	}
+	jinjaUnary struct {
+		pos jinjaPos
+		op  string
+		x   jinjaExpr
+	}
+)
+
+// exprPos 返回表達式的位置
+func exprPos(e jinjaExpr) jinjaPos {
+	switch e := e.(type) {
+	case *jinjaLiteral:
+		return e.pos
+	case *jinjaName:
+		return e.pos
+	case *jinjaAttr:
+		return e.pos
+	case *jinjaItem:
+		return e.pos
+	case *jinjaFilter:
+		return e.pos
+	case *jinjaKwarg:
+		return e.pos
+	case *jinjaCall:
+		return e.pos
+	case *jinjaBinary:
+		return e.pos
+	case *jinjaUnary:
+		return e.pos
+	}
+	return jinjaPos{}
+}
+
+// ---- 語法分析 ----
+
+// jinjaParser 語法分析器
+type jinjaParser struct {
+	name   string
+	tokens []jinjaToken
+	i      int
+}
+
+// parseJinja2 將原始碼解析為語法樹
+func parseJinja2(name, src string) ([]jinjaNode, error) {
+	tokens, err := tokenizeJinja2(name, src)
+	if err != nil {
+		return nil, err
+	}
+	p := &jinjaParser{name: name, tokens: tokens}
+	nodes, stop, err := p.parseBody()
+	if err != nil {
+		return nil, err
+	}
+	if stop != nil {
+		return nil, p.errorf(stop.pos, "unexpected '%s'", stop.value)
+	}
+	return nodes, nil
+}
+
+func (p *jinjaParser) errorf(pos jinjaPos, format string, args ...interface{}) error {
+	return &Jinja2Error{Name: p.name, Line: pos.line, Column: pos.col, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *jinjaParser) peek() jinjaToken {
+	return p.tokens[p.i]
+}
+
+func (p *jinjaParser) next() jinjaToken {
+	tok := p.tokens[p.i]
+	if tok.kind != tokEOF {
+		p.i++
+	}
+	return tok
+}
+
+// isOp 檢查下一個詞法單元是否為指定運算子
+func (p *jinjaParser) isOp(op string) bool {
+	tok := p.peek()
+	return tok.kind == tokOp && tok.value == op
+}
+
+// isKeyword 檢查下一個詞法單元是否為指定關鍵字
+func (p *jinjaParser) isKeyword(word string) bool {
+	tok := p.peek()
+	return tok.kind == tokName && tok.value == word
+}
+
+func (p *jinjaParser) expectOp(op string) error {
+	if !p.isOp(op) {
+		return p.errorf(p.peek().pos, "expected '%s', found %s", op, describeToken(p.peek()))
+	}
+	p.next()
+	return nil
+}
+
+func (p *jinjaParser) expectName() (jinjaToken, error) {
+	tok := p.peek()
+	if tok.kind != tokName {
+		return tok, p.errorf(tok.pos, "expected a name, found %s", describeToken(tok))
+	}
+	return p.next(), nil
+}
+
+// describeToken 在錯誤訊息中描述詞法單元
+func describeToken(tok jinjaToken) string {
+	switch tok.kind {
+	case tokEOF:
+		return "end of template"
+	case tokVarEnd:
+		return "'}}'"
+	case tokBlockEnd:
+		return "'%}'"
+	case tokString:
+		return fmt.Sprintf("string %q", tok.value)
+	}
+	return fmt.Sprintf("'%s'", tok.value)
+}
+
+// endTag 讀取標籤結束標記並返回完整的標籤資訊
+func (p *jinjaParser) endTag(begin jinjaToken, kind jinjaTokenKind) (jinjaTag, error) {
+	tok := p.peek()
+	if tok.kind != kind {
+		return jinjaTag{}, p.errorf(tok.pos, "expected end of tag, found %s", describeToken(tok))
+	}
+	p.next()
+	return jinjaTag{pos: begin.pos, trimL: begin.trim, trimR: tok.trim, lines: tok.pos.line - begin.pos.line}, nil
+}
+
+// parseBody 解析語句直到遇到結束語句（endif、else 等）或檔案結尾；
+// 遇到結束語句時返回其名稱詞法單元，游標停在該名稱之後
+func (p *jinjaParser) parseBody() ([]jinjaNode, *jinjaToken, error) {
+	var nodes []jinjaNode
+	for {
+		tok := p.next()
+		switch tok.kind {
+		case tokEOF:
+			return nodes, nil, nil
+		case tokText:
+			nodes = append(nodes, &jinjaText{text: tok.value})
+		case tokVarBegin:
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, nil, err
+			}
+			tag, err := p.endTag(tok, tokVarEnd)
+			if err != nil {
+				return nil, nil, err
+			}
+			nodes = append(nodes, &jinjaOutput{tag: tag, expr: expr})
+		case tokBlockBegin:
+			word, err := p.expectName()
+			if err != nil {
+				return nil, nil, err
+			}
+			switch word.value {
+			case "endif", "elif", "else", "endfor", "endmacro", "endraw":
+				// 由呼叫者讀取結束語句其餘部分；位置與空白控制取自開始標記
+				stop := word
+				stop.pos = tok.pos
+				stop.trim = tok.trim
+				return nodes, &stop, nil
+			}
+			node, err := p.parseStatement(tok, word)
+			if err != nil {
+				return nil, nil, err
+			}
+			if node != nil {
+				nodes = append(nodes, node)
+			}
+		default:
+			return nil, nil, p.errorf(tok.pos, "unexpected %s", describeToken(tok))
+		}
+	}
+}
+
+// parseStatement 解析 {% word ... %} 語句
+func (p *jinjaParser) parseStatement(begin, word jinjaToken) (jinjaNode, error) {
+	switch word.value {
+	case "if":
+		return p.parseIf(begin)
+	case "for":
+		return p.parseFor(begin)
+	case "set":
+		return p.parseSet(begin)
+	case "macro":
+		return p.parseMacro(begin)
+	case "include":
+		return p.parseInclude(begin)
+	case "raw":
+		return p.parseRaw(begin)
+	}
+	return nil, p.errorf(word.pos, "unsupported statement '%s'", word.value)
+}
+
+// expectEnd 解析結束語句（例如 endif），stop 為 parseBody 返回的結束語句
+func (p *jinjaParser) expectEnd(stop *jinjaToken, want string, open jinjaToken, openName string) (jinjaTag, error) {
+	if stop == nil {
+		return jinjaTag{}, p.errorf(open.pos, "unclosed '%s' (missing %s)", openName, want)
+	}
+	if stop.value != want {
+		return jinjaTag{}, p.errorf(stop.pos, "unexpected '%s', expected '%s'", stop.value, want)
+	}
+	return p.endTag(*stop, tokBlockEnd)
+}
+
+func (p *jinjaParser) parseIf(begin jinjaToken) (jinjaNode, error) {
+	node := &jinjaIf{}
+	tagBegin := begin
+	cond, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tag, err := p.endTag(tagBegin, tokBlockEnd)
+		if err != nil {
+			return nil, err
+		}
+		body, stop, err := p.parseBody()
+		if err != nil {
+			return nil, err
+		}
+		node.branches = append(node.branches, jinjaIfBranch{tag: tag, cond: cond, body: body})
+		if stop == nil {
+			return nil, p.errorf(begin.pos, "unclosed 'if' (missing endif)")
+		}
+		switch stop.value {
+		case "elif":
+			if cond == nil {
+				return nil, p.errorf(stop.pos, "unexpected 'elif' after 'else'")
+			}
+			if cond, err = p.parseExpr(); err != nil {
+				return nil, err
+			}
+		case "else":
+			if cond == nil {
+				return nil, p.errorf(stop.pos, "unexpected 'else' after 'else'")
+			}
+			cond = nil
+		case "endif":
+			if node.end, err = p.endTag(*stop, tokBlockEnd); err != nil {
+				return nil, err
+			}
+			return node, nil
+		default:
+			return nil, p.errorf(stop.pos, "unexpected '%s', expected 'endif'", stop.value)
+		}
+		tagBegin = *stop
+	}
+}
+
+func (p *jinjaParser) parseFor(begin jinjaToken) (jinjaNode, error) {
+	node := &jinjaFor{}
+	first, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	node.val = first.value
+	if p.isOp(",") {
+		p.next()
+		second, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		node.key, node.val = first.value, second.value
+	}
+	if !p.isKeyword("in") {
+		return nil, p.errorf(p.peek().pos, "expected 'in', found %s", describeToken(p.peek()))
+	}
+	p.next()
+	// 迭代對象之後的 if 是迴圈過濾而不是行內 if
+	if node.iter, err = p.parseOr(); err != nil {
+		return nil, err
+	}
+	if p.isKeyword("if") || p.isKeyword("recursive") {
+		return nil, p.errorf(p.peek().pos, "unsupported '%s' in for loop", p.peek().value)
+	}
+	if node.tag, err = p.endTag(begin, tokBlockEnd); err != nil {
+		return nil, err
+	}
+
+	body, stop, err := p.parseBody()
+	if err != nil {
+		return nil, err
+	}
+	node.body = body
+	if stop != nil && stop.value == "else" {
+		tag, err := p.endTag(*stop, tokBlockEnd)
+		if err != nil {
+			return nil, err
+		}
+		node.elseTag = &tag
+		if node.elseBody, stop, err = p.parseBody(); err != nil {
+			return nil, err
+		}
+	}
+	if node.end, err = p.expectEnd(stop, "endfor", begin, "for"); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func (p *jinjaParser) parseSet(begin jinjaToken) (jinjaNode, error) {
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if p.isOp(",") {
+		return nil, p.errorf(p.peek().pos, "unsupported multiple assignment in set")
+	}
+	if err := p.expectOp("="); err != nil {
+		return nil, err
+	}
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	tag, err := p.endTag(begin, tokBlockEnd)
+	if err != nil {
+		return nil, err
+	}
+	return &jinjaSet{tag: tag, name: name.value, expr: expr}, nil
+}
+
+func (p *jinjaParser) parseMacro(begin jinjaToken) (jinjaNode, error) {
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	node := &jinjaMacro{name: name.value}
+	if err := p.expectOp("("); err != nil {
+		return nil, err
+	}
+	for !p.isOp(")") {
+		param, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		mp := jinjaMacroParam{name: param.value}
+		if p.isOp("=") {
+			p.next()
+			if mp.def, err = p.parseExpr(); err != nil {
+				return nil, err
+			}
+		}
+		node.params = append(node.params, mp)
+		if !p.isOp(",") {
+			break
+		}
+		p.next()
+	}
+	if err := p.expectOp(")"); err != nil {
+		return nil, err
+	}
+	if node.tag, err = p.endTag(begin, tokBlockEnd); err != nil {
+		return nil, err
+	}
+
+	body, stop, err := p.parseBody()
+	if err != nil {
+		return nil, err
+	}
+	node.body = body
+	if node.end, err = p.expectEnd(stop, "endmacro", begin, "macro"); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func (p *jinjaParser) parseInclude(begin jinjaToken) (jinjaNode, error) {
+	tok := p.peek()
+	if tok.kind != tokString {
+		return nil, p.errorf(tok.pos, "include requires a string literal path")
+	}
+	p.next()
+	if p.isKeyword("ignore") || p.isKeyword("with") || p.isKeyword("without") {
+		return nil, p.errorf(p.peek().pos, "unsupported '%s' in include", p.peek().value)
+	}
+	tag, err := p.endTag(begin, tokBlockEnd)
+	if err != nil {
+		return nil, err
+	}
+	return &jinjaInclude{tag: tag, path: tok.value}, nil
+}
+
+func (p *jinjaParser) parseRaw(begin jinjaToken) (jinjaNode, error) {
+	node := &jinjaRaw{}
+	var err error
+	if node.tag, err = p.endTag(begin, tokBlockEnd); err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind == tokText {
+		node.text = p.next().value
+	}
+	// lexer 保證 raw 內容之後是 {% endraw %}
+	endBegin := p.next()
+	if _, err := p.expectName(); err != nil {
+		return nil, err
+	}
+	if node.end, err = p.endTag(endBegin, tokBlockEnd); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// ---- 表達式 ----
+
+// parseExpr 解析表達式，運算子優先順序由低到高：or、and、not、比較、~、+ -、* / %、一元 -、過濾器、後綴
+func (p *jinjaParser) parseExpr() (jinjaExpr, error) {
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.isKeyword("if") {
+		return nil, p.errorf(p.peek().pos, "unsupported inline if expression")
+	}
+	return expr, nil
+}
+
+func (p *jinjaParser) parseOr() (jinjaExpr, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("or") {
+		pos := p.next().pos
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = &jinjaBinary{pos: pos, op: "or", l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *jinjaParser) parseAnd() (jinjaExpr, error) {
+	l, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("and") {
+		pos := p.next().pos
+		r, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l = &jinjaBinary{pos: pos, op: "and", l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *jinjaParser) parseNot() (jinjaExpr, error) {
+	if p.isKeyword("not") {
+		pos := p.next().pos
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &jinjaUnary{pos: pos, op: "not", x: x}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *jinjaParser) parseCompare() (jinjaExpr, error) {
+	l, err := p.parseConcat()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		switch {
+		case tok.kind == tokOp && (tok.value == "==" || tok.value == "!=" || tok.value == "<" || tok.value == ">" || tok.value == "<=" || tok.value == ">="):
+		case tok.kind == tokName && (tok.value == "in" || tok.value == "is"):
+			return nil, p.errorf(tok.pos, "unsupported operator '%s'", tok.value)
+		default:
+			return l, nil
+		}
+		p.next()
+		r, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		l = &jinjaBinary{pos: tok.pos, op: tok.value, l: l, r: r}
+	}
+}
+
+func (p *jinjaParser) parseConcat() (jinjaExpr, error) {
+	l, err := p.parseAdd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("~") {
+		pos := p.next().pos
+		r, err := p.parseAdd()
+		if err != nil {
+			return nil, err
+		}
+		l = &jinjaBinary{pos: pos, op: "~", l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *jinjaParser) parseAdd() (jinjaExpr, error) {
+	l, err := p.parseMul()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("+") || p.isOp("-") {
+		tok := p.next()
+		r, err := p.parseMul()
+		if err != nil {
+			return nil, err
+		}
+		l = &jinjaBinary{pos: tok.pos, op: tok.value, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *jinjaParser) parseMul() (jinjaExpr, error) {
+	l, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("*") || p.isOp("/") || p.isOp("%") {
+		tok := p.next()
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l = &jinjaBinary{pos: tok.pos, op: tok.value, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *jinjaParser) parseUnary() (jinjaExpr, error) {
+	if p.isOp("-") {
+		pos := p.next().pos
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &jinjaUnary{pos: pos, op: "-", x: x}, nil
+	}
+	return p.parseFilters()
+}
+
+func (p *jinjaParser) parseFilters() (jinjaExpr, error) {
+	x, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("|") {
+		p.next()
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		filter := &jinjaFilter{pos: name.pos, x: x, name: name.value}
+		if p.isOp("(") {
+			if filter.args, err = p.parseArgs(); err != nil {
+				return nil, err
+			}
+		}
+		x = filter
+	}
+	return x, nil
+}
+
+func (p *jinjaParser) parsePostfix() (jinjaExpr, error) {
+	x, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.isOp("."):
+			p.next()
+			tok := p.peek()
+			if tok.kind != tokName && tok.kind != tokNumber {
+				return nil, p.errorf(tok.pos, "expected attribute name, found %s", describeToken(tok))
+			}
+			p.next()
+			x = &jinjaAttr{pos: tok.pos, x: x, name: tok.value}
+		case p.isOp("["):
+			pos := p.next().pos
+			key, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if p.isOp(":") {
+				return nil, p.errorf(p.peek().pos, "unsupported slice expression")
+			}
+			if err := p.expectOp("]"); err != nil {
+				return nil, err
+			}
+			x = &jinjaItem{pos: pos, x: x, key: key}
+		case p.isOp("("):
+			name, ok := x.(*jinjaName)
+			if !ok {
+				return nil, p.errorf(p.peek().pos, "only functions and macros can be called")
+			}
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			x = &jinjaCall{pos: name.pos, name: name.name, args: args}
+		default:
+			return x, nil
+		}
+	}
+}
+
+// parseArgs 解析 ( 參數, name=值 ) 參數列表
+func (p *jinjaParser) parseArgs() ([]jinjaExpr, error) {
+	if err := p.expectOp("("); err != nil {
+		return nil, err
+	}
+	var args []jinjaExpr
+	for !p.isOp(")") {
+		tok := p.peek()
+		if tok.kind == tokName && p.tokens[p.i+1].kind == tokOp && p.tokens[p.i+1].value == "=" {
+			p.next()
+			p.next()
+			value, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, &jinjaKwarg{pos: tok.pos, name: tok.value, value: value})
+		} else {
+			arg, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+		if !p.isOp(",") {
+			break
+		}
+		p.next()
+	}
+	if err := p.expectOp(")"); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+func (p *jinjaParser) parsePrimary() (jinjaExpr, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokString, tokNumber:
+		p.next()
+		return &jinjaLiteral{pos: tok.pos, kind: tok.kind, value: tok.value}, nil
+	case tokName:
+		p.next()
+		switch tok.value {
+		case "true", "True", "false", "False", "none", "None":
+			return &jinjaLiteral{pos: tok.pos, kind: tokName, value: strings.ToLower(tok.value)}, nil
+		}
+		return &jinjaName{pos: tok.pos, name: tok.value}, nil
+	case tokOp:
+		switch tok.value {
+		case "(":
+			p.next()
+			x, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectOp(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		case "[":
+			return nil, p.errorf(tok.pos, "unsupported list literal")
+		}
+	}
+	return nil, p.errorf(tok.pos, "unexpected %s in expression", describeToken(tok))
+}
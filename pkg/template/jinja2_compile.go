@@ -0,0 +1,989 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Jinja2 語法樹編譯為 Go template 原始碼：
+//   - 根變數（status、alert_name、alerts 等）對應 TemplateData 欄位，屬性名稱不分大小寫並忽略底線
+//   - 靜態型別已知時直接存取欄位，否則透過 jinja_item 在執行時查找
+//   - set 變數在所屬作用域（模板、for 迴圈、macro）開頭預先宣告，讓 if 內的 set 可以在 if 之後使用
+//   - macro 編譯為 define，呼叫時以 jinja_args 傳入根資料與參數
+//   - 除了 include 之外，輸出與原始碼的行號一致，執行錯誤的行號可以直接對應 .j2 檔案
+
+// jinjaMacroPrefix macro 對應的 define 名稱前綴
+const jinjaMacroPrefix = "jinja_macro_"
+
+// jinjaMaxIncludeDepth include 的最大巢狀深度，用於偵測循環 include
+const jinjaMaxIncludeDepth = 10
+
+// jinjaLoader 讀取 include 的模板原始碼
+type jinjaLoader func(path string) (string, error)
+
+// jinjaPlatformFuncs 第一個參數為平台的格式化函數，Jinja2 中呼叫時自動帶入目前平台
+var jinjaPlatformFuncs = map[string]bool{
+	"format_time":   true,
+	"format_text":   true,
+	"format_bold":   true,
+	"format_italic": true,
+	"format_code":   true,
+	"format_link":   true,
+}
+
+// jinjaFilterAliases Jinja2 內建過濾器名稱對應的模板函數
+var jinjaFilterAliases = map[string]string{
+	"length":    "len",
+	"count":     "len",
+	"e":         "format_text",
+	"escape":    "format_text",
+	"d":         "default",
+	"tojson":    "toJSON",
+	"urlencode": "queryEscape",
+	"format":    "printf",
+}
+
+// jinjaValueFirstFuncs 被操作的值放在第一個參數的函數（其他函數與 sprig 相同，值放在最後）
+var jinjaValueFirstFuncs = map[string]bool{
+	"printf":         true,
+	"t":              true,
+	"tn":             true,
+	"alert_duration": true,
+}
+
+// jinja2Funcs Jinja2 編譯結果使用的輔助函數
+var jinja2Funcs = template.FuncMap{
+	"jinja_args": jinjaArgs,
+	"jinja_item": jinjaGetItem,
+	"jinja_math": jinjaMath,
+}
+
+var (
+	jinjaRootType   = reflect.TypeOf(TemplateData{})
+	jinjaStringType = reflect.TypeOf("")
+	jinjaIntType    = reflect.TypeOf(0)
+	jinjaBoolType   = reflect.TypeOf(true)
+)
+
+// jinjaIncluded 已展開的 include，nodes 來自檔案 name
+type jinjaIncluded struct {
+	tag   jinjaTag
+	name  string
+	nodes []jinjaNode
+}
+
+// jinjaLoop for 迴圈資訊，用於 loop.index 等屬性
+type jinjaLoop struct {
+	index   string // 索引變數，例如 $jinja_loop1
+	iter    string // 迭代對象的程式碼
+	mapping bool
+}
+
+// jinjaScope 變數作用域（模板、for 迴圈或 macro），記錄變數的靜態型別（未知為 nil）
+type jinjaScope map[string]reflect.Type
+
+// jinjaCompiler 編譯器狀態
+type jinjaCompiler struct {
+	name    string // 目前編譯的檔案名稱，用於錯誤訊息
+	funcs   template.FuncMap
+	out     strings.Builder
+	scopes  []jinjaScope
+	loops   []jinjaLoop
+	macros  map[string]*jinjaMacro
+	inMacro bool
+	depth   int // 區塊語句巢狀深度，macro 只能定義在頂層
+	counter int
+}
+
+// compileJinja2 將 Jinja2 子集模板編譯為 Go template 原始碼；include 透過 load 讀取，load 為 nil 時不支援 include
+func (te *TemplateEngine) compileJinja2(name, source string, load jinjaLoader) (string, error) {
+	nodes, err := parseJinja2(name, source)
+	if err != nil {
+		return "", err
+	}
+	if nodes, err = expandIncludes(name, nodes, load, 0); err != nil {
+		return "", err
+	}
+
+	c := &jinjaCompiler{name: name, funcs: te.funcMap(), macros: make(map[string]*jinjaMacro)}
+	c.pushScope(nodes)
+	if err := c.compileNodes(nodes); err != nil {
+		return "", err
+	}
+	return c.out.String(), nil
+}
+
+// includeLoader 返回依序從 dirs 讀取 include 的 loader；路徑必須是目錄內的相對路徑
+func includeLoader(dirs ...string) jinjaLoader {
+	return func(path string) (string, error) {
+		clean := filepath.Clean(filepath.FromSlash(path))
+		if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("include path %q must be relative to the template directory", path)
+		}
+		var err error
+		for _, dir := range dirs {
+			if dir == "" {
+				continue
+			}
+			var content []byte
+			if content, err = os.ReadFile(filepath.Join(dir, clean)); err == nil {
+				return string(content), nil
+			}
+		}
+		return "", fmt.Errorf("failed to read include %q: %v", path, err)
+	}
+}
+
+// expandIncludes 以被 include 檔案的語法樹取代 include 語句
+func expandIncludes(name string, nodes []jinjaNode, load jinjaLoader, depth int) ([]jinjaNode, error) {
+	errorf := func(pos jinjaPos, format string, args ...interface{}) error {
+		return &Jinja2Error{Name: name, Line: pos.line, Column: pos.col, Message: fmt.Sprintf(format, args...)}
+	}
+
+	var expandList func([]jinjaNode) ([]jinjaNode, error)
+	expandList = func(list []jinjaNode) ([]jinjaNode, error) {
+		for i, node := range list {
+			var err error
+			switch n := node.(type) {
+			case *jinjaInclude:
+				if load == nil {
+					return nil, errorf(n.tag.pos, "include is not available for this template")
+				}
+				if depth >= jinjaMaxIncludeDepth {
+					return nil, errorf(n.tag.pos, "include depth exceeds %d (circular include?)", jinjaMaxIncludeDepth)
+				}
+				source, err := load(n.path)
+				if err != nil {
+					return nil, errorf(n.tag.pos, "%v", err)
+				}
+				included, err := parseJinja2(n.path, source)
+				if err != nil {
+					return nil, err
+				}
+				if included, err = expandIncludes(n.path, included, load, depth+1); err != nil {
+					return nil, err
+				}
+				list[i] = &jinjaIncluded{tag: n.tag, name: n.path, nodes: included}
+			case *jinjaIf:
+				for j := range n.branches {
+					if n.branches[j].body, err = expandList(n.branches[j].body); err != nil {
+						return nil, err
+					}
+				}
+			case *jinjaFor:
+				if n.body, err = expandList(n.body); err != nil {
+					return nil, err
+				}
+				if n.elseBody, err = expandList(n.elseBody); err != nil {
+					return nil, err
+				}
+			case *jinjaMacro:
+				if n.body, err = expandList(n.body); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return list, nil
+	}
+	return expandList(nodes)
+}
+
+// collectSets 收集作用域內 set 的變數名稱；for 迴圈、macro 與 include 的內容屬於新的作用域
+func collectSets(nodes []jinjaNode, names *[]string, seen map[string]bool) {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *jinjaSet:
+			if !seen[n.name] {
+				seen[n.name] = true
+				*names = append(*names, n.name)
+			}
+		case *jinjaIf:
+			for _, branch := range n.branches {
+				collectSets(branch.body, names, seen)
+			}
+		case *jinjaFor:
+			collectSets(n.elseBody, names, seen)
+		}
+	}
+}
+
+func (c *jinjaCompiler) errorf(pos jinjaPos, format string, args ...interface{}) error {
+	return &Jinja2Error{Name: c.name, Line: pos.line, Column: pos.col, Message: fmt.Sprintf(format, args...)}
+}
+
+// pushScope 建立新作用域，並預先宣告 body 中 set 的變數；
+// 與 Jinja2 相同，外層已有的變數以外層的值開始，作用域內的 set 不影響外層
+func (c *jinjaCompiler) pushScope(body []jinjaNode) {
+	var names []string
+	collectSets(body, &names, make(map[string]bool))
+
+	scope := make(jinjaScope)
+	for _, name := range names {
+		if outer, ok := c.lookupVar(name); ok {
+			scope[name] = outer[name]
+			c.out.WriteString(`{{$` + name + ` := $` + name + `}}`)
+			continue
+		}
+		scope[name] = nil
+		c.out.WriteString(`{{$` + name + ` := ""}}`)
+	}
+	c.scopes = append(c.scopes, scope)
+}
+
+func (c *jinjaCompiler) popScope() {
+	c.scopes = c.scopes[:len(c.scopes)-1]
+}
+
+// lookupVar 由內而外查找變數
+func (c *jinjaCompiler) lookupVar(name string) (jinjaScope, bool) {
+	for i := len(c.scopes) - 1; i >= 0; i-- {
+		if _, ok := c.scopes[i][name]; ok {
+			return c.scopes[i], true
+		}
+	}
+	return nil, false
+}
+
+// checkVarName 檢查變數名稱是否可以使用
+func (c *jinjaCompiler) checkVarName(pos jinjaPos, name string) error {
+	if strings.HasPrefix(name, "jinja_") || name == "loop" {
+		return c.errorf(pos, "variable name '%s' is reserved", name)
+	}
+	return nil
+}
+
+// root 返回根資料的程式碼；macro 內以 $jinja_root 存取
+func (c *jinjaCompiler) root() string {
+	if c.inMacro {
+		return "$jinja_root"
+	}
+	return "$"
+}
+
+// action 產生 Go template 動作，保留空白控制與標籤內的換行
+func action(tag jinjaTag, body string) string {
+	left, right := delimiters(tag)
+	return left + body + strings.Repeat("\n", tag.lines) + right
+}
+
+// silentAction 產生不輸出內容的動作（註解），保留空白控制與標籤內的換行
+func silentAction(tag jinjaTag) string {
+	left, right := delimiters(tag)
+	return left + "/*" + strings.Repeat("\n", tag.lines) + "*/" + right
+}
+
+// delimiters 返回帶空白控制的動作分隔符
+func delimiters(tag jinjaTag) (string, string) {
+	left, right := "{{", "}}"
+	if tag.trimL {
+		left = "{{- "
+	}
+	if tag.trimR {
+		right = " -}}"
+	}
+	return left, right
+}
+
+// escapeText 轉義文字中會被 Go template 視為動作開頭的 {
+func escapeText(text string) string {
+	if !strings.Contains(text, "{") {
+		return text
+	}
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '{' && (i+1 == len(text) || text[i+1] == '{') {
+			b.WriteString(`{{"{"}}`)
+			continue
+		}
+		b.WriteByte(text[i])
+	}
+	return b.String()
+}
+
+func (c *jinjaCompiler) compileNodes(nodes []jinjaNode) error {
+	for _, node := range nodes {
+		if err := c.compileNode(node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *jinjaCompiler) compileNode(node jinjaNode) error {
+	switch n := node.(type) {
+	case *jinjaText:
+		c.out.WriteString(escapeText(n.text))
+	case *jinjaRaw:
+		c.out.WriteString(silentAction(n.tag))
+		c.out.WriteString(escapeText(n.text))
+		c.out.WriteString(silentAction(n.end))
+	case *jinjaOutput:
+		return c.compileOutput(n)
+	case *jinjaIf:
+		return c.compileIf(n)
+	case *jinjaFor:
+		return c.compileFor(n)
+	case *jinjaSet:
+		return c.compileSet(n)
+	case *jinjaMacro:
+		return c.compileMacro(n)
+	case *jinjaIncluded:
+		c.out.WriteString(silentAction(n.tag))
+		// 被 include 的模板可以讀取目前的變數，但其中的 set 不影響外層
+		name := c.name
+		c.name = n.name
+		c.pushScope(n.nodes)
+		err := c.compileNodes(n.nodes)
+		c.popScope()
+		c.name = name
+		return err
+	}
+	return nil
+}
+
+func (c *jinjaCompiler) compileOutput(n *jinjaOutput) error {
+	if call, ok := n.expr.(*jinjaCall); ok {
+		if macro, ok := c.macros[call.name]; ok {
+			code, err := c.compileMacroCall(macro, call)
+			if err != nil {
+				return err
+			}
+			c.out.WriteString(action(n.tag, code))
+			return nil
+		}
+	}
+	code, _, err := c.compileExpr(n.expr)
+	if err != nil {
+		return err
+	}
+	c.out.WriteString(action(n.tag, code))
+	return nil
+}
+
+func (c *jinjaCompiler) compileIf(n *jinjaIf) error {
+	c.depth++
+	defer func() { c.depth-- }()
+
+	for i, branch := range n.branches {
+		switch {
+		case i == 0:
+			cond, _, err := c.compileExpr(branch.cond)
+			if err != nil {
+				return err
+			}
+			c.out.WriteString(action(branch.tag, "if "+cond))
+		case branch.cond != nil:
+			cond, _, err := c.compileExpr(branch.cond)
+			if err != nil {
+				return err
+			}
+			c.out.WriteString(action(branch.tag, "else if "+cond))
+		default:
+			c.out.WriteString(action(branch.tag, "else"))
+		}
+		if err := c.compileNodes(branch.body); err != nil {
+			return err
+		}
+	}
+	c.out.WriteString(action(n.end, "end"))
+	return nil
+}
+
+func (c *jinjaCompiler) compileFor(n *jinjaFor) error {
+	for _, name := range []string{n.key, n.val} {
+		if name != "" {
+			if err := c.checkVarName(n.tag.pos, name); err != nil {
+				return err
+			}
+		}
+	}
+
+	iter, iterType, err := c.compileExpr(n.iter)
+	if err != nil {
+		return err
+	}
+	iterType = derefType(iterType)
+
+	mapping := iterType != nil && iterType.Kind() == reflect.Map
+	if iterType == nil && n.key != "" {
+		mapping = true
+	}
+	if mapping && n.key == "" {
+		return c.errorf(n.tag.pos, "iterating a mapping requires two loop variables (for key, value in ...)")
+	}
+	if !mapping && n.key != "" {
+		return c.errorf(n.tag.pos, "two loop variables are only supported when iterating a mapping")
+	}
+
+	loop := jinjaLoop{iter: iter, mapping: mapping}
+	var header string
+	if mapping {
+		header = fmt.Sprintf("range $%s, $%s := %s", n.key, n.val, iter)
+	} else {
+		c.counter++
+		loop.index = fmt.Sprintf("$jinja_loop%d", c.counter)
+		header = fmt.Sprintf("range %s, $%s := %s", loop.index, n.val, iter)
+	}
+
+	c.depth++
+	defer func() { c.depth-- }()
+
+	c.out.WriteString(action(n.tag, header))
+	c.loops = append(c.loops, loop)
+	c.pushScope(n.body)
+	scope := c.scopes[len(c.scopes)-1]
+	var elemType reflect.Type
+	if iterType != nil && (iterType.Kind() == reflect.Slice || iterType.Kind() == reflect.Array || iterType.Kind() == reflect.Map) {
+		elemType = iterType.Elem()
+	}
+	scope[n.val] = elemType
+	if n.key != "" {
+		scope[n.key] = jinjaStringType
+	}
+	err = c.compileNodes(n.body)
+	c.popScope()
+	c.loops = c.loops[:len(c.loops)-1]
+	if err != nil {
+		return err
+	}
+
+	if n.elseTag != nil {
+		c.out.WriteString(action(*n.elseTag, "else"))
+		if err := c.compileNodes(n.elseBody); err != nil {
+			return err
+		}
+	}
+	c.out.WriteString(action(n.end, "end"))
+	return nil
+}
+
+func (c *jinjaCompiler) compileSet(n *jinjaSet) error {
+	if err := c.checkVarName(n.tag.pos, n.name); err != nil {
+		return err
+	}
+	if call, ok := n.expr.(*jinjaCall); ok {
+		if _, ok := c.macros[call.name]; ok {
+			return c.errorf(call.pos, "macro '%s' can only be called as {{ %s(...) }}", call.name, call.name)
+		}
+	}
+	code, typ, err := c.compileExpr(n.expr)
+	if err != nil {
+		return err
+	}
+	scope, ok := c.lookupVar(n.name)
+	if !ok {
+		// 不應發生：set 變數在作用域開頭已預先宣告
+		return c.errorf(n.tag.pos, "variable '%s' is not declared", n.name)
+	}
+	scope[n.name] = typ
+	c.out.WriteString(action(n.tag, fmt.Sprintf("$%s = %s", n.name, code)))
+	return nil
+}
+
+func (c *jinjaCompiler) compileMacro(n *jinjaMacro) error {
+	if c.depth > 0 || c.inMacro {
+		return c.errorf(n.tag.pos, "macros must be defined at the top level")
+	}
+	if _, ok := c.macros[n.name]; ok {
+		return c.errorf(n.tag.pos, "macro '%s' is already defined", n.name)
+	}
+	for _, param := range n.params {
+		if err := c.checkVarName(n.tag.pos, param.name); err != nil {
+			return err
+		}
+	}
+	c.macros[n.name] = n
+
+	// define 的內容先讀取根資料與參數；空白控制放在最後一個動作上
+	prelude := "$jinja_root := .Root"
+	for _, param := range n.params {
+		prelude += fmt.Sprintf("}}{{$%s := .%s", param.name, param.name)
+	}
+	defineTag := n.tag
+	defineTag.trimR, defineTag.lines = false, 0
+	c.out.WriteString(action(defineTag, fmt.Sprintf("define %q", jinjaMacroPrefix+n.name)))
+	c.out.WriteString(action(jinjaTag{trimR: n.tag.trimR, lines: n.tag.lines}, prelude))
+
+	// macro 內只能存取參數與根資料
+	loops, scopes := c.loops, c.scopes
+	c.loops, c.scopes = nil, nil
+	c.inMacro = true
+	c.pushScope(n.body)
+	scope := c.scopes[len(c.scopes)-1]
+	for _, param := range n.params {
+		scope[param.name] = nil
+	}
+	err := c.compileNodes(n.body)
+	c.popScope()
+	c.inMacro = false
+	c.loops, c.scopes = loops, scopes
+	if err != nil {
+		return err
+	}
+
+	c.out.WriteString(action(n.end, "end"))
+	return nil
+}
+
+// compileMacroCall 編譯 macro 呼叫為 template 動作；未提供的參數使用預設值或空字串
+func (c *jinjaCompiler) compileMacroCall(macro *jinjaMacro, call *jinjaCall) (string, error) {
+	values := make(map[string]string)
+	positional := 0
+	for _, arg := range call.args {
+		if kw, ok := arg.(*jinjaKwarg); ok {
+			found := false
+			for _, param := range macro.params {
+				if param.name == kw.name {
+					found = true
+				}
+			}
+			if !found {
+				return "", c.errorf(kw.pos, "macro '%s' has no parameter '%s'", macro.name, kw.name)
+			}
+			code, _, err := c.compileExpr(kw.value)
+			if err != nil {
+				return "", err
+			}
+			values[kw.name] = code
+			continue
+		}
+		if positional >= len(macro.params) {
+			return "", c.errorf(exprPos(arg), "macro '%s' takes %d arguments", macro.name, len(macro.params))
+		}
+		code, _, err := c.compileExpr(arg)
+		if err != nil {
+			return "", err
+		}
+		values[macro.params[positional].name] = code
+		positional++
+	}
+
+	args := []string{c.root()}
+	for _, param := range macro.params {
+		code, ok := values[param.name]
+		if !ok {
+			code = `""`
+			if param.def != nil {
+				var err error
+				if code, _, err = c.compileExpr(param.def); err != nil {
+					return "", err
+				}
+			}
+		}
+		args = append(args, strconv.Quote(param.name), code)
+	}
+	return fmt.Sprintf("template %q (jinja_args %s)", jinjaMacroPrefix+macro.name, strings.Join(args, " ")), nil
+}
+
+// compileExpr 編譯表達式，返回 Go template 程式碼與靜態型別（未知為 nil）
+func (c *jinjaCompiler) compileExpr(e jinjaExpr) (string, reflect.Type, error) {
+	switch e := e.(type) {
+	case *jinjaLiteral:
+		switch e.kind {
+		case tokString:
+			return strconv.Quote(e.value), jinjaStringType, nil
+		case tokNumber:
+			if strings.Contains(e.value, ".") {
+				return e.value, reflect.TypeOf(0.0), nil
+			}
+			return e.value, jinjaIntType, nil
+		}
+		if e.value == "none" {
+			return "nil", nil, nil
+		}
+		return e.value, jinjaBoolType, nil
+
+	case *jinjaName:
+		return c.compileName(e)
+
+	case *jinjaAttr:
+		if name, ok := e.x.(*jinjaName); ok && name.name == "loop" {
+			if _, found := c.lookupVar("loop"); !found {
+				return c.compileLoopAttr(e)
+			}
+		}
+		x, typ, err := c.compileExpr(e.x)
+		if err != nil {
+			return "", nil, err
+		}
+		if _, err := strconv.Atoi(e.name); err == nil {
+			return c.itemAccess(x, typ, e.name)
+		}
+		return c.attrAccess(e, x, typ)
+
+	case *jinjaItem:
+		x, typ, err := c.compileExpr(e.x)
+		if err != nil {
+			return "", nil, err
+		}
+		key, _, err := c.compileExpr(e.key)
+		if err != nil {
+			return "", nil, err
+		}
+		return c.itemAccess(x, typ, key)
+
+	case *jinjaFilter:
+		return c.compileFilter(e)
+
+	case *jinjaCall:
+		return c.compileCall(e)
+
+	case *jinjaKwarg:
+		return "", nil, c.errorf(e.pos, "keyword arguments are only supported in macro calls")
+
+	case *jinjaUnary:
+		x, typ, err := c.compileExpr(e.x)
+		if err != nil {
+			return "", nil, err
+		}
+		if e.op == "not" {
+			return "(not " + x + ")", jinjaBoolType, nil
+		}
+		if lit, ok := e.x.(*jinjaLiteral); ok && lit.kind == tokNumber {
+			return "-" + x, typ, nil
+		}
+		return fmt.Sprintf(`(jinja_math "-" 0 %s)`, x), typ, nil
+
+	case *jinjaBinary:
+		l, lt, err := c.compileExpr(e.l)
+		if err != nil {
+			return "", nil, err
+		}
+		r, rt, err := c.compileExpr(e.r)
+		if err != nil {
+			return "", nil, err
+		}
+		switch e.op {
+		case "and", "or":
+			return fmt.Sprintf("(%s %s %s)", e.op, l, r), nil, nil
+		case "==", "!=", "<", ">", "<=", ">=":
+			fn := map[string]string{"==": "eq", "!=": "ne", "<": "lt", ">": "gt", "<=": "le", ">=": "ge"}[e.op]
+			return fmt.Sprintf("(%s %s %s)", fn, l, r), jinjaBoolType, nil
+		case "~":
+			return fmt.Sprintf(`(printf "%%v%%v" %s %s)`, l, r), jinjaStringType, nil
+		case "+":
+			if lt == jinjaStringType && rt == jinjaStringType {
+				return fmt.Sprintf(`(printf "%%s%%s" %s %s)`, l, r), jinjaStringType, nil
+			}
+		}
+		typ := lt
+		if lt != rt {
+			typ = nil
+		}
+		return fmt.Sprintf("(jinja_math %q %s %s)", e.op, l, r), typ, nil
+	}
+	return "", nil, c.errorf(exprPos(e), "unsupported expression")
+}
+
+// compileName 編譯變數名稱：區域變數、根變數（TemplateData 欄位）
+func (c *jinjaCompiler) compileName(e *jinjaName) (string, reflect.Type, error) {
+	if scope, ok := c.lookupVar(e.name); ok {
+		return "$" + e.name, scope[e.name], nil
+	}
+	if e.name == "loop" {
+		return "", nil, c.errorf(e.pos, "'loop' is only available as loop.<attribute> inside a for loop")
+	}
+	if field, ok := findField(jinjaRootType, e.name); ok {
+		return c.root() + "." + field.Name, field.Type, nil
+	}
+	if _, ok := c.macros[e.name]; ok {
+		return "", nil, c.errorf(e.pos, "macro '%s' can only be called as {{ %s(...) }}", e.name, e.name)
+	}
+	return "", nil, c.errorf(e.pos, "undefined variable '%s'", e.name)
+}
+
+// compileLoopAttr 編譯 loop.index 等迴圈屬性
+func (c *jinjaCompiler) compileLoopAttr(e *jinjaAttr) (string, reflect.Type, error) {
+	if len(c.loops) == 0 {
+		return "", nil, c.errorf(e.pos, "'loop' is only available inside a for loop")
+	}
+	loop := c.loops[len(c.loops)-1]
+	if loop.mapping {
+		return "", nil, c.errorf(e.pos, "loop.%s is not available when iterating a mapping", e.name)
+	}
+	switch e.name {
+	case "index":
+		return "(add " + loop.index + " 1)", jinjaIntType, nil
+	case "index0":
+		return loop.index, jinjaIntType, nil
+	case "first":
+		return "(eq " + loop.index + " 0)", jinjaBoolType, nil
+	case "last":
+		return fmt.Sprintf("(eq (add %s 1) (len %s))", loop.index, loop.iter), jinjaBoolType, nil
+	case "length":
+		return "(len " + loop.iter + ")", jinjaIntType, nil
+	case "revindex":
+		return fmt.Sprintf(`(jinja_math "-" (len %s) %s)`, loop.iter, loop.index), jinjaIntType, nil
+	case "revindex0":
+		return fmt.Sprintf(`(jinja_math "-" (jinja_math "-" (len %s) %s) 1)`, loop.iter, loop.index), jinjaIntType, nil
+	}
+	return "", nil, c.errorf(e.pos, "unsupported loop attribute '%s'", e.name)
+}
+
+// attrAccess 依靜態型別編譯屬性存取
+func (c *jinjaCompiler) attrAccess(e *jinjaAttr, x string, typ reflect.Type) (string, reflect.Type, error) {
+	typ = derefType(typ)
+	if typ == nil {
+		return fmt.Sprintf("(jinja_item %s %q)", x, e.name), nil, nil
+	}
+	switch typ.Kind() {
+	case reflect.Struct:
+		field, ok := findField(typ, e.name)
+		if !ok {
+			return "", nil, c.errorf(e.pos, "unknown attribute '%s'", e.name)
+		}
+		return x + "." + field.Name, field.Type, nil
+	case reflect.Map:
+		return c.itemAccess(x, typ, strconv.Quote(e.name))
+	}
+	return "", nil, c.errorf(e.pos, "attribute '%s' is not available on a %s value", e.name, typ.Kind())
+}
+
+// itemAccess 編譯下標存取；map[string]string 使用 index，其他使用 jinja_item
+func (c *jinjaCompiler) itemAccess(x string, typ reflect.Type, key string) (string, reflect.Type, error) {
+	typ = derefType(typ)
+	if typ != nil && typ.Kind() == reflect.Map && typ.Key().Kind() == reflect.String && typ.Elem().Kind() == reflect.String {
+		return fmt.Sprintf("(index %s %s)", x, key), jinjaStringType, nil
+	}
+	var elem reflect.Type
+	if typ != nil && (typ.Kind() == reflect.Map || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) {
+		elem = typ.Elem()
+	}
+	return fmt.Sprintf("(jinja_item %s %s)", x, key), elem, nil
+}
+
+// compileFilter 編譯過濾器為函數呼叫
+func (c *jinjaCompiler) compileFilter(e *jinjaFilter) (string, reflect.Type, error) {
+	x, typ, err := c.compileExpr(e.x)
+	if err != nil {
+		return "", nil, err
+	}
+	fn := e.name
+	if alias, ok := jinjaFilterAliases[fn]; ok {
+		fn = alias
+	}
+	args, err := c.compileArgs(e.args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch {
+	case fn == "len":
+		return "(len " + x + ")", jinjaIntType, nil
+	case e.name == "string":
+		return `(printf "%v" ` + x + ")", jinjaStringType, nil
+	case c.funcs[fn] == nil:
+		return "", nil, c.errorf(e.pos, "unknown filter '%s'", e.name)
+	case jinjaPlatformFuncs[fn]:
+		args = append([]string{c.root() + ".Platform", x}, args...)
+	case jinjaValueFirstFuncs[fn]:
+		args = append([]string{x}, args...)
+	default:
+		args = append(args, x)
+	}
+	return "(" + fn + " " + strings.Join(args, " ") + ")", c.resultType(fn, typ), nil
+}
+
+// compileCall 編譯函數呼叫；平台格式化函數自動帶入目前平台
+func (c *jinjaCompiler) compileCall(e *jinjaCall) (string, reflect.Type, error) {
+	if _, ok := c.macros[e.name]; ok {
+		return "", nil, c.errorf(e.pos, "macro '%s' can only be called as {{ %s(...) }}", e.name, e.name)
+	}
+	if e.name != "len" && c.funcs[e.name] == nil {
+		return "", nil, c.errorf(e.pos, "unknown function '%s'", e.name)
+	}
+	args, err := c.compileArgs(e.args)
+	if err != nil {
+		return "", nil, err
+	}
+	if jinjaPlatformFuncs[e.name] {
+		args = append([]string{c.root() + ".Platform"}, args...)
+	}
+
+	var argType reflect.Type
+	if len(e.args) > 0 {
+		_, argType, _ = c.compileExpr(e.args[len(e.args)-1])
+	}
+	if e.name == "len" {
+		return "(len " + strings.Join(args, " ") + ")", jinjaIntType, nil
+	}
+	return "(" + e.name + " " + strings.Join(args, " ") + ")", c.resultType(e.name, argType), nil
+}
+
+// compileArgs 編譯位置參數
+func (c *jinjaCompiler) compileArgs(exprs []jinjaExpr) ([]string, error) {
+	args := make([]string, 0, len(exprs))
+	for _, arg := range exprs {
+		if kw, ok := arg.(*jinjaKwarg); ok {
+			return nil, c.errorf(kw.pos, "keyword arguments are only supported in macro calls")
+		}
+		code, _, err := c.compileExpr(arg)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, code)
+	}
+	return args, nil
+}
+
+// resultType 推斷函數結果的靜態型別；返回 interface{} 的函數依被操作值推斷
+func (c *jinjaCompiler) resultType(fn string, valueType reflect.Type) reflect.Type {
+	switch fn {
+	case "first", "last":
+		if valueType = derefType(valueType); valueType != nil && (valueType.Kind() == reflect.Slice || valueType.Kind() == reflect.Array) {
+			return valueType.Elem()
+		}
+		return nil
+	case "default":
+		return valueType
+	}
+	fnType := reflect.TypeOf(c.funcs[fn])
+	if fnType == nil || fnType.Kind() != reflect.Func || fnType.NumOut() == 0 || fnType.Out(0).Kind() == reflect.Interface {
+		return nil
+	}
+	return fnType.Out(0)
+}
+
+// derefType 取得指標指向的型別
+func derefType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// normalizeName 比對屬性名稱時忽略大小寫與底線，例如 alert_name、alertName 與 AlertName 相同
+func normalizeName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+// findField 以正規化名稱查找結構欄位
+func findField(t reflect.Type, name string) (reflect.StructField, bool) {
+	t = derefType(t)
+	if t == nil || t.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+	want := normalizeName(name)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.IsExported() && normalizeName(field.Name) == want {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// jinjaArgs 建立 macro 的參數：Root 為根資料，其餘為參數名稱與值
+func jinjaArgs(root interface{}, pairs ...interface{}) (map[string]interface{}, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("jinja_args requires name and value pairs")
+	}
+	args := map[string]interface{}{"Root": root}
+	for i := 0; i < len(pairs); i += 2 {
+		name, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("jinja_args parameter names must be strings")
+		}
+		args[name] = pairs[i+1]
+	}
+	return args, nil
+}
+
+// jinjaGetItem 在執行時存取屬性或下標：map 以 key、list 以索引（可為負數）、結構以正規化欄位名稱；
+// 不存在時與 Jinja2 的 undefined 相同，輸出空字串
+func jinjaGetItem(container, key interface{}) interface{} {
+	v := reflect.ValueOf(container)
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return ""
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		k := reflect.ValueOf(key)
+		if !k.IsValid() || !k.Type().ConvertibleTo(v.Type().Key()) {
+			return ""
+		}
+		if item := v.MapIndex(k.Convert(v.Type().Key())); item.IsValid() {
+			return item.Interface()
+		}
+	case reflect.Slice, reflect.Array, reflect.String:
+		i, ok := toInt(key)
+		if !ok {
+			return ""
+		}
+		if i < 0 {
+			i += v.Len()
+		}
+		if i >= 0 && i < v.Len() {
+			return v.Index(i).Interface()
+		}
+	case reflect.Struct:
+		if name, ok := key.(string); ok {
+			if field, ok := findField(v.Type(), name); ok {
+				return v.FieldByIndex(field.Index).Interface()
+			}
+		}
+	}
+	return ""
+}
+
+// jinjaMath 執行 + - * / % 運算；兩邊都是整數時結果為整數，/ 與 Jinja2 相同總是返回浮點數
+func jinjaMath(op string, a, b interface{}) (interface{}, error) {
+	ai, aInt := toInt(a)
+	bi, bInt := toInt(b)
+	if aInt && bInt && op != "/" {
+		switch op {
+		case "+":
+			return ai + bi, nil
+		case "-":
+			return ai - bi, nil
+		case "*":
+			return ai * bi, nil
+		case "%":
+			if bi == 0 {
+				return nil, fmt.Errorf("integer modulo by zero")
+			}
+			return ai % bi, nil
+		}
+	}
+
+	af, aOK := toFloat(a)
+	bf, bOK := toFloat(b)
+	if !aOK || !bOK {
+		return nil, fmt.Errorf("unsupported operand types for %s: %T and %T", op, a, b)
+	}
+	switch op {
+	case "+":
+		return af + bf, nil
+	case "-":
+		return af - bf, nil
+	case "*":
+		return af * bf, nil
+	case "/":
+		if bf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return af / bf, nil
+	}
+	return nil, fmt.Errorf("unsupported operator %s", op)
+}
+
+// toInt 將整數型別轉為 int
+func toInt(value interface{}) (int, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(v.Uint()), true
+	}
+	return 0, false
+}
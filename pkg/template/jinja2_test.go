@@ -0,0 +1,205 @@
+package template
+
+import (
+	"fmt"
+	"testing"
+)
+
+// jinjaTestFiles include 使用的模板檔案
+var jinjaTestFiles = map[string]string{
+	"header.j2":   "[{{ alert_name }}]",
+	"nested.j2":   `<{% include "header.j2" %}>`,
+	"circular.j2": `{% include "circular.j2" %}`,
+	"broken.j2":   "ok\n{% if %}",
+}
+
+func jinjaTestLoader(path string) (string, error) {
+	if content, ok := jinjaTestFiles[path]; ok {
+		return content, nil
+	}
+	return "", fmt.Errorf("failed to read include %q: not found", path)
+}
+
+// jinjaTestData 兩個觸發中的警報，第一個有 env 標籤
+func jinjaTestData() TemplateData {
+	return TemplateData{
+		Status:      "firing",
+		AlertName:   "HighCPU",
+		TotalAlerts: 2,
+		FiringCount: 2,
+		Alerts: []AlertData{
+			{Status: "firing", Labels: map[string]string{"pod": "p1", "env": "prod"}},
+			{Status: "firing", Labels: map[string]string{"pod": "p2"}},
+		},
+	}
+}
+
+// renderJinja2 編譯、解析並執行 Jinja2 模板
+func renderJinja2(te *TemplateEngine, source string, data TemplateData) (string, error) {
+	code, err := te.compileJinja2("t.j2", source, jinjaTestLoader)
+	if err != nil {
+		return "", err
+	}
+	tmpl, err := te.parse("t.j2", code)
+	if err != nil {
+		return "", err
+	}
+	return te.execute(tmpl, data)
+}
+
+func TestJinja2Compile(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			"if elif else",
+			`{% if firing_count > 1 %}many{% elif firing_count == 1 %}one{% else %}none{% endif %}`,
+			`{{if (gt $.FiringCount 1)}}many{{else if (eq $.FiringCount 1)}}one{{else}}none{{end}}`,
+		},
+		{
+			"for with loop.index",
+			`{% for a in alerts %}{{ loop.index }}:{{ a.labels.pod }}{% endfor %}`,
+			`{{range $jinja_loop1, $a := $.Alerts}}{{(add $jinja_loop1 1)}}:{{(index $a.Labels "pod")}}{{end}}`,
+		},
+		{
+			"set is declared at the start of its scope",
+			`{% if true %}{% set greeting = "hi " ~ alert_name %}{% endif %}{{ greeting }}`,
+			`{{$greeting := ""}}{{if true}}{{$greeting = (printf "%v%v" "hi " $.AlertName)}}{{end}}{{$greeting}}`,
+		},
+		{
+			"macro with default argument",
+			`{% macro row(k, v="?") %}{{ k }}={{ v }}{% endmacro %}{{ row("a") }}`,
+			`{{define "jinja_macro_row"}}{{$jinja_root := .Root}}{{$k := .k}}{{$v := .v}}{{$k}}={{$v}}{{end}}{{template "jinja_macro_row" (jinja_args $ "k" "a" "v" "?")}}`,
+		},
+		{
+			"filters",
+			`{{ alert_name | truncate(4) | upper }}{{ namespace | default("none") }}{{ alerts | length }}`,
+			`{{(upper (truncate 4 $.AlertName))}}{{(default "none" $.Namespace)}}{{(len $.Alerts)}}`,
+		},
+		{
+			"include keeps line numbers",
+			`{% include "header.j2" %}`,
+			`{{/**/}}[{{$.AlertName}}]`,
+		},
+		{
+			"whitespace control",
+			"a\n  {%- if true -%}\n  b\n{%- endif %}",
+			"a\n  {{- if true -}}\n  b\n{{- end}}",
+		},
+	}
+
+	te := NewTemplateEngine()
+	for _, tt := range tests {
+		got, err := te.compileJinja2("t.j2", tt.source, jinjaTestLoader)
+		if err != nil {
+			t.Errorf("%s: compileJinja2 returned error: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: compileJinja2 =\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestJinja2Render(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"if", `{% if firing_count > 1 %}many{% elif firing_count == 1 %}one{% else %}none{% endif %}`, "many"},
+		{"elif", `{% if firing_count > 5 %}many{% elif firing_count == 2 %}two{% else %}none{% endif %}`, "two"},
+		{"else", `{% if resolved_count %}some{% elif firing_count > 5 %}many{% else %}none{% endif %}`, "none"},
+		{"and or not", `{% if status == "firing" and not resolved_count or false %}yes{% endif %}`, "yes"},
+		{"for with loop attributes", `{% for a in alerts %}{{ loop.index }}/{{ loop.length }}:{{ a.labels.pod }}{% if not loop.last %},{% endif %}{% endfor %}`, "1/2:p1,2/2:p2"},
+		{"loop index0, first and revindex", `{% for a in alerts %}{{ loop.index0 }}{{ loop.first }}{{ loop.revindex }} {% endfor %}`, "0true2 1false1 "},
+		{"for over mapping is sorted", `{% for k, v in alerts[0].labels %}{{ k }}={{ v }} {% endfor %}`, "env=prod pod=p1 "},
+		{"set", `{% set greeting = "hi " ~ alert_name %}{{ greeting | upper }}`, "HI HIGHCPU"},
+		{"set inside for does not leak", `{% set n = 0 %}{% for a in alerts %}{% set n = loop.index %}{% endfor %}{{ n }}`, "0"},
+		{"set inside if is visible after it", `{% if true %}{% set x = "in" %}{% endif %}{{ x }}`, "in"},
+		{"macro", `{% macro row(k, v="?") %}{{ k }}={{ v }};{% endmacro %}{{ row("a", "b") }}{{ row("c") }}{{ row(k="d", v=status) }}`, "a=b;c=?;d=firing;"},
+		{"macro reads root data", `{% macro name() %}{{ alert_name }}{% endmacro %}{{ name() }}`, "HighCPU"},
+		{"include", `{% include "header.j2" %} {% include "nested.j2" %}`, "[HighCPU] <[HighCPU]>"},
+		{"filters", `{{ alert_name | truncate(4) }}|{{ namespace | default("none") }}|{{ alerts | length }}|{{ status | title }}`, "Hig…|none|2|Firing"},
+		{"subscript and attribute", `{{ alerts[1].labels["pod"] }} {{ alerts[0].status }}`, "p2 firing"},
+		{"arithmetic", `{{ 1 + 2 * 3 }} {{ 7 % 3 }} {{ total_alerts - 1 }}`, "7 1 1"},
+		{"raw", `{% raw %}{{ not a var }}{% endraw %}`, "{{ not a var }}"},
+		{"comment", `a{# hidden #}b`, "ab"},
+		{"whitespace control", "line1\n  {%- if true %}\n  x\n  {%- endif %}", "line1\n  x"},
+	}
+
+	te := NewTemplateEngine()
+	for _, tt := range tests {
+		got, err := renderJinja2(te, tt.source, jinjaTestData())
+		if err != nil {
+			t.Errorf("%s: render returned error: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// 沒有警報時輸出 for 的 else 區塊
+	data := jinjaTestData()
+	data.Alerts = nil
+	if got, err := renderJinja2(te, `{% for a in alerts %}x{% else %}empty{% endfor %}`, data); err != nil || got != "empty" {
+		t.Errorf("for else = %q, %v, want %q", got, err, "empty")
+	}
+}
+
+func TestJinja2Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		err    string
+	}{
+		{"unclosed if", `{% if firing_count %}`, "template: t.j2:1:1: unclosed 'if' (missing endif)"},
+		{"mismatched end tag", "a\n{% for a in alerts %}\n{% endif %}", "template: t.j2:3:1: unexpected 'endif', expected 'endfor'"},
+		{"elif after else", "{% if true %}{% else %}\n{% elif false %}{% endif %}", "template: t.j2:2:1: unexpected 'elif' after 'else'"},
+		{"stray else", `{% else %}`, "template: t.j2:1:1: unexpected 'else'"},
+		{"missing filter name", `{{ alert_name | }}`, "template: t.j2:1:17: expected a name, found '}}'"},
+		{"unsupported statement", `{% extends "base.j2" %}`, "template: t.j2:1:4: unsupported statement 'extends'"},
+		{"inline if", "x\n  {{ a if b else c }}", "template: t.j2:2:8: unsupported inline if expression"},
+		{"is test", `{{ x is defined }}`, "template: t.j2:1:6: unsupported operator 'is'"},
+		{"list literal", `{{ [1, 2] }}`, "template: t.j2:1:4: unsupported list literal"},
+		{"unterminated string", `{{ 'abc }}`, "template: t.j2:1:4: unterminated string"},
+		{"unclosed comment", `{# never closed`, "template: t.j2:1:1: unclosed comment"},
+		{"unknown function", `{{ nosuchfunc(1) }}`, "template: t.j2:1:4: unknown function 'nosuchfunc'"},
+		{"loop outside for", `{{ loop.index }}`, "template: t.j2:1:9: 'loop' is only available inside a for loop"},
+		{"two loop variables over a list", `{% for k, v in alerts %}{% endfor %}`, "template: t.j2:1:1: two loop variables are only supported when iterating a mapping"},
+		{"too many macro arguments", `{% macro m(a) %}{% endmacro %}{{ m(1, 2) }}`, "template: t.j2:1:39: macro 'm' takes 1 arguments"},
+		{"unknown macro parameter", `{% macro m(a) %}{% endmacro %}{{ m(b=1) }}`, "template: t.j2:1:36: macro 'm' has no parameter 'b'"},
+		{"nested macro", `{% if true %}{% macro m() %}{% endmacro %}{% endif %}`, "template: t.j2:1:14: macros must be defined at the top level"},
+		{"missing include", `{% include "missing.j2" %}`, `template: t.j2:1:1: failed to read include "missing.j2": not found`},
+		{"error inside include reports the included file", `{% include "broken.j2" %}`, "template: broken.j2:2:7: unexpected '%}' in expression"},
+		{"circular include", `{% include "circular.j2" %}`, "template: circular.j2:1:1: include depth exceeds 10 (circular include?)"},
+	}
+
+	te := NewTemplateEngine()
+	for _, tt := range tests {
+		_, err := te.compileJinja2("t.j2", tt.source, jinjaTestLoader)
+		if err == nil {
+			t.Errorf("%s: compileJinja2 returned no error, want %q", tt.name, tt.err)
+			continue
+		}
+		if err.Error() != tt.err {
+			t.Errorf("%s: compileJinja2 error = %q, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestJinja2ExecutionErrorPosition(t *testing.T) {
+	// 編譯結果與原始碼的行號一致，執行錯誤的行號可以直接對應 .j2 檔案
+	source := "{{ alert_name }}\n{% for a in alerts %}\n  {{ a.labels.pod | truncate(\"x\") }}\n{% endfor %}"
+	_, err := renderJinja2(NewTemplateEngine(), source, jinjaTestData())
+	if err == nil {
+		t.Fatal("render returned no error")
+	}
+	renderErr := NewRenderError(StageExec, err)
+	if renderErr.Line != 3 {
+		t.Errorf("execution error line = %d, want 3 (%v)", renderErr.Line, err)
+	}
+}
@@ -0,0 +1,87 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"alert-webhooks/pkg/logger"
+)
+
+// PartialsDir 模板目錄中共用區塊的子目錄名稱。
+// 其中的 .tmpl 檔案以 {{ define "name" }} 定義區塊，所有模板集與語言的 .tmpl 模板都可以用
+// {{ template "name" . }} 呼叫；每個檔案本身也可以用檔名呼叫，例如 {{ template "footer.tmpl" . }}。
+// 語言模板中同名的 define 會覆寫共用區塊，.j2 模板則以 {% include "partials/xxx.j2" %} 共用內容
+const PartialsDir = "partials"
+
+// SlackBlocksTemplate Slack 使用 template_mode: blocks 時執行的區塊名稱，輸出為 Block Kit blocks 的 JSON 陣列；
+// 預設版面定義在 partials/slack_blocks.tmpl，模板集可用同名的 define 覆寫
+const SlackBlocksTemplate = "slack.blocks"
+
+// partialFiles 返回 partials 目錄中的 .tmpl 檔案（依檔名排序），目錄不存在時返回空列表
+func partialFiles(templateDir string) ([]string, error) {
+	dir := filepath.Join(templateDir, PartialsDir)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || filepath.Ext(name) != ".tmpl" {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	return files, nil
+}
+
+// parsePartials 將 partials 檔案解析到同一個模板集合；多個檔案定義同名區塊時以排序在後的檔案為準
+func (te *TemplateEngine) parsePartials(files []string) (*template.Template, error) {
+	partials := template.New(PartialsDir).Funcs(te.funcMap())
+	for _, path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := partials.New(filepath.Base(path)).Parse(string(content)); err != nil {
+			return nil, fmt.Errorf("failed to parse partial %s: %v", path, err)
+		}
+	}
+	return partials, nil
+}
+
+// loadPartials 載入模板目錄的共用區塊；載入失敗時不使用共用區塊，呼叫共用區塊的模板會在執行時失敗
+func (te *TemplateEngine) loadPartials(templateDir string) {
+	te.partials = nil
+
+	files, err := partialFiles(templateDir)
+	if err != nil {
+		logger.Warn("Failed to scan template partials", "template_engine",
+			logger.String("template_dir", templateDir),
+			logger.Err(err))
+		return
+	}
+	if len(files) == 0 {
+		return
+	}
+
+	partials, err := te.parsePartials(files)
+	if err != nil {
+		logger.Warn("Failed to load template partials", "template_engine",
+			logger.String("template_dir", templateDir),
+			logger.Err(err))
+		return
+	}
+
+	te.partials = partials
+	logger.Info("Template partials loaded", "template_engine",
+		logger.String("template_dir", templateDir),
+		logger.Int("files", len(files)))
+}
@@ -0,0 +1,46 @@
+package template
+
+import (
+	"strings"
+
+	"alert-webhooks/config"
+)
+
+// SelectTemplateSet 決定通知使用的具名模板集，優先順序：
+// 請求或路由指定的名稱 > 提供者 level_templates 中該 level 的設定 > 提供者的 template_name；
+// 都未設定時返回空字串（預設模板）
+func SelectTemplateSet(requested, provider, level string) string {
+	if name := strings.TrimSpace(requested); name != "" {
+		return name
+	}
+
+	var name string
+	var levelTemplates map[string]string
+	switch strings.ToLower(provider) {
+	case "telegram":
+		name, levelTemplates = config.Telegram.TemplateName, config.Telegram.LevelTemplates
+	case "slack":
+		name, levelTemplates = config.Slack.TemplateName, config.Slack.LevelTemplates
+	case "discord":
+		name, levelTemplates = config.Conf.Discord.TemplateName, config.Conf.Discord.LevelTemplates
+	}
+
+	if level != "" {
+		want := normalizeLevel(level)
+		for key, levelName := range levelTemplates {
+			if normalizeLevel(key) == want && strings.TrimSpace(levelName) != "" {
+				return strings.TrimSpace(levelName)
+			}
+		}
+	}
+	return strings.TrimSpace(name)
+}
+
+// normalizeLevel 將 L2、l2 與 2 視為相同的 level，其他名稱轉為小寫
+func normalizeLevel(level string) string {
+	level = strings.ToLower(strings.TrimSpace(level))
+	if len(level) > 1 && level[0] == 'l' && level[1] >= '0' && level[1] <= '9' {
+		return level[1:]
+	}
+	return level
+}
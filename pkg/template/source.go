@@ -0,0 +1,63 @@
+package template
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// 模板語法
+const (
+	SyntaxGo     = "go"
+	SyntaxJinja2 = "jinja2"
+)
+
+// 模板錯誤階段
+const (
+	StageParse = "parse"
+	StageExec  = "exec"
+)
+
+// RenderError 模板解析或執行錯誤，Line 與 Column 無法取得時為 0
+type RenderError struct {
+	Stage   string `json:"stage"` // parse 或 exec
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// templateErrorPattern 比對 text/template 錯誤中的位置，例如 template: inline:3:14: executing ...
+var templateErrorPattern = regexp.MustCompile(`template: [^:\s]+:(\d+)(?::(\d+))?: (.*)`)
+
+// NewRenderError 從 text/template 錯誤中取出行號、欄位與訊息
+func NewRenderError(stage string, err error) RenderError {
+	renderErr := RenderError{Stage: stage, Message: err.Error()}
+	if match := templateErrorPattern.FindStringSubmatch(err.Error()); match != nil {
+		renderErr.Line, _ = strconv.Atoi(match[1])
+		renderErr.Column, _ = strconv.Atoi(match[2])
+		renderErr.Message = match[3]
+	}
+	return renderErr
+}
+
+// ParseSource 解析模板原始碼；syntax 為 jinja2 時先編譯為 Go template 語法，include 相對於已載入的模板目錄，空值視為 Go template
+func (te *TemplateEngine) ParseSource(name, source, syntax string) (*template.Template, error) {
+	if strings.EqualFold(syntax, SyntaxJinja2) {
+		var load jinjaLoader
+		if te.dir != "" {
+			load = includeLoader(te.dir)
+		}
+		var err error
+		if source, err = te.compileJinja2(name, source, load); err != nil {
+			return nil, err
+		}
+	}
+	return te.parse(name, source)
+}
+
+// ExecuteForPlatform 為特定平台執行已解析的模板，FormatOptions 為空時使用配置的預設值
+func (te *TemplateEngine) ExecuteForPlatform(tmpl *template.Template, platform string, data TemplateData) (string, error) {
+	data.Platform = platform
+	return te.execute(tmpl, data)
+}
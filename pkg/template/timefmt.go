@@ -0,0 +1,253 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/telegramhtml"
+)
+
+// defaultTimeLayout format_time 未設定 layout 時使用的時間格式
+const defaultTimeLayout = "2006-01-02 15:04:05"
+
+// timeUnits time_ago 與 alert_duration 使用的時間單位（由大到小）；翻譯目錄沒有該單位時使用 fallback
+var timeUnits = []struct {
+	key      string
+	size     time.Duration
+	fallback string
+}{
+	{"time.unit.day", 24 * time.Hour, "{count}d"},
+	{"time.unit.hour", time.Hour, "{count}h"},
+	{"time.unit.minute", time.Minute, "{count}m"},
+	{"time.unit.second", time.Second, "{count}s"},
+}
+
+// timeLocations 已載入的時區：IANA 名稱 -> *time.Location，無效的時區為 nil，只記錄一次警告
+var timeLocations sync.Map
+
+// timeLocation 載入並快取時區，無效的時區返回 nil
+func timeLocation(zone string) *time.Location {
+	if cached, ok := timeLocations.Load(zone); ok {
+		return cached.(*time.Location)
+	}
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		logger.Warn("Invalid time zone in time_format, keeping the original time zone", "template_engine",
+			logger.String("timezone", zone),
+			logger.Err(err))
+		loc = nil
+	}
+	timeLocations.Store(zone, loc)
+	return loc
+}
+
+// providerTimeFormat 返回提供者的 time_format 配置
+func providerTimeFormat(platform string) config.TimeFormatConf {
+	switch strings.ToLower(platform) {
+	case "telegram":
+		return config.Telegram.TimeFormat
+	case "slack":
+		return config.Slack.TimeFormat
+	case "discord":
+		return config.Conf.Discord.TimeFormat
+	}
+	return config.TimeFormatConf{}
+}
+
+// levelTimeFormat 返回 level 的時區與 layout 設定，L2、l2 與 2 視為相同的 level
+func levelTimeFormat(levels map[string]config.TimeFormatRule, level string) config.TimeFormatRule {
+	if level == "" {
+		return config.TimeFormatRule{}
+	}
+	want := normalizeLevel(level)
+	for key, rule := range levels {
+		if normalizeLevel(key) == want {
+			return rule
+		}
+	}
+	return config.TimeFormatRule{}
+}
+
+// timeFormatFor 決定時區與 layout，兩者分別依下列順序取第一個非空的設定：
+// 提供者 levels > 提供者 languages > 提供者 timezone / layout > 全局 levels > 全局 languages > 全局 timezone / layout。
+// 都未設定時不轉換時區，layout 使用 defaultTimeLayout
+func timeFormatFor(platform, level, language string) (zone, layout string) {
+	for _, conf := range []config.TimeFormatConf{providerTimeFormat(platform), config.TimeFormat} {
+		rules := []config.TimeFormatRule{
+			levelTimeFormat(conf.Levels, level),
+			conf.Languages[strings.ToLower(language)],
+			{Timezone: conf.Timezone, Layout: conf.Layout},
+		}
+		for _, rule := range rules {
+			if zone == "" {
+				zone = strings.TrimSpace(rule.Timezone)
+			}
+			if layout == "" {
+				layout = rule.Layout
+			}
+		}
+	}
+	if layout == "" {
+		layout = defaultTimeLayout
+	}
+	return zone, layout
+}
+
+// isUnsetTime 檢查時間是否未設定；AlertManager 以零值時間表示仍在觸發的警報沒有結束時間
+func isUnsetTime(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == "" || v == "0001-01-01T00:00:00Z"
+	case time.Time:
+		return v.IsZero()
+	}
+	return false
+}
+
+// timeFuncs 返回綁定平台、level 與語言的時間函數：
+//   - format_time PLATFORM TIME：以 time_format 的時區與 layout 格式化時間，Telegram 會轉義輸出
+//   - format_time_simple TIME：與 format_time 相同但不轉義，使用綁定的平台配置
+//   - time_ago TIME：相對於現在的時間，例如 5 minutes ago；不到一分鐘為 just now
+//   - alert_duration START END：警報持續時間，最多兩個相鄰單位，例如 2 hours 5 minutes；END 未設定時計算到現在
+//   - slack_date TIME：Slack 依讀者時區顯示的日期標記 <!date^...>，無法顯示時使用 format_time 的格式；未設定的時間輸出空字串
+//
+// 文字由翻譯目錄的 time.* key 提供，未設定的時間輸出 time.not_set；無法解析的時間輸出原始值
+func (te *TemplateEngine) timeFuncs(platform, level, language string) template.FuncMap {
+	text := func(key, fallback string, count *int, args ...interface{}) string {
+		if translated, ok := te.translate(language, key, count, args); ok {
+			return translated
+		}
+		return fillPlaceholders(fallback, count, args)
+	}
+
+	format := func(targetPlatform string, value interface{}) string {
+		if isUnsetTime(value) {
+			return text("time.not_set", "未設定", nil)
+		}
+		t, err := toTime(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		zone, layout := timeFormatFor(targetPlatform, level, language)
+		if zone != "" {
+			if loc := timeLocation(zone); loc != nil {
+				t = t.In(loc)
+			}
+		}
+		return t.Format(layout)
+	}
+
+	// spell 以翻譯的時間單位描述時間間隔，從最大的非零單位開始最多使用 units 個相鄰單位
+	spell := func(d time.Duration, units int) string {
+		if d < 0 {
+			d = 0
+		}
+		var parts []string
+		for _, unit := range timeUnits {
+			n := int(d / unit.size)
+			d -= time.Duration(n) * unit.size
+			if len(parts) == 0 && n == 0 {
+				continue
+			}
+			if n > 0 {
+				parts = append(parts, text(unit.key, unit.fallback, &n))
+			}
+			if units--; units == 0 {
+				break
+			}
+		}
+		if len(parts) == 0 {
+			last := timeUnits[len(timeUnits)-1]
+			zero := 0
+			parts = append(parts, text(last.key, last.fallback, &zero))
+		}
+		return strings.Join(parts, text("time.separator", " ", nil))
+	}
+
+	return template.FuncMap{
+		"format_time": func(targetPlatform string, value interface{}) string {
+			if targetPlatform == "telegram" {
+				// 無法解析時會返回原始字串，仍需轉義
+				return telegramhtml.Escape(format(targetPlatform, value))
+			}
+			return format(targetPlatform, value)
+		},
+		"format_time_simple": func(value interface{}) string {
+			return format(platform, value)
+		},
+		"time_ago": func(value interface{}) string {
+			if isUnsetTime(value) {
+				return text("time.not_set", "未設定", nil)
+			}
+			t, err := toTime(value)
+			if err != nil {
+				return fmt.Sprint(value)
+			}
+			elapsed := time.Since(t)
+			if elapsed < time.Minute {
+				return text("time.just_now", "just now", nil)
+			}
+			return text("time.ago", "{duration} ago", nil, "duration", spell(elapsed, 1))
+		},
+		"slack_date": func(value interface{}) string {
+			if isUnsetTime(value) {
+				return ""
+			}
+			t, err := toTime(value)
+			if err != nil {
+				return ""
+			}
+			return fmt.Sprintf("<!date^%d^{date_short_pretty} {time}|%s>", t.Unix(), format("slack", t))
+		},
+		"alert_duration": func(start, end interface{}) string {
+			if isUnsetTime(start) {
+				return text("time.not_set", "未設定", nil)
+			}
+			return spell(duration(start, end), 2)
+		},
+	}
+}
+
+// validateTimeFormats 檢查全局與提供者 time_format 中的時區是否有效；Path 為配置中的 key，例如 telegram.time_format.levels.L2.timezone
+func validateTimeFormats() []ValidationIssue {
+	var issues []ValidationIssue
+	for _, platform := range []string{"", "telegram", "slack", "discord"} {
+		conf, prefix := config.TimeFormat, "time_format."
+		if platform != "" {
+			conf, prefix = providerTimeFormat(platform), platform+".time_format."
+		}
+
+		zones := map[string]string{prefix + "timezone": conf.Timezone}
+		for language, rule := range conf.Languages {
+			zones[prefix+"languages."+language+".timezone"] = rule.Timezone
+		}
+		for level, rule := range conf.Levels {
+			zones[prefix+"levels."+level+".timezone"] = rule.Timezone
+		}
+
+		for _, setting := range sortedKeys(zones) {
+			zone := strings.TrimSpace(zones[setting])
+			if zone == "" {
+				continue
+			}
+			if _, err := time.LoadLocation(zone); err != nil {
+				issues = append(issues, ValidationIssue{
+					Severity: SeverityError,
+					Kind:     IssueTimezone,
+					Template: "time_format",
+					Path:     setting,
+					Platform: platform,
+					Message:  fmt.Sprintf("invalid time zone %q, format_time will keep the original time zone: %v", zone, err),
+				})
+			}
+		}
+	}
+	return issues
+}
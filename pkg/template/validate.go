@@ -0,0 +1,510 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"alert-webhooks/pkg/telegramhtml"
+)
+
+// 驗證問題類型
+const (
+	IssueParse              = "parse"               // 模板語法錯誤
+	IssueExec               = "exec"                // 執行失敗
+	IssueMissingKey         = "missing_key"         // 存取不存在的欄位或 map key
+	IssueMarkup             = "markup"              // 輸出不是平台可接受的格式
+	IssueLength             = "length"              // 輸出超過平台單則訊息上限
+	IssueMissingLanguage    = "missing_language"    // 支援的語言沒有對應模板，執行時使用回退語言
+	IssueMissingTranslation = "missing_translation" // 翻譯目錄缺少 key，執行時使用回退語言的翻譯或 key 本身
+	IssueTimezone           = "timezone"            // time_format 設定了無效的時區，format_time 保留原本的時區
+)
+
+// 驗證問題嚴重程度
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// ValidationIssue 模板驗證發現的問題
+type ValidationIssue struct {
+	Severity string `json:"severity"`
+	Kind     string `json:"kind"`
+	Template string `json:"template"` // 模板集名稱
+	Language string `json:"language,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Platform string `json:"platform,omitempty"`
+	Fixture  string `json:"fixture,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Message  string `json:"message"`
+}
+
+// String 以 path:line:column 形式描述問題
+func (i ValidationIssue) String() string {
+	location := i.Template + "/" + i.Language
+	if i.Path != "" {
+		location = i.Path
+	}
+	if i.Line > 0 {
+		location += fmt.Sprintf(":%d", i.Line)
+		if i.Column > 0 {
+			location += fmt.Sprintf(":%d", i.Column)
+		}
+	}
+	var context []string
+	if i.Path != "" && i.Language != "" {
+		context = append(context, "language="+i.Language)
+	}
+	if i.Platform != "" {
+		context = append(context, "platform="+i.Platform)
+	}
+	if i.Fixture != "" {
+		context = append(context, "fixture="+i.Fixture)
+	}
+	text := fmt.Sprintf("%s %s: [%s] %s", strings.ToUpper(i.Severity), location, i.Kind, i.Message)
+	if len(context) > 0 {
+		text += " (" + strings.Join(context, ", ") + ")"
+	}
+	return text
+}
+
+// ValidationReport 模板驗證結果
+type ValidationReport struct {
+	Templates int               `json:"templates"` // 檢查的模板檔案數
+	Renders   int               `json:"renders"`   // 執行的渲染次數
+	Issues    []ValidationIssue `json:"issues"`
+}
+
+// Errors 返回嚴重程度為 error 的問題數
+func (r *ValidationReport) Errors() int {
+	return r.count(SeverityError)
+}
+
+// Warnings 返回嚴重程度為 warning 的問題數
+func (r *ValidationReport) Warnings() int {
+	return r.count(SeverityWarning)
+}
+
+func (r *ValidationReport) count(severity string) int {
+	n := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// ValidationFixture 驗證模板使用的範例資料
+type ValidationFixture struct {
+	Name string
+	Data TemplateData
+}
+
+// hugeGroupSize huge_group 範例中的警報數
+const hugeGroupSize = 100
+
+// ValidationFixtures 返回驗證使用的範例資料：firing、resolved、mixed、empty_annotations 與 huge_group
+func ValidationFixtures() []ValidationFixture {
+	return []ValidationFixture{
+		{Name: "firing", Data: fixtureData("firing", fixtureAlerts(3, 0, true))},
+		{Name: "resolved", Data: fixtureData("resolved", fixtureAlerts(0, 2, true))},
+		{Name: "mixed", Data: fixtureData("firing", fixtureAlerts(2, 2, true))},
+		{Name: "empty_annotations", Data: fixtureData("firing", fixtureAlerts(1, 1, false))},
+		{Name: "huge_group", Data: fixtureData("firing", fixtureAlerts(hugeGroupSize-hugeGroupSize/4, hugeGroupSize/4, true))},
+	}
+}
+
+// fixtureAlerts 產生指定數量的 firing 與 resolved 警報；annotations 為 false 時不帶任何註解
+func fixtureAlerts(firing, resolved int, annotations bool) []AlertData {
+	alerts := make([]AlertData, 0, firing+resolved)
+	for i := 0; i < firing+resolved; i++ {
+		alert := AlertData{
+			Status: "firing",
+			Labels: map[string]string{
+				"alertname": "HighCPUUsage",
+				"env":       "prod",
+				"severity":  "critical",
+				"namespace": "payments",
+				"pod":       fmt.Sprintf("payments-api-7d9f8b6c5-%05d", i),
+				"instance":  fmt.Sprintf("10.0.%d.%d:9100", i/250, i%250),
+			},
+			Annotations:  map[string]string{},
+			StartsAt:     "2024-05-01T08:00:00Z",
+			EndsAt:       "0001-01-01T00:00:00Z",
+			GeneratorURL: "http://prometheus.example.com/graph?g0.expr=rate%28cpu%5B5m%5D%29+%3E+0.9&g0.tab=1",
+		}
+		if i >= firing {
+			alert.Status = "resolved"
+			alert.EndsAt = "2024-05-01T08:30:00Z"
+		}
+		if annotations {
+			alert.Annotations["summary"] = fmt.Sprintf("CPU usage on pod %s is above 90%% (value: 97.3 > 90)", alert.Labels["pod"])
+			alert.Annotations["description"] = "Container <app> & sidecar have used more than 90% CPU for 5 minutes"
+			alert.Annotations["runbook_url"] = "https://runbooks.example.com/cpu?team=payments&severity=critical"
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts
+}
+
+// fixtureData 以警報列表組成 TemplateData
+func fixtureData(status string, alerts []AlertData) TemplateData {
+	data := TemplateData{
+		Status:      status,
+		AlertName:   "HighCPUUsage",
+		Env:         "prod",
+		Severity:    "critical",
+		Namespace:   "payments",
+		TotalAlerts: len(alerts),
+		Alerts:      alerts,
+		CommonLabels: map[string]string{
+			"alertname": "HighCPUUsage",
+			"env":       "prod",
+			"severity":  "critical",
+			"namespace": "payments",
+		},
+		ExternalURL: "http://alertmanager.example.com",
+	}
+	for _, alert := range alerts {
+		if alert.Status == "firing" {
+			data.FiringCount++
+		} else {
+			data.ResolvedCount++
+		}
+	}
+	return data
+}
+
+// ValidateTemplates 驗證最近一次載入的模板目錄中所有模板集與語言的模板：
+// 重新解析每個模板檔案，並以每個範例資料為 limits 中的每個平台執行，
+// 檢查執行錯誤、不存在的欄位或 key、平台格式與長度。limits 為平台 -> 單則訊息上限
+func (te *TemplateEngine) ValidateTemplates(limits map[string]int) *ValidationReport {
+	report := &ValidationReport{}
+	if te.dir == "" {
+		report.Issues = append(report.Issues, ValidationIssue{
+			Severity: SeverityError,
+			Kind:     IssueParse,
+			Template: DefaultTemplateSet,
+			Message:  "no template directory loaded",
+		})
+		return report
+	}
+
+	sets := map[string]string{DefaultTemplateSet: te.dir}
+	if entries, err := os.ReadDir(te.dir); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") && entry.Name() != DefaultTemplateSet && entry.Name() != PartialsDir && entry.Name() != I18nDir {
+				sets[entry.Name()] = filepath.Join(te.dir, entry.Name())
+			}
+		}
+	}
+
+	// 共用區塊逐檔解析，語法錯誤指向各自的檔案；呼叫不存在的區塊在執行模板時回報
+	files, err := partialFiles(te.dir)
+	if err != nil {
+		report.Issues = append(report.Issues, ValidationIssue{
+			Severity: SeverityError,
+			Kind:     IssueParse,
+			Template: PartialsDir,
+			Message:  err.Error(),
+		})
+	}
+	for _, path := range files {
+		report.Templates++
+		if _, err := te.parsePartials([]string{path}); err != nil {
+			renderErr := NewRenderError(StageParse, err)
+			report.Issues = append(report.Issues, ValidationIssue{
+				Severity: SeverityError,
+				Kind:     IssueParse,
+				Template: PartialsDir,
+				Path:     path,
+				Line:     renderErr.Line,
+				Column:   renderErr.Column,
+				Message:  renderErr.Message,
+			})
+		}
+	}
+
+	report.Issues = append(report.Issues, te.validateCatalogs()...)
+	report.Issues = append(report.Issues, validateTimeFormats()...)
+
+	platforms := make([]string, 0, len(limits))
+	for platform := range limits {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	fixtures := ValidationFixtures()
+
+	for _, name := range sortedKeys(sets) {
+		files, err := te.scanTemplateFiles(sets[name])
+		if err != nil || len(files) == 0 {
+			continue
+		}
+
+		// 有翻譯目錄但沒有模板的語言以共用模板驗證，沒有共用模板時使用回退語言的模板
+		fallback, shared := files[sharedTemplate]
+		if !shared {
+			fallback = files[fallbackLanguage(files, te.config.FallbackOrder)]
+		}
+		for _, language := range te.GetCatalogLanguages() {
+			if _, ok := files[language]; !ok {
+				files[language] = fallback
+			}
+		}
+		if shared && len(te.GetCatalogLanguages()) > 0 {
+			// 共用模板已以各語言驗證
+			delete(files, sharedTemplate)
+		}
+
+		if name == DefaultTemplateSet {
+			for _, language := range te.GetSupportedLanguages() {
+				if _, ok := files[language]; !ok {
+					report.Issues = append(report.Issues, ValidationIssue{
+						Severity: SeverityWarning,
+						Kind:     IssueMissingLanguage,
+						Template: name,
+						Language: language,
+						Message:  "no template for supported language, fallback language will be used",
+					})
+				}
+			}
+		}
+
+		for _, language := range sortedKeys(files) {
+			path := files[language]
+			report.Templates++
+			tmpl, err := te.loadTemplate(path)
+			if err != nil {
+				renderErr := NewRenderError(StageParse, err)
+				report.Issues = append(report.Issues, ValidationIssue{
+					Severity: SeverityError,
+					Kind:     IssueParse,
+					Template: name,
+					Language: language,
+					Path:     path,
+					Line:     renderErr.Line,
+					Column:   renderErr.Column,
+					Message:  renderErr.Message,
+				})
+				continue
+			}
+
+			seen := make(map[string]bool)
+			for _, platform := range platforms {
+				for _, fixture := range fixtures {
+					report.Renders++
+					fixture.Data.Language = language
+					for _, issue := range te.validateRender(tmpl, platform, limits[platform], fixture) {
+						// 同一錯誤通常在每個範例資料都會出現，只回報第一次；
+						// 執行錯誤與平台無關，格式與長度問題則依平台分別回報
+						key := issue.Kind + "|" + issue.Message
+						if issue.Kind == IssueMarkup || issue.Kind == IssueLength {
+							key = issue.Platform + "|" + key
+						}
+						if seen[key] {
+							continue
+						}
+						seen[key] = true
+						issue.Template, issue.Language, issue.Path = name, language, path
+						report.Issues = append(report.Issues, issue)
+					}
+				}
+			}
+		}
+	}
+
+	return report
+}
+
+// validateRender 以單一範例資料為平台執行模板並檢查輸出
+func (te *TemplateEngine) validateRender(tmpl *template.Template, platform string, limit int, fixture ValidationFixture) []ValidationIssue {
+	base := ValidationIssue{Platform: platform, Fixture: fixture.Name}
+
+	// 以 missingkey=error 執行，存取不存在的 map key 時回報而不是輸出 <no value>
+	strict, err := tmpl.Clone()
+	if err != nil {
+		issue := base
+		issue.Severity, issue.Kind, issue.Message = SeverityError, IssueExec, err.Error()
+		return []ValidationIssue{issue}
+	}
+	var missing []string
+	data := fixture.Data
+	data.Platform = platform
+	output, err := te.executeLocalized(strict.Option("missingkey=error"), "", data, func(key string) {
+		missing = append(missing, key)
+	})
+	if err != nil {
+		renderErr := NewRenderError(StageExec, err)
+		issue := base
+		issue.Line, issue.Column, issue.Message = renderErr.Line, renderErr.Column, renderErr.Message
+		switch {
+		case strings.Contains(renderErr.Message, "map has no entry for key"):
+			// 一般執行時會輸出 <no value> 而不會失敗
+			issue.Severity, issue.Kind = SeverityWarning, IssueMissingKey
+		case strings.Contains(renderErr.Message, "can't evaluate field"):
+			issue.Severity, issue.Kind = SeverityError, IssueMissingKey
+		default:
+			issue.Severity, issue.Kind = SeverityError, IssueExec
+		}
+		return []ValidationIssue{issue}
+	}
+
+	var issues []ValidationIssue
+	for _, key := range missing {
+		issue := base
+		issue.Severity, issue.Kind = SeverityWarning, IssueMissingTranslation
+		issue.Message = fmt.Sprintf("translation key %q not found in any catalog", key)
+		issues = append(issues, issue)
+	}
+	if err := validateMarkup(platform, output); err != nil {
+		issue := base
+		issue.Severity, issue.Kind, issue.Message = SeverityError, IssueMarkup, err.Error()
+		issues = append(issues, issue)
+	}
+	if length := utf8.RuneCountInString(output); limit > 0 && length > limit {
+		issue := base
+		issue.Severity, issue.Kind = SeverityWarning, IssueLength
+		issue.Message = fmt.Sprintf("output is %d characters, limit is %d", length, limit)
+		issues = append(issues, issue)
+	}
+	if platform == "slack" && strict.Lookup(SlackBlocksTemplate) != nil {
+		issues = append(issues, te.validateSlackBlocks(strict, base, data)...)
+	}
+	return issues
+}
+
+// validateSlackBlocks 執行 slack.blocks 區塊，檢查輸出是否為 Block Kit blocks 的 JSON 陣列
+func (te *TemplateEngine) validateSlackBlocks(tmpl *template.Template, base ValidationIssue, data TemplateData) []ValidationIssue {
+	output, err := te.executeLocalized(tmpl, SlackBlocksTemplate, data, nil)
+	if err == nil {
+		var blocks []json.RawMessage
+		if err = json.Unmarshal([]byte(output), &blocks); err != nil {
+			err = fmt.Errorf("%s output is not a JSON array of blocks: %v", SlackBlocksTemplate, err)
+		}
+	}
+	if err == nil {
+		return nil
+	}
+	renderErr := NewRenderError(StageExec, err)
+	issue := base
+	issue.Severity, issue.Kind = SeverityError, IssueMarkup
+	issue.Line, issue.Column, issue.Message = renderErr.Line, renderErr.Column, renderErr.Message
+	return []ValidationIssue{issue}
+}
+
+// validateCatalogs 檢查翻譯目錄：解析錯誤，以及參考語言（回退順序中第一個有翻譯目錄的語言）有、
+// 但其他語言缺少的 key
+func (te *TemplateEngine) validateCatalogs() []ValidationIssue {
+	files, err := catalogFiles(te.dir)
+	if err != nil {
+		return []ValidationIssue{{Severity: SeverityError, Kind: IssueParse, Template: I18nDir, Message: err.Error()}}
+	}
+
+	var issues []ValidationIssue
+	catalogs := make(map[string]catalog)
+	for _, language := range sortedKeys(files) {
+		content, err := os.ReadFile(files[language])
+		if err == nil {
+			var entries catalog
+			if entries, err = parseCatalog(content); err == nil {
+				catalogs[language] = entries
+				continue
+			}
+		}
+		issues = append(issues, ValidationIssue{
+			Severity: SeverityError,
+			Kind:     IssueParse,
+			Template: I18nDir,
+			Language: language,
+			Path:     files[language],
+			Message:  err.Error(),
+		})
+	}
+
+	reference := fallbackLanguage(files, te.config.FallbackOrder)
+	if _, ok := catalogs[reference]; !ok {
+		return issues
+	}
+	for _, language := range sortedKeys(files) {
+		entries, ok := catalogs[language]
+		if !ok || language == reference {
+			continue
+		}
+		var keys []string
+		for key := range catalogs[reference] {
+			if _, ok := entries[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			issues = append(issues, ValidationIssue{
+				Severity: SeverityWarning,
+				Kind:     IssueMissingTranslation,
+				Template: I18nDir,
+				Language: language,
+				Path:     files[language],
+				Message:  fmt.Sprintf("key %q is missing, fallback translation will be used", key),
+			})
+		}
+	}
+	return issues
+}
+
+// fallbackLanguage 依回退順序選擇 files 中的語言，都沒有時使用排序後的第一個
+func fallbackLanguage(files map[string]string, fallbackOrder []string) string {
+	for _, language := range fallbackOrder {
+		if _, ok := files[language]; ok {
+			return language
+		}
+	}
+	if keys := sortedKeys(files); len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
+
+// validateMarkup 檢查輸出是否為平台可接受的格式
+func validateMarkup(platform, output string) error {
+	switch platform {
+	case "telegram":
+		return telegramhtml.Validate(output)
+	case "slack":
+		// mrkdwn 的 <url|text> 連結必須在同一行內閉合
+		for n, line := range strings.Split(output, "\n") {
+			for rest := line; ; {
+				start := strings.IndexByte(rest, '<')
+				if start < 0 {
+					break
+				}
+				end := strings.IndexByte(rest[start:], '>')
+				if end < 0 {
+					return fmt.Errorf("line %d: unclosed '<' in mrkdwn link", n+1)
+				}
+				rest = rest[start+end+1:]
+			}
+		}
+	case "discord":
+		if strings.Count(output, "```")%2 != 0 {
+			return fmt.Errorf("unclosed code block")
+		}
+	}
+	return nil
+}
+
+// sortedKeys 返回排序後的 map key
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
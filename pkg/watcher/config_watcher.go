@@ -2,25 +2,32 @@ package watcher
 
 import (
 	"context"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"alert-webhooks/config"
+	"alert-webhooks/pkg/dedup"
+	"alert-webhooks/pkg/inhibit"
 	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/msgref"
+	"alert-webhooks/pkg/routing"
 	"alert-webhooks/pkg/service"
+	"alert-webhooks/pkg/template"
 
 	"github.com/fsnotify/fsnotify"
 )
 
 // ConfigWatcher 配置檔案監控器
 type ConfigWatcher struct {
-	watcher   *fsnotify.Watcher
-	mu        sync.RWMutex
-	stopCh    chan struct{}
-	isRunning bool
-	debounce  time.Duration
+	watcher     *fsnotify.Watcher
+	mu          sync.RWMutex
+	stopCh      chan struct{}
+	isRunning   bool
+	debounce    time.Duration
+	templateDir string // 監控中的模板目錄
 }
 
 // NewConfigWatcher 創建新的配置監控器
@@ -65,6 +72,9 @@ func (cw *ConfigWatcher) Start(ctx context.Context) error {
 		}
 	}
 
+	// 監控模板目錄（含模板集與 partials 子目錄），模板檔案變更時重新載入模板引擎
+	cw.watchTemplateDirs()
+
 	cw.isRunning = true
 
 	// 啟動監控協程
@@ -108,10 +118,16 @@ func (cw *ConfigWatcher) watchLoop(ctx context.Context) {
 				return
 			}
 
-			// 只處理寫入和創建事件
-			if event.Op&fsnotify.Write == fsnotify.Write || event.Op&fsnotify.Create == fsnotify.Create {
-				// 只監控 YAML 檔案
-				if strings.HasSuffix(event.Name, ".yaml") || strings.HasSuffix(event.Name, ".yml") {
+			// 模板目錄中新增的子目錄（模板集或 partials）加入監控
+			if event.Op&fsnotify.Create == fsnotify.Create && cw.isTemplateSubdir(event.Name) {
+				cw.addTemplateDir(event.Name)
+			}
+
+			// 只處理寫入和創建事件；模板檔案的刪除與改名也會影響載入結果
+			if event.Op&fsnotify.Write == fsnotify.Write || event.Op&fsnotify.Create == fsnotify.Create ||
+				(isTemplateFile(event.Name) && event.Op&(fsnotify.Remove|fsnotify.Rename) != 0) {
+				// 只監控 YAML 與模板檔案
+				if strings.HasSuffix(event.Name, ".yaml") || strings.HasSuffix(event.Name, ".yml") || isTemplateFile(event.Name) {
 					logger.Info("Config file changed", "config_watcher",
 						logger.String("file", event.Name),
 						logger.String("operation", event.Op.String()))
@@ -144,6 +160,9 @@ func (cw *ConfigWatcher) handleConfigChange(filename string) {
 	baseName := filepath.Base(filename)
 
 	switch {
+	case isTemplateFile(filename) || cw.isCatalogFile(filename):
+		// 模板、共用區塊或翻譯目錄變更
+		cw.reloadTemplateConfig()
 	case strings.HasPrefix(baseName, "config."):
 		// 主配置檔案變更
 		cw.reloadMainConfig()
@@ -172,6 +191,22 @@ func (cw *ConfigWatcher) reloadMainConfig() {
 		return
 	}
 
+	// 重新載入路由樹
+	if err := routing.Load(config.Routing); err != nil {
+		logger.Error("Failed to reload routing tree, keeping previous tree", "config_watcher", logger.Err(err))
+	}
+
+	// 重新載入去重配置（窗口未變更時保留既有紀錄）
+	dedup.Load(config.Dedup)
+
+	// 重新載入抑制規則（觸發索引保留）
+	if err := inhibit.Load(config.Inhibit); err != nil {
+		logger.Error("Failed to reload inhibit rules, keeping previous rules", "config_watcher", logger.Err(err))
+	}
+
+	// 重新載入訊息參照配置（既有參照保留）
+	msgref.Load(config.MessageRef)
+
 	logger.Info("Main config reloaded successfully", "config_watcher")
 }
 
@@ -187,6 +222,60 @@ func (cw *ConfigWatcher) reloadTemplateConfig() {
 	logger.Info("Template config reloaded successfully", "config_watcher")
 }
 
+// watchTemplateDirs 監控已載入的模板目錄與其子目錄（fsnotify 不會遞迴監控）
+func (cw *ConfigWatcher) watchTemplateDirs() {
+	engine := service.GetServiceManager().GetTemplateEngine()
+	if engine == nil || engine.GetTemplateDir() == "" {
+		return
+	}
+
+	templateDir := engine.GetTemplateDir()
+	cw.templateDir = filepath.Clean(templateDir)
+	cw.addTemplateDir(templateDir)
+
+	entries, err := os.ReadDir(templateDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			cw.addTemplateDir(filepath.Join(templateDir, entry.Name()))
+		}
+	}
+}
+
+// addTemplateDir 將模板目錄加入監控
+func (cw *ConfigWatcher) addTemplateDir(dir string) {
+	if err := cw.watcher.Add(dir); err != nil {
+		logger.Warn("Failed to watch template path", "config_watcher",
+			logger.String("path", dir),
+			logger.Err(err))
+		return
+	}
+	logger.Info("Watching template path", "config_watcher",
+		logger.String("path", dir))
+}
+
+// isTemplateSubdir 檢查路徑是否為模板目錄下的子目錄
+func (cw *ConfigWatcher) isTemplateSubdir(path string) bool {
+	if cw.templateDir == "" || filepath.Dir(path) != cw.templateDir || strings.HasPrefix(filepath.Base(path), ".") {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// isCatalogFile 檢查是否為模板目錄中的翻譯目錄檔案
+func (cw *ConfigWatcher) isCatalogFile(path string) bool {
+	return cw.templateDir != "" && filepath.Dir(path) == filepath.Join(cw.templateDir, template.I18nDir)
+}
+
+// isTemplateFile 檢查是否為模板檔案（.tmpl 或 .j2）
+func isTemplateFile(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".tmpl" || ext == ".j2"
+}
+
 // IsRunning 檢查是否正在運行
 func (cw *ConfigWatcher) IsRunning() bool {
 	cw.mu.RLock()
@@ -0,0 +1,286 @@
+// Package alertmanager 提供統一的 Alertmanager webhook 接收端點，解析一次後透過 NotificationManager 扇出到所有提供者，
+// 啟用路由樹時依警報標籤決定目的地
+package alertmanager
+
+import (
+	"net/http"
+	"strconv"
+
+	"alert-webhooks/pkg/alertfilter"
+	"alert-webhooks/pkg/dedup"
+	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/notification"
+	"alert-webhooks/pkg/notification/types"
+	"alert-webhooks/pkg/queue"
+	"alert-webhooks/pkg/routing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultLevel 未指定 level 查詢參數時使用的等級
+const defaultLevel = "L0"
+
+// Handler Alertmanager 統一接收處理器
+type Handler struct {
+	manager *notification.NotificationManager
+}
+
+// NewHandler 創建新的 Alertmanager 接收處理器
+func NewHandler(manager *notification.NotificationManager) *Handler {
+	return &Handler{
+		manager: manager,
+	}
+}
+
+// ReceiveResponse 統一接收端點的響應結構
+type ReceiveResponse struct {
+	Success bool                          `json:"success"`
+	Message string                        `json:"message"`
+	Results []*types.NotificationResponse `json:"results"`
+
+	// Deliveries 非同步模式下每個目的地的投遞憑證
+	Deliveries []queue.Receipt `json:"deliveries,omitempty"`
+
+	// Deduplicated 在去重窗口內已發送過而被抑制的目的地
+	Deduplicated []types.Destination `json:"deduplicated,omitempty"`
+
+	// Silenced 被本地靜音移除的警報數
+	Silenced int `json:"silenced,omitempty"`
+
+	// Inhibited 被抑制規則移除的警報數
+	Inhibited int `json:"inhibited,omitempty"`
+}
+
+// Receive 接收 Alertmanager webhook 並扇出到所有提供者，或依路由樹發送
+// @Summary Receive Alertmanager webhook
+// @Description 解析一次 Alertmanager payload，透過 NotificationManager 發送到所有已啟用的提供者（Telegram、Slack、Discord），並回傳每個提供者的結果。
+// @Description 若配置啟用 routing，則依警報群組標籤比對路由樹決定目的地，level 查詢參數將被忽略
+// @Description 被本地靜音命中或被抑制規則抑制的警報會在渲染前移除，群組中沒有剩餘警報時不發送通知
+// @Description 若配置啟用 dedup，去重窗口內相同 groupKey、狀態與 fingerprint 的重複通知會被抑制並列於 deduplicated
+// @Tags alertmanager
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param level query string false "通知等級 (例如: L0, L2)，預設 L0"
+// @Param template_language query string false "模板語言 (eng, tw, zh, ja, ko)，預設使用各提供者配置"
+// @Param async query bool false "非同步模式：寫入持久化佇列後立即回傳 202 與投遞 ID（需啟用 queue）"
+// @Param template query string false "具名模板集（templates/alerts 子目錄），優先於路由、level 與提供者配置"
+// @Param request body types.AlertManagerData true "Alertmanager webhook payload"
+// @Success 200 {object} ReceiveResponse
+// @Success 202 {object} ReceiveResponse
+// @Success 207 {object} ReceiveResponse
+// @Failure 400 {object} ReceiveResponse
+// @Failure 500 {object} ReceiveResponse
+// @Failure 503 {object} ReceiveResponse
+// @Router /alertmanager [post]
+func (h *Handler) Receive(c *gin.Context) {
+	var data types.AlertManagerData
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.JSON(http.StatusBadRequest, ReceiveResponse{
+			Success: false,
+			Message: "Invalid AlertManager payload: " + err.Error(),
+		})
+		return
+	}
+
+	if len(data.Alerts) == 0 {
+		c.JSON(http.StatusBadRequest, ReceiveResponse{
+			Success: false,
+			Message: "AlertManager payload contains no alerts",
+		})
+		return
+	}
+
+	if len(h.manager.GetProviderNames()) == 0 {
+		c.JSON(http.StatusServiceUnavailable, ReceiveResponse{
+			Success: false,
+			Message: "No notification providers are available",
+		})
+		return
+	}
+
+	// 渲染前移除被靜音或被抑制的警報
+	filtered := alertfilter.Apply(&data)
+	if filtered.Empty() {
+		logger.Info("All alerts in group silenced or inhibited, notification skipped", "alertmanager_handler",
+			logger.String("group_key", data.GroupKey),
+			logger.Int("silenced", filtered.Silenced),
+			logger.Int("inhibited", filtered.Inhibited))
+		c.JSON(http.StatusOK, ReceiveResponse{
+			Success:   true,
+			Message:   "All alerts silenced or inhibited, notification not sent",
+			Results:   []*types.NotificationResponse{},
+			Silenced:  filtered.Silenced,
+			Inhibited: filtered.Inhibited,
+		})
+		return
+	}
+	data = *filtered.Data
+
+	level := c.DefaultQuery("level", defaultLevel)
+	if len(level) > 0 && level[0] >= '0' && level[0] <= '9' {
+		level = "L" + level
+	}
+
+	req := &types.NotificationRequest{
+		Level:            level,
+		AlertData:        &data,
+		TemplateLanguage: c.Query("template_language"),
+		TemplateName:     c.Query("template"),
+	}
+
+	logger.Info("Received AlertManager webhook", "alertmanager_handler",
+		logger.String("receiver", data.Receiver),
+		logger.String("status", data.Status),
+		logger.String("group_key", data.GroupKey),
+		logger.Int("alerts_count", len(data.Alerts)),
+		logger.String("level", req.Level))
+
+	var destinations []types.Destination
+	if routing.Enabled() {
+		destinations = routing.Evaluate(&data)
+		if len(destinations) == 0 {
+			logger.Warn("No route matched AlertManager group, notification dropped", "alertmanager_handler",
+				logger.String("group_key", data.GroupKey))
+			c.JSON(http.StatusOK, ReceiveResponse{
+				Success:   true,
+				Message:   "No route matched, notification not sent",
+				Results:   []*types.NotificationResponse{},
+				Silenced:  filtered.Silenced,
+				Inhibited: filtered.Inhibited,
+			})
+			return
+		}
+	} else {
+		for _, providerName := range h.manager.GetProviderNames() {
+			destinations = append(destinations, types.Destination{
+				Provider: providerName,
+				Level:    req.Level,
+			})
+		}
+	}
+
+	destinations, keys, duplicates := deduplicate(&data, destinations)
+	if len(destinations) == 0 {
+		c.JSON(http.StatusOK, ReceiveResponse{
+			Success:      true,
+			Message:      "Duplicate notification suppressed within dedup window",
+			Results:      []*types.NotificationResponse{},
+			Deduplicated: duplicates,
+			Silenced:     filtered.Silenced,
+			Inhibited:    filtered.Inhibited,
+		})
+		return
+	}
+
+	if async, _ := strconv.ParseBool(c.Query("async")); async {
+		status, resp := h.enqueue(req, destinations, keys)
+		resp.Deduplicated = duplicates
+		resp.Silenced = filtered.Silenced
+		resp.Inhibited = filtered.Inhibited
+		c.JSON(status, resp)
+		return
+	}
+
+	results := h.manager.SendToDestinations(c.Request.Context(), req, destinations)
+	for i, result := range results {
+		if result == nil || !result.Success {
+			dedup.Release(keys[i])
+		}
+	}
+
+	status, resp := summarize(results)
+	resp.Deduplicated = duplicates
+	resp.Silenced = filtered.Silenced
+	resp.Inhibited = filtered.Inhibited
+	c.JSON(status, resp)
+}
+
+// deduplicate 移除去重窗口內已發送過的目的地，返回剩餘目的地、對應的去重鍵值與被抑制的目的地
+func deduplicate(data *types.AlertManagerData, destinations []types.Destination) ([]types.Destination, []string, []types.Destination) {
+	remaining := make([]types.Destination, 0, len(destinations))
+	keys := make([]string, 0, len(destinations))
+	var duplicates []types.Destination
+
+	for _, dest := range destinations {
+		key, duplicate := dedup.Check(dest, data)
+		if duplicate {
+			duplicates = append(duplicates, dest)
+			continue
+		}
+		remaining = append(remaining, dest)
+		keys = append(keys, key)
+	}
+
+	return remaining, keys, duplicates
+}
+
+// enqueue 將每個目的地寫入持久化佇列，返回 202 與投遞 ID
+func (h *Handler) enqueue(req *types.NotificationRequest, destinations []types.Destination, keys []string) (int, ReceiveResponse) {
+	deliveryQueue := queue.GetQueue()
+	if deliveryQueue == nil {
+		for _, key := range keys {
+			dedup.Release(key)
+		}
+		return http.StatusServiceUnavailable, ReceiveResponse{
+			Success: false,
+			Message: "Async delivery requires the queue to be enabled",
+		}
+	}
+
+	receipts, err := deliveryQueue.SubmitAll(req, destinations)
+	if err != nil {
+		// 已入列的目的地保留去重紀錄，其餘釋放讓 Alertmanager 重試
+		for _, key := range keys[len(receipts):] {
+			dedup.Release(key)
+		}
+		logger.Error("Failed to enqueue AlertManager notification", "alertmanager_handler",
+			logger.String("group_key", req.AlertData.GroupKey),
+			logger.Int("enqueued", len(receipts)),
+			logger.Err(err))
+		return http.StatusInternalServerError, ReceiveResponse{
+			Success:    false,
+			Message:    "Failed to enqueue notification: " + err.Error(),
+			Deliveries: receipts,
+		}
+	}
+
+	return http.StatusAccepted, ReceiveResponse{
+		Success:    true,
+		Message:    "Notification accepted for delivery",
+		Results:    []*types.NotificationResponse{},
+		Deliveries: receipts,
+	}
+}
+
+// summarize 根據各提供者結果決定 HTTP 狀態碼與響應內容
+// 全部成功回傳 200，部分成功回傳 207，全部失敗回傳 500
+func summarize(results []*types.NotificationResponse) (int, ReceiveResponse) {
+	succeeded := 0
+	for _, result := range results {
+		if result != nil && result.Success {
+			succeeded++
+		}
+	}
+
+	switch {
+	case succeeded == len(results):
+		return http.StatusOK, ReceiveResponse{
+			Success: true,
+			Message: "Notification delivered to all providers",
+			Results: results,
+		}
+	case succeeded > 0:
+		return http.StatusMultiStatus, ReceiveResponse{
+			Success: false,
+			Message: "Notification delivered to some providers",
+			Results: results,
+		}
+	default:
+		return http.StatusInternalServerError, ReceiveResponse{
+			Success: false,
+			Message: "Failed to deliver notification to any provider",
+			Results: results,
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package alertmanager
+
+import (
+	"alert-webhooks/pkg/middleware"
+	"alert-webhooks/pkg/notification"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes 註冊 Alertmanager 統一接收路由
+func RegisterRoutes(router *gin.RouterGroup, manager *notification.NotificationManager) {
+	handler := NewHandler(manager)
+
+	// 單一 webhook_config 即可同時送達 Telegram、Slack、Discord（需要基本認證）
+	router.POST("/alertmanager", middleware.BasicAuth(), handler.Receive)
+}
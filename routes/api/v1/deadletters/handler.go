@@ -0,0 +1,222 @@
+// Package deadletters 提供死信（重試用盡的投遞）的查詢、刪除與重送管理端點
+package deadletters
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/notification/types"
+	"alert-webhooks/pkg/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler 死信管理處理器
+type Handler struct{}
+
+// NewHandler 創建新的死信管理處理器
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// Response 死信管理端點的通用響應結構
+type Response struct {
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+}
+
+// ListResponse 死信列表響應
+type ListResponse struct {
+	Success     bool                `json:"success"`
+	Count       int                 `json:"count"`
+	DeadLetters []*queue.DeadLetter `json:"dead_letters"`
+}
+
+// DetailResponse 單筆死信響應
+type DetailResponse struct {
+	Success    bool              `json:"success"`
+	DeadLetter *queue.DeadLetter `json:"dead_letter"`
+}
+
+// ReplayRequest 重送請求，未提供 provider 時送回原目的地
+type ReplayRequest struct {
+	Provider string `json:"provider,omitempty"` // telegram, slack, discord
+	Level    string `json:"level,omitempty"`    // 例如 L0、L2
+	Channel  string `json:"channel,omitempty"`  // Slack 頻道或 Discord 頻道 ID
+}
+
+// ReplayResponse 重送響應
+type ReplayResponse struct {
+	Success  bool          `json:"success"`
+	Message  string        `json:"message"`
+	Delivery queue.Receipt `json:"delivery"`
+}
+
+// List 列出死信
+// @Summary List dead letters
+// @Description 列出重試次數用盡的投遞紀錄，可依提供者篩選
+// @Tags dead-letters
+// @Produce json
+// @Security BasicAuth
+// @Param provider query string false "提供者 (telegram, slack, discord)"
+// @Success 200 {object} ListResponse
+// @Failure 503 {object} Response
+// @Router /dead-letters [get]
+func (h *Handler) List(c *gin.Context) {
+	deliveryQueue, ok := requireQueue(c)
+	if !ok {
+		return
+	}
+
+	letters, err := deliveryQueue.ListDeadLetters(c.Query("provider"))
+	if err != nil {
+		logger.Error("Failed to list dead letters", "dead_letters_handler", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, Response{Success: false, Message: "Failed to list dead letters: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ListResponse{
+		Success:     true,
+		Count:       len(letters),
+		DeadLetters: letters,
+	})
+}
+
+// Get 查詢單筆死信
+// @Summary Get dead letter
+// @Description 查詢單筆死信，包含提供者、目的地、渲染後的訊息、原始 payload 與最後錯誤
+// @Tags dead-letters
+// @Produce json
+// @Security BasicAuth
+// @Param id path string true "死信 ID"
+// @Success 200 {object} DetailResponse
+// @Failure 404 {object} Response
+// @Failure 503 {object} Response
+// @Router /dead-letters/{id} [get]
+func (h *Handler) Get(c *gin.Context) {
+	deliveryQueue, ok := requireQueue(c)
+	if !ok {
+		return
+	}
+
+	letter, err := deliveryQueue.GetDeadLetter(c.Param("id"))
+	if err != nil {
+		respondError(c, "Failed to get dead letter", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, DetailResponse{Success: true, DeadLetter: letter})
+}
+
+// Delete 刪除單筆死信
+// @Summary Delete dead letter
+// @Description 刪除單筆死信
+// @Tags dead-letters
+// @Produce json
+// @Security BasicAuth
+// @Param id path string true "死信 ID"
+// @Success 200 {object} Response
+// @Failure 404 {object} Response
+// @Failure 503 {object} Response
+// @Router /dead-letters/{id} [delete]
+func (h *Handler) Delete(c *gin.Context) {
+	deliveryQueue, ok := requireQueue(c)
+	if !ok {
+		return
+	}
+
+	id := c.Param("id")
+	if err := deliveryQueue.DeleteDeadLetter(id); err != nil {
+		respondError(c, "Failed to delete dead letter", err)
+		return
+	}
+
+	logger.Info("Dead letter deleted", "dead_letters_handler", logger.String("id", id))
+	c.JSON(http.StatusOK, Response{Success: true, Message: "Dead letter deleted"})
+}
+
+// Replay 重送單筆死信到原目的地或新的目的地
+// @Summary Replay dead letter
+// @Description 將死信重新寫入投遞佇列；請求體為空時送回原目的地，提供 provider 與 level/channel 時改送到新的目的地
+// @Tags dead-letters
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param id path string true "死信 ID"
+// @Param request body ReplayRequest false "新的目的地（可選）"
+// @Success 202 {object} ReplayResponse
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Failure 503 {object} Response
+// @Router /dead-letters/{id}/replay [post]
+func (h *Handler) Replay(c *gin.Context) {
+	deliveryQueue, ok := requireQueue(c)
+	if !ok {
+		return
+	}
+
+	var req ReplayRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, Response{Success: false, Message: "Invalid request format: " + err.Error()})
+			return
+		}
+	}
+
+	var dest *types.Destination
+	if req.Provider != "" {
+		level := strings.TrimSpace(req.Level)
+		if len(level) > 0 && level[0] >= '0' && level[0] <= '9' {
+			level = "L" + level
+		}
+		if level == "" && req.Channel == "" {
+			c.JSON(http.StatusBadRequest, Response{Success: false, Message: "Either level or channel must be provided with provider"})
+			return
+		}
+		dest = &types.Destination{
+			Provider: strings.ToLower(req.Provider),
+			Level:    level,
+			Channel:  req.Channel,
+		}
+	} else if req.Level != "" || req.Channel != "" {
+		c.JSON(http.StatusBadRequest, Response{Success: false, Message: "Provider is required when overriding the destination"})
+		return
+	}
+
+	receipt, err := deliveryQueue.ReplayDeadLetter(c.Param("id"), dest)
+	if err != nil {
+		respondError(c, "Failed to replay dead letter", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, ReplayResponse{
+		Success:  true,
+		Message:  "Dead letter re-enqueued for delivery",
+		Delivery: receipt,
+	})
+}
+
+// requireQueue 取得全局佇列，未啟用時回傳 503
+func requireQueue(c *gin.Context) (*queue.Queue, bool) {
+	deliveryQueue := queue.GetQueue()
+	if deliveryQueue == nil {
+		c.JSON(http.StatusServiceUnavailable, Response{Success: false, Message: "Dead letters require the queue to be enabled"})
+		return nil, false
+	}
+	return deliveryQueue, true
+}
+
+// respondError 依錯誤類型回傳 404 或 500
+func respondError(c *gin.Context, message string, err error) {
+	if errors.Is(err, queue.ErrNotFound) {
+		c.JSON(http.StatusNotFound, Response{Success: false, Message: "Dead letter not found"})
+		return
+	}
+
+	logger.Error(message, "dead_letters_handler",
+		logger.String("id", c.Param("id")),
+		logger.Err(err))
+	c.JSON(http.StatusInternalServerError, Response{Success: false, Message: message + ": " + err.Error()})
+}
@@ -0,0 +1,21 @@
+package deadletters
+
+import (
+	"alert-webhooks/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes 註冊死信管理路由
+func RegisterRoutes(router *gin.RouterGroup) {
+	handler := NewHandler()
+
+	// 死信管理端點（需要基本認證）
+	deadLetters := router.Group("/dead-letters", middleware.BasicAuth())
+	{
+		deadLetters.GET("", handler.List)
+		deadLetters.GET("/:id", handler.Get)
+		deadLetters.DELETE("/:id", handler.Delete)
+		deadLetters.POST("/:id/replay", handler.Replay)
+	}
+}
@@ -1,22 +1,34 @@
 package discord
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"alert-webhooks/config"
+	"alert-webhooks/pkg/actions"
+	"alert-webhooks/pkg/alertfilter"
 	"alert-webhooks/pkg/alertmodel"
+	"alert-webhooks/pkg/dedup"
+	"alert-webhooks/pkg/discordembed"
 	"alert-webhooks/pkg/logger"
 	"alert-webhooks/pkg/notification/types"
+	"alert-webhooks/pkg/queue"
 	"alert-webhooks/pkg/service"
 	"alert-webhooks/pkg/template"
 
+	"github.com/bwmarrin/discordgo"
 	"github.com/gin-gonic/gin"
 )
 
+// maxInteractionBodyBytes caps the size of an interaction request body
+const maxInteractionBodyBytes = 1 << 20
+
 // Handler handles Discord API requests
 type Handler struct {
 	discordService *service.DiscordService
@@ -49,9 +61,13 @@ type SendMessageRequest struct {
 
 // SendMessageResponse send message response structure
 type SendMessageResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Level   string `json:"level"`
+	Success      bool   `json:"success"`
+	Message      string `json:"message"`
+	Level        string `json:"level"`
+	DeliveryID   string `json:"delivery_id,omitempty"`  // Delivery ID in async mode
+	Deduplicated bool   `json:"deduplicated,omitempty"` // Duplicate within the dedup window, not sent
+	Silenced     int    `json:"silenced,omitempty"`     // Alerts dropped by local silences
+	Inhibited    int    `json:"inhibited,omitempty"`    // Alerts dropped by inhibit rules
 }
 
 // StatusResponse status response structure
@@ -71,7 +87,10 @@ type StatusResponse struct {
 // @Produce json
 // @Param channel path string true "Discord Channel ID"
 // @Param request body SendMessageRequest true "Message request"
+// @Param async query bool false "Async mode: enqueue to the persistent queue and return 202 with a delivery ID (requires queue)"
+// @Param template query string false "Named template set (templates/alerts subdirectory), overrides provider settings"
 // @Success 200 {object} SendMessageResponse
+// @Success 202 {object} SendMessageResponse
 // @Failure 400 {object} SendMessageResponse
 // @Failure 500 {object} SendMessageResponse
 // @Router /discord/channel/{channel} [post]
@@ -103,9 +122,15 @@ func (h *Handler) SendMessageToChannel(c *gin.Context) {
 		return
 	}
 
+	async, _ := strconv.ParseBool(c.Query("async"))
+
 	// Handle direct message
 	if req.Message != "" {
-		err := h.discordService.SendMessageToChannel(channel, req.Message)
+		if async {
+			h.enqueue(c, "", "", channel, &req)
+			return
+		}
+		err := h.discordService.SendMessageToChannel(c.Request.Context(), channel, req.Message)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, SendMessageResponse{
 				Success: false,
@@ -121,6 +146,39 @@ func (h *Handler) SendMessageToChannel(c *gin.Context) {
 		return
 	}
 
+	// Drop silenced and inhibited alerts before rendering; skip the notification when none are left
+	if filtered := applyFilters(&req); filtered != nil && filtered.Empty() {
+		c.JSON(http.StatusOK, SendMessageResponse{
+			Success:   true,
+			Message:   "All alerts silenced or inhibited, notification not sent",
+			Silenced:  filtered.Silenced,
+			Inhibited: filtered.Inhibited,
+		})
+		return
+	}
+
+	// Dedup: send each groupKey/status/fingerprint set once per window; release the key on failure so retries go through
+	key, duplicate := checkDuplicate(types.Destination{Provider: "discord", Channel: channel}, &req)
+	if duplicate {
+		c.JSON(http.StatusOK, SendMessageResponse{
+			Success:      true,
+			Message:      "Duplicate notification suppressed within dedup window",
+			Deduplicated: true,
+		})
+		return
+	}
+	defer func() {
+		if c.Writer.Status() >= http.StatusBadRequest {
+			dedup.Release(key)
+		}
+	}()
+
+	// Async mode: enqueue and let the queue workers render and deliver via NotificationManager
+	if async {
+		h.enqueue(c, "", "", channel, &req)
+		return
+	}
+
 	// Handle AlertManager data (wrapped format)
 	if len(req.AlertManagerData) > 0 {
 		alertDataBytes, err := json.Marshal(req.AlertManagerData)
@@ -132,7 +190,7 @@ func (h *Handler) SendMessageToChannel(c *gin.Context) {
 			return
 		}
 
-		message, err := h.generateAlertManagerMessage(alertDataBytes)
+		message, err := h.generateAlertManagerMessage(alertDataBytes, template.SelectTemplateSet(c.Query("template"), "discord", ""), "")
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, SendMessageResponse{
 				Success: false,
@@ -141,7 +199,7 @@ func (h *Handler) SendMessageToChannel(c *gin.Context) {
 			return
 		}
 
-		err = h.discordService.SendMessageToChannel(channel, message)
+		err = h.discordService.SendChannelMessage(c.Request.Context(), channel, message, req.messageOptions())
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, SendMessageResponse{
 				Success: false,
@@ -182,7 +240,7 @@ func (h *Handler) SendMessageToChannel(c *gin.Context) {
 			return
 		}
 
-		message, err := h.generateAlertManagerMessage(alertDataBytes)
+		message, err := h.generateAlertManagerMessage(alertDataBytes, template.SelectTemplateSet(c.Query("template"), "discord", ""), "")
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, SendMessageResponse{
 				Success: false,
@@ -191,7 +249,7 @@ func (h *Handler) SendMessageToChannel(c *gin.Context) {
 			return
 		}
 
-		err = h.discordService.SendMessageToChannel(channel, message)
+		err = h.discordService.SendChannelMessage(c.Request.Context(), channel, message, req.messageOptions())
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, SendMessageResponse{
 				Success: false,
@@ -221,7 +279,10 @@ func (h *Handler) SendMessageToChannel(c *gin.Context) {
 // @Produce json
 // @Param level path string true "Alert Level (0-5)"
 // @Param request body SendMessageRequest true "Message request"
+// @Param async query bool false "Async mode: enqueue to the persistent queue and return 202 with a delivery ID (requires queue)"
+// @Param template query string false "Named template set (templates/alerts subdirectory), overrides level and provider settings"
 // @Success 200 {object} SendMessageResponse
+// @Success 202 {object} SendMessageResponse
 // @Failure 400 {object} SendMessageResponse
 // @Failure 500 {object} SendMessageResponse
 // @Router /discord/chatid_L{level} [post]
@@ -256,6 +317,45 @@ func (h *Handler) SendMessageToLevel(c *gin.Context) {
 		return
 	}
 
+	// Drop silenced and inhibited alerts before rendering; skip the notification when none are left
+	if req.Message == "" {
+		if filtered := applyFilters(&req); filtered != nil && filtered.Empty() {
+			c.JSON(http.StatusOK, SendMessageResponse{
+				Success:   true,
+				Message:   "All alerts silenced or inhibited, notification not sent",
+				Level:     level,
+				Silenced:  filtered.Silenced,
+				Inhibited: filtered.Inhibited,
+			})
+			return
+		}
+	}
+
+	// Dedup: send each groupKey/status/fingerprint set once per window; release the key on failure so retries go through
+	if req.Message == "" {
+		key, duplicate := checkDuplicate(types.Destination{Provider: "discord", Level: levelKey}, &req)
+		if duplicate {
+			c.JSON(http.StatusOK, SendMessageResponse{
+				Success:      true,
+				Message:      "Duplicate notification suppressed within dedup window",
+				Level:        level,
+				Deduplicated: true,
+			})
+			return
+		}
+		defer func() {
+			if c.Writer.Status() >= http.StatusBadRequest {
+				dedup.Release(key)
+			}
+		}()
+	}
+
+	// Async mode: enqueue and let the queue workers render and deliver via NotificationManager
+	if async, _ := strconv.ParseBool(c.Query("async")); async {
+		h.enqueue(c, level, levelKey, "", &req)
+		return
+	}
+
 	// Handle direct message
 	if req.Message != "" {
 		err := h.discordService.SendMessage(c.Request.Context(), levelKey, req.Message)
@@ -286,7 +386,7 @@ func (h *Handler) SendMessageToLevel(c *gin.Context) {
 			return
 		}
 
-		message, err := h.generateAlertManagerMessage(alertDataBytes)
+		message, err := h.generateAlertManagerMessage(alertDataBytes, template.SelectTemplateSet(c.Query("template"), "discord", levelKey), levelKey)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, SendMessageResponse{
 				Success: false,
@@ -295,7 +395,7 @@ func (h *Handler) SendMessageToLevel(c *gin.Context) {
 			return
 		}
 
-		err = h.discordService.SendMessage(c.Request.Context(), levelKey, message)
+		err = h.discordService.SendLevelMessage(c.Request.Context(), levelKey, message, req.messageOptions())
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, SendMessageResponse{
 				Success: false,
@@ -337,7 +437,7 @@ func (h *Handler) SendMessageToLevel(c *gin.Context) {
 			return
 		}
 
-		message, err := h.generateAlertManagerMessage(alertDataBytes)
+		message, err := h.generateAlertManagerMessage(alertDataBytes, template.SelectTemplateSet(c.Query("template"), "discord", levelKey), levelKey)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, SendMessageResponse{
 				Success: false,
@@ -346,7 +446,7 @@ func (h *Handler) SendMessageToLevel(c *gin.Context) {
 			return
 		}
 
-		err = h.discordService.SendMessage(c.Request.Context(), levelKey, message)
+		err = h.discordService.SendLevelMessage(c.Request.Context(), levelKey, message, req.messageOptions())
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, SendMessageResponse{
 				Success: false,
@@ -369,6 +469,271 @@ func (h *Handler) SendMessageToLevel(c *gin.Context) {
 	})
 }
 
+// enqueue writes the message to the persistent queue and responds with 202 and a delivery ID;
+// channel targets a channel ID directly, otherwise levelKey selects the level channel
+func (h *Handler) enqueue(c *gin.Context, level, levelKey, channel string, req *SendMessageRequest) {
+	deliveryQueue := queue.GetQueue()
+	if deliveryQueue == nil {
+		c.JSON(http.StatusServiceUnavailable, SendMessageResponse{
+			Success: false,
+			Message: "Async delivery requires the queue to be enabled",
+			Level:   level,
+		})
+		return
+	}
+
+	notificationReq := &types.NotificationRequest{
+		ProviderName: "discord",
+		Level:        levelKey,
+		Channel:      channel,
+		Message:      req.Message,
+		TemplateName: c.Query("template"),
+	}
+
+	if req.Message == "" {
+		alertData, err := req.alertData()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, SendMessageResponse{
+				Success: false,
+				Message: fmt.Sprintf("Invalid alertmanager_data: %s", err.Error()),
+				Level:   level,
+			})
+			return
+		}
+		if alertData == nil {
+			c.JSON(http.StatusBadRequest, SendMessageResponse{
+				Success: false,
+				Message: "Either message, alertmanager_data, or direct AlertManager format must be provided",
+			})
+			return
+		}
+		notificationReq.AlertData = alertData
+	}
+
+	receipt, err := deliveryQueue.Submit(notificationReq)
+	if err != nil {
+		logger.Error("Failed to enqueue Discord message", "DiscordHandler",
+			logger.String("level", levelKey),
+			logger.String("channel", channel),
+			logger.Err(err))
+		c.JSON(http.StatusInternalServerError, SendMessageResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to enqueue message: %s", err.Error()),
+			Level:   level,
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, SendMessageResponse{
+		Success:    true,
+		Message:    "Message accepted for delivery",
+		Level:      level,
+		DeliveryID: receipt.ID,
+	})
+}
+
+// alertData converts the wrapped or direct AlertManager payload to the unified structure; returns nil when neither is present
+func (req *SendMessageRequest) alertData() (*types.AlertManagerData, error) {
+	switch {
+	case len(req.AlertManagerData) > 0:
+		// Wrapped format: convert to the unified AlertManager structure via JSON
+		alertData := &types.AlertManagerData{}
+		raw, err := json.Marshal(req.AlertManagerData)
+		if err == nil {
+			err = json.Unmarshal(raw, alertData)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return alertData, nil
+	case len(req.Alerts) > 0 || req.Status != "":
+		return &types.AlertManagerData{
+			Receiver:          req.Receiver,
+			Status:            req.Status,
+			Alerts:            req.Alerts,
+			GroupLabels:       req.GroupLabels,
+			CommonLabels:      req.CommonLabels,
+			CommonAnnotations: req.CommonAnnotations,
+			ExternalURL:       req.ExternalURL,
+			Version:           req.Version,
+			GroupKey:          req.GroupKey,
+			TruncatedAlerts:   req.TruncatedAlerts,
+		}, nil
+	}
+	return nil, nil
+}
+
+// messageOptions returns the action buttons, alert data and, with the embed message format,
+// the alert embeds for an AlertManager payload, or nil
+func (req *SendMessageRequest) messageOptions() *types.DiscordMessageOptions {
+	alertData, err := req.alertData()
+	if err != nil || alertData == nil {
+		return nil
+	}
+	opts := &types.DiscordMessageOptions{
+		Components: actions.DiscordComponents(alertData),
+		AlertData:  alertData,
+	}
+	if discordembed.Enabled() {
+		opts.Embeds = discordembed.AlertEmbeds(alertData)
+	}
+	return opts
+}
+
+// applyFilters drops alerts matched by local silences or inhibit rules and writes the rest back to the request;
+// returns nil when there is no decodable AlertManager payload
+func applyFilters(req *SendMessageRequest) *alertfilter.Result {
+	alertData, err := req.alertData()
+	if err != nil || alertData == nil {
+		return nil
+	}
+
+	filtered := alertfilter.Apply(alertData)
+	if filtered.Dropped() > 0 && !filtered.Empty() {
+		if len(req.AlertManagerData) > 0 {
+			req.AlertManagerData["alerts"] = filtered.Data.Alerts
+			req.AlertManagerData["status"] = filtered.Data.Status
+		} else {
+			req.Alerts = filtered.Data.Alerts
+			req.Status = filtered.Data.Status
+		}
+	}
+	return filtered
+}
+
+// checkDuplicate reports whether the AlertManager payload was already sent to dest within the dedup window;
+// payloads that cannot be decoded are never treated as duplicates
+func checkDuplicate(dest types.Destination, req *SendMessageRequest) (string, bool) {
+	alertData, err := req.alertData()
+	if err != nil || alertData == nil {
+		return "", false
+	}
+	return dedup.Check(dest, alertData)
+}
+
+// HandleInteraction receives Discord interactions
+// @Summary Receive Discord interactions
+// @Description Interactions endpoint for Discord action buttons (Ack / Silence). Verifies the Ed25519 signature against the application public key, answers PING, and edits the original message after a button press
+// @Tags discord
+// @Accept json
+// @Produce json
+// @Param X-Signature-Ed25519 header string true "Ed25519 signature (hex)"
+// @Param X-Signature-Timestamp header string true "Signature timestamp"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} SendMessageResponse
+// @Failure 401 {object} SendMessageResponse
+// @Router /discord/interactions [post]
+func (h *Handler) HandleInteraction(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxInteractionBodyBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, SendMessageResponse{Success: false, Message: "Failed to read request body"})
+		return
+	}
+
+	if err := actions.VerifyDiscordSignature(body, c.GetHeader("X-Signature-Ed25519"), c.GetHeader("X-Signature-Timestamp"), time.Now()); err != nil {
+		logger.Warn("Rejected Discord interaction", "discord_handler",
+			logger.String("client_ip", c.ClientIP()),
+			logger.Err(err))
+		c.JSON(http.StatusUnauthorized, SendMessageResponse{Success: false, Message: "Invalid request signature"})
+		return
+	}
+
+	var interaction discordgo.Interaction
+	if err := json.Unmarshal(body, &interaction); err != nil {
+		c.JSON(http.StatusBadRequest, SendMessageResponse{Success: false, Message: fmt.Sprintf("Invalid interaction: %s", err.Error())})
+		return
+	}
+
+	switch interaction.Type {
+	case discordgo.InteractionPing:
+		c.JSON(http.StatusOK, discordgo.InteractionResponse{Type: discordgo.InteractionResponsePong})
+	case discordgo.InteractionMessageComponent:
+		// Discord expects a reply within 3 seconds; acknowledge now and edit the message once the action completes
+		go h.processInteraction(&interaction)
+		c.JSON(http.StatusOK, discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredMessageUpdate})
+	default:
+		c.JSON(http.StatusBadRequest, SendMessageResponse{Success: false, Message: "Unsupported interaction type"})
+	}
+}
+
+// processInteraction performs the button action and edits the original message to show who acted
+func (h *Handler) processInteraction(interaction *discordgo.Interaction) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Use the current service instance; it is recreated on config reload
+	discordService := service.GetServiceManager().GetDiscordService()
+	if discordService == nil {
+		discordService = h.discordService
+	}
+	if discordService == nil {
+		logger.Error("Discord service not available, cannot handle interaction", "discord_handler")
+		return
+	}
+
+	action, token, ok := actions.ParseDiscordCustomID(interaction.MessageComponentData().CustomID)
+	if !ok {
+		return
+	}
+	user := discordUserName(interaction)
+
+	logger.Info("Discord action received", "discord_handler",
+		logger.String("action", string(action)),
+		logger.String("user", user))
+
+	result, err := actions.Perform(ctx, token, action, user)
+	if err != nil {
+		logger.Error("Failed to perform Discord action", "discord_handler",
+			logger.String("action", string(action)),
+			logger.String("user", user),
+			logger.Err(err))
+		h.followup(discordService, interaction, "Failed: "+err.Error())
+		return
+	}
+	if result.Summary == "" || interaction.Message == nil {
+		h.followup(discordService, interaction, result.Notice)
+		return
+	}
+
+	content, components := actions.DiscordUpdate(interaction.Message.Content, result)
+	if err := discordService.EditInteractionMessage(interaction, content, components); err != nil {
+		logger.Error("Failed to edit Discord message after action", "discord_handler",
+			logger.String("message_id", interaction.Message.ID),
+			logger.Err(err))
+		h.followup(discordService, interaction, result.Notice+", but failed to update the message")
+	}
+}
+
+// followup sends an ephemeral message to the user who pressed the button
+func (h *Handler) followup(discordService *service.DiscordService, interaction *discordgo.Interaction, content string) {
+	if content == "" {
+		return
+	}
+	if err := discordService.InteractionFollowup(interaction, content); err != nil {
+		logger.Warn("Failed to send Discord interaction follow-up", "discord_handler", logger.Err(err))
+	}
+}
+
+// discordUserName returns the name of the user who pressed the button
+func discordUserName(interaction *discordgo.Interaction) string {
+	var user *discordgo.User
+	if interaction.Member != nil && interaction.Member.User != nil {
+		user = interaction.Member.User
+		if interaction.Member.Nick != "" {
+			return interaction.Member.Nick
+		}
+	} else {
+		user = interaction.User
+	}
+	if user == nil {
+		return "unknown"
+	}
+	if user.GlobalName != "" {
+		return user.GlobalName
+	}
+	return user.Username
+}
+
 // GetStatus returns Discord service status
 // @Summary Get Discord service status
 // @Description Get the status of Discord service and bot information
@@ -444,7 +809,7 @@ func (h *Handler) TestChannel(c *gin.Context) {
 	testMessage := fmt.Sprintf("🤖 Discord Test Message\n\nTimestamp: %s\nChannel: %s\n\nThis is a test message from Alert Webhooks Discord bot.",
 		time.Now().UTC().Format("2006-01-02 15:04:05 UTC"), channel)
 
-	err := h.discordService.SendMessageToChannel(channel, testMessage)
+	err := h.discordService.SendMessageToChannel(c.Request.Context(), channel, testMessage)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, SendMessageResponse{
 			Success: false,
@@ -502,8 +867,9 @@ func (h *Handler) ValidateChannel(c *gin.Context) {
 	})
 }
 
-// generateAlertManagerMessage generates a formatted message from AlertManager data
-func (h *Handler) generateAlertManagerMessage(alertData json.RawMessage) (string, error) {
+// generateAlertManagerMessage generates a formatted message from AlertManager data using the
+// named template set (empty for the default templates); level selects the time_format level settings
+func (h *Handler) generateAlertManagerMessage(alertData json.RawMessage, templateName, level string) (string, error) {
 	// Parse AlertManager JSON
 	var req types.AlertManagerData
 	if err := json.Unmarshal(alertData, &req); err != nil {
@@ -526,13 +892,14 @@ func (h *Handler) generateAlertManagerMessage(alertData json.RawMessage) (string
 				req.ExternalURL,
 				formatOptions,
 			)
+			data.Level = level
 
 			language := config.Conf.Discord.TemplateLanguage
 			if language == "" {
 				language = "tw"
 			}
-			actual := templateEngine.GetDefaultLanguage(language)
-			message, err := templateEngine.RenderTemplateForPlatform(actual, "discord", data)
+			actual := templateEngine.GetTemplateSetLanguage(templateName, language)
+			message, err := templateEngine.RenderNamedTemplateForPlatform(templateName, actual, "discord", data)
 			if err != nil {
 				logger.Error("Failed to render template, falling back to built-in", "DiscordHandler", logger.String("error", err.Error()))
 				return h.generateBuiltInMessage(alertData)
@@ -1,8 +1,11 @@
 package discord
 
 import (
-	"github.com/gin-gonic/gin"
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/logger"
 	"alert-webhooks/pkg/service"
+
+	"github.com/gin-gonic/gin"
 )
 
 // SetupRoutes configures Discord API routes
@@ -17,6 +20,15 @@ func SetupRoutes(router *gin.RouterGroup, discordService *service.DiscordService
 	// Note: Create handler even if service is nil - handler will check service availability
 	handler := NewHandler(discordService)
 
+	// Interactions endpoint for action buttons, authenticated by Ed25519 signature instead of basic auth
+	if config.Actions.Discord.Enable {
+		if config.Actions.Discord.PublicKey != "" {
+			router.POST("/discord/interactions", handler.HandleInteraction)
+		} else {
+			logger.Warn("Discord actions require public_key, /discord/interactions not registered", "discord_routes")
+		}
+	}
+
 	// Discord routes (consistent with Telegram/Slack pattern)
 	discordRoutes := router.Group("/discord")
 	{
@@ -2,9 +2,14 @@ package v1
 
 import (
 	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/notification"
+	v1alertmanager "alert-webhooks/routes/api/v1/alertmanager"
+	v1deadletters "alert-webhooks/routes/api/v1/deadletters"
 	v1discord "alert-webhooks/routes/api/v1/discord"
+	v1silences "alert-webhooks/routes/api/v1/silences"
 	v1slack "alert-webhooks/routes/api/v1/slack"
 	v1telegram "alert-webhooks/routes/api/v1/telegram"
+	v1templates "alert-webhooks/routes/api/v1/templates"
   "alert-webhooks/pkg/service"
 	"github.com/gin-gonic/gin"
 )
@@ -37,5 +42,17 @@ func RegisterApiV1Routes(router *gin.RouterGroup) {
 		logger.Info("Discord service not ready, skipping Discord routes", "routes")
 	}
 	
+	// 註冊 Alertmanager 統一接收路由（透過 NotificationManager 扇出到所有提供者）
+	v1alertmanager.RegisterRoutes(router, notification.GetNotificationManager())
+
+	// 註冊死信管理路由（需啟用 queue）
+	v1deadletters.RegisterRoutes(router)
+
+	// 註冊本地靜音管理路由（需啟用 silence）
+	v1silences.RegisterRoutes(router)
+
+	// 註冊模板預覽路由
+	v1templates.RegisterRoutes(router)
+
 	logger.Info("API V1 routes registered successfully", "routes")
 }
@@ -0,0 +1,214 @@
+// Package silences 提供本地靜音的建立、查詢與刪除管理端點
+package silences
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/silence"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler 靜音管理處理器
+type Handler struct{}
+
+// NewHandler 創建新的靜音管理處理器
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// Response 靜音管理端點的通用響應結構
+type Response struct {
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+}
+
+// CreateRequest 建立靜音請求
+type CreateRequest struct {
+	Matchers  []string  `json:"matchers" binding:"required"` // 標籤匹配器，例如 alertname="HighCPU"、namespace=~"dev-.*"
+	StartsAt  time.Time `json:"starts_at"`                   // 開始時間（RFC3339），預設為現在
+	EndsAt    time.Time `json:"ends_at"`                     // 結束時間（RFC3339），與 duration 擇一
+	Duration  string    `json:"duration,omitempty"`          // 持續時間（例如 2h），未提供 ends_at 時使用
+	CreatedBy string    `json:"created_by" binding:"required"`
+	Comment   string    `json:"comment" binding:"required"`
+}
+
+// ListResponse 靜音列表響應
+type ListResponse struct {
+	Success  bool               `json:"success"`
+	Count    int                `json:"count"`
+	Silences []*silence.Silence `json:"silences"`
+}
+
+// DetailResponse 單筆靜音響應
+type DetailResponse struct {
+	Success bool             `json:"success"`
+	Silence *silence.Silence `json:"silence"`
+}
+
+// Create 建立靜音
+// @Summary Create silence
+// @Description 建立本地靜音；有效期間內所有標籤命中全部匹配器的警報會在渲染前被移除，群組中沒有剩餘警報時不發送通知
+// @Tags silences
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param request body CreateRequest true "靜音內容"
+// @Success 201 {object} DetailResponse
+// @Failure 400 {object} Response
+// @Failure 503 {object} Response
+// @Router /silences [post]
+func (h *Handler) Create(c *gin.Context) {
+	store, ok := requireStore(c)
+	if !ok {
+		return
+	}
+
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Success: false, Message: "Invalid request format: " + err.Error()})
+		return
+	}
+
+	if req.EndsAt.IsZero() && req.Duration != "" {
+		duration, err := time.ParseDuration(req.Duration)
+		if err != nil || duration <= 0 {
+			c.JSON(http.StatusBadRequest, Response{Success: false, Message: "Invalid duration: " + req.Duration})
+			return
+		}
+		start := req.StartsAt
+		if start.IsZero() {
+			start = time.Now()
+		}
+		req.StartsAt = start
+		req.EndsAt = start.Add(duration)
+	}
+
+	created, err := store.Create(&silence.Silence{
+		Matchers:  req.Matchers,
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		CreatedBy: req.CreatedBy,
+		Comment:   req.Comment,
+	})
+	if err != nil {
+		respondError(c, "Failed to create silence", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, DetailResponse{Success: true, Silence: created})
+}
+
+// List 列出靜音
+// @Summary List silences
+// @Description 列出本地靜音，可依狀態篩選
+// @Tags silences
+// @Produce json
+// @Security BasicAuth
+// @Param state query string false "狀態 (active, pending, expired)"
+// @Success 200 {object} ListResponse
+// @Failure 400 {object} Response
+// @Failure 503 {object} Response
+// @Router /silences [get]
+func (h *Handler) List(c *gin.Context) {
+	store, ok := requireStore(c)
+	if !ok {
+		return
+	}
+
+	state := c.Query("state")
+	switch state {
+	case "", silence.StateActive, silence.StatePending, silence.StateExpired:
+	default:
+		c.JSON(http.StatusBadRequest, Response{Success: false, Message: "Invalid state, must be active, pending or expired"})
+		return
+	}
+
+	silences := store.List(state)
+	c.JSON(http.StatusOK, ListResponse{
+		Success:  true,
+		Count:    len(silences),
+		Silences: silences,
+	})
+}
+
+// Get 查詢單筆靜音
+// @Summary Get silence
+// @Description 查詢單筆本地靜音
+// @Tags silences
+// @Produce json
+// @Security BasicAuth
+// @Param id path string true "靜音 ID"
+// @Success 200 {object} DetailResponse
+// @Failure 404 {object} Response
+// @Failure 503 {object} Response
+// @Router /silences/{id} [get]
+func (h *Handler) Get(c *gin.Context) {
+	store, ok := requireStore(c)
+	if !ok {
+		return
+	}
+
+	s, err := store.Get(c.Param("id"))
+	if err != nil {
+		respondError(c, "Failed to get silence", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, DetailResponse{Success: true, Silence: s})
+}
+
+// Delete 刪除單筆靜音
+// @Summary Delete silence
+// @Description 刪除本地靜音，刪除後立即恢復通知
+// @Tags silences
+// @Produce json
+// @Security BasicAuth
+// @Param id path string true "靜音 ID"
+// @Success 200 {object} Response
+// @Failure 404 {object} Response
+// @Failure 503 {object} Response
+// @Router /silences/{id} [delete]
+func (h *Handler) Delete(c *gin.Context) {
+	store, ok := requireStore(c)
+	if !ok {
+		return
+	}
+
+	if err := store.Delete(c.Param("id")); err != nil {
+		respondError(c, "Failed to delete silence", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Success: true, Message: "Silence deleted"})
+}
+
+// requireStore 取得全局靜音存放區，未啟用時回傳 503
+func requireStore(c *gin.Context) (*silence.Store, bool) {
+	store := silence.GetStore()
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, Response{Success: false, Message: "Silences require silence.enable to be set"})
+		return nil, false
+	}
+	return store, true
+}
+
+// respondError 依錯誤類型回傳 400、404 或 500
+func respondError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, silence.ErrNotFound):
+		c.JSON(http.StatusNotFound, Response{Success: false, Message: "Silence not found"})
+		return
+	case errors.Is(err, silence.ErrInvalid):
+		c.JSON(http.StatusBadRequest, Response{Success: false, Message: err.Error()})
+		return
+	}
+
+	logger.Error(message, "silences_handler",
+		logger.String("id", c.Param("id")),
+		logger.Err(err))
+	c.JSON(http.StatusInternalServerError, Response{Success: false, Message: message + ": " + err.Error()})
+}
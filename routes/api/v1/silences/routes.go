@@ -0,0 +1,21 @@
+package silences
+
+import (
+	"alert-webhooks/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes 註冊靜音管理路由
+func RegisterRoutes(router *gin.RouterGroup) {
+	handler := NewHandler()
+
+	// 靜音管理端點（需要基本認證）
+	silences := router.Group("/silences", middleware.BasicAuth())
+	{
+		silences.POST("", handler.Create)
+		silences.GET("", handler.List)
+		silences.GET("/:id", handler.Get)
+		silences.DELETE("/:id", handler.Delete)
+	}
+}
@@ -1,47 +1,46 @@
 package slack
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"alert-webhooks/config"
+	"alert-webhooks/pkg/actions"
+	"alert-webhooks/pkg/alertfilter"
 	"alert-webhooks/pkg/alertmodel"
+	"alert-webhooks/pkg/dedup"
 	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/notification/types"
+	"alert-webhooks/pkg/queue"
 	"alert-webhooks/pkg/service"
 	"alert-webhooks/pkg/template"
 
 	"github.com/gin-gonic/gin"
 )
 
+// maxInteractionBodyBytes Slack 互動事件請求主體上限
+const maxInteractionBodyBytes = 1 << 20
+
 // Handler Slack 路由處理器
 type Handler struct {
-	slackService   *service.SlackService
-	templateEngine *template.TemplateEngine
+	slackService *service.SlackService
 }
 
-// NewHandler 創建新的 Slack 路由處理器
+// NewHandler 創建新的 Slack 路由處理器；模板引擎由 ServiceManager 管理，每次請求時重新取得，
+// 模板重新載入後立即生效
 func NewHandler(slackService *service.SlackService) *Handler {
 	logger.Info("Creating new Slack handler", "slack_handler")
 
-	// 從 ServiceManager 獲取模板引擎
-	serviceManager := service.GetServiceManager()
-	templateEngine := serviceManager.GetTemplateEngine()
-
-	if templateEngine == nil {
-		logger.Error("Template engine not available from service manager", "slack_handler")
-		return &Handler{
-			slackService:   slackService,
-			templateEngine: template.NewTemplateEngine(), // 後備方案
-		}
-	}
-
-	logger.Info("Template engine obtained from service manager", "slack_handler")
-
 	return &Handler{
-		slackService:   slackService,
-		templateEngine: templateEngine,
+		slackService: slackService,
 	}
 }
 
@@ -82,9 +81,13 @@ type RichMessageRequest struct {
 
 // SendMessageResponse 發送訊息響應結構
 type SendMessageResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Level   string `json:"level"`
+	Success      bool   `json:"success"`
+	Message      string `json:"message"`
+	Level        string `json:"level"`
+	DeliveryID   string `json:"delivery_id,omitempty"`  // 非同步模式下的投遞 ID
+	Deduplicated bool   `json:"deduplicated,omitempty"` // 去重窗口內的重複通知，未發送
+	Silenced     int    `json:"silenced,omitempty"`     // 被本地靜音移除的警報數
+	Inhibited    int    `json:"inhibited,omitempty"`    // 被抑制規則移除的警報數
 }
 
 // StatusResponse Slack 服務狀態響應
@@ -110,7 +113,10 @@ type ChannelsResponse struct {
 // @Security BasicAuth
 // @Param channel path string true "頻道名稱 (例如: alerts, emergency)"
 // @Param request body SendMessageRequest true "發送訊息請求"
+// @Param async query bool false "非同步模式：寫入持久化佇列後立即回傳 202 與投遞 ID（需啟用 queue）"
+// @Param template query string false "具名模板集（templates/alerts 子目錄），優先於提供者配置"
 // @Success 200 {object} SendMessageResponse
+// @Success 202 {object} SendMessageResponse
 // @Failure 400 {object} SendMessageResponse
 // @Failure 401 {object} SendMessageResponse
 // @Failure 500 {object} SendMessageResponse
@@ -158,27 +164,70 @@ func (h *Handler) SendMessageToChannel(c *gin.Context) {
 		return
 	}
 
+	// 渲染前移除被靜音或被抑制的警報，全部被移除時不發送
+	if req.Message == "" {
+		if filtered := applyFilters(&req, isRawAlertManager); filtered != nil && filtered.Empty() {
+			c.JSON(http.StatusOK, SendMessageResponse{
+				Success:   true,
+				Message:   "All alerts silenced or inhibited, notification not sent",
+				Silenced:  filtered.Silenced,
+				Inhibited: filtered.Inhibited,
+			})
+			return
+		}
+	}
+
+	// 去重：窗口內相同 groupKey、狀態與 fingerprint 的通知只發送一次，發送失敗時釋放鍵值讓重試通過
+	if req.Message == "" {
+		key, duplicate := checkDuplicate(types.Destination{Provider: "slack", Channel: channel}, &req, isRawAlertManager)
+		if duplicate {
+			c.JSON(http.StatusOK, SendMessageResponse{
+				Success:      true,
+				Message:      "Duplicate notification suppressed within dedup window",
+				Deduplicated: true,
+			})
+			return
+		}
+		defer func() {
+			if c.Writer.Status() >= http.StatusBadRequest {
+				dedup.Release(key)
+			}
+		}()
+	}
+
+	// 非同步模式：寫入持久化佇列，由 worker 透過 NotificationManager 渲染並投遞
+	if async, _ := strconv.ParseBool(c.Query("async")); async {
+		h.enqueue(c, "", channel, &req, isRawAlertManager)
+		return
+	}
+
 	var message string
 	if req.Message != "" {
 		message = req.Message
 	} else if isRawAlertManager {
 		// 處理原始 AlertManager JSON 格式
-		message = h.formatAlertManagerMessage(&req)
+		message = h.formatAlertManagerMessage(&req, template.SelectTemplateSet(c.Query("template"), "slack", ""))
 	} else {
 		// 處理包裝格式的 AlertManager 數據
 		message = "AlertManager notification (wrapped format - template integration pending)"
 	}
 
 	// 構建 Slack 訊息選項
-	options := &service.SlackMessage{
+	options := &types.SlackMessageOptions{
 		Username:  req.Username,
 		IconURL:   req.IconURL,
 		IconEmoji: req.IconEmoji,
 		ThreadTS:  req.ThreadTS,
 	}
+	if req.Message == "" {
+		// blocks 模式的版面與觸發中警報的互動按鈕由 SlackService 產生
+		alertData, _ := toAlertData(&req, isRawAlertManager)
+		options.AlertData = alertData
+		options.TemplateName = c.Query("template")
+	}
 
 	// 發送訊息
-	if err := h.slackService.SendMessageWithOptions(channel, message, options); err != nil {
+	if err := h.slackService.SendChannelMessage(c.Request.Context(), channel, message, options); err != nil {
 		logger.Error("Failed to send Slack message", "slack_handler",
 			logger.String("channel", channel),
 			logger.String("message", message),
@@ -206,7 +255,10 @@ func (h *Handler) SendMessageToChannel(c *gin.Context) {
 // @Security BasicAuth
 // @Param level path string true "等級名稱 (例如: emergency, critical, warning, info)"
 // @Param request body SendMessageRequest true "發送訊息請求"
+// @Param async query bool false "非同步模式：寫入持久化佇列後立即回傳 202 與投遞 ID（需啟用 queue）"
+// @Param template query string false "具名模板集（templates/alerts 子目錄），優先於路由、level 與提供者配置"
 // @Success 200 {object} SendMessageResponse
+// @Success 202 {object} SendMessageResponse
 // @Failure 400 {object} SendMessageResponse
 // @Failure 401 {object} SendMessageResponse
 // @Failure 500 {object} SendMessageResponse
@@ -254,6 +306,45 @@ func (h *Handler) SendMessageToLevel(c *gin.Context) {
 		return
 	}
 
+	// 渲染前移除被靜音或被抑制的警報，全部被移除時不發送
+	if req.Message == "" {
+		if filtered := applyFilters(&req, isRawAlertManager); filtered != nil && filtered.Empty() {
+			c.JSON(http.StatusOK, SendMessageResponse{
+				Success:   true,
+				Message:   "All alerts silenced or inhibited, notification not sent",
+				Level:     level,
+				Silenced:  filtered.Silenced,
+				Inhibited: filtered.Inhibited,
+			})
+			return
+		}
+	}
+
+	// 去重：窗口內相同 groupKey、狀態與 fingerprint 的通知只發送一次，發送失敗時釋放鍵值讓重試通過
+	if req.Message == "" {
+		key, duplicate := checkDuplicate(types.Destination{Provider: "slack", Level: level}, &req, isRawAlertManager)
+		if duplicate {
+			c.JSON(http.StatusOK, SendMessageResponse{
+				Success:      true,
+				Message:      "Duplicate notification suppressed within dedup window",
+				Level:        level,
+				Deduplicated: true,
+			})
+			return
+		}
+		defer func() {
+			if c.Writer.Status() >= http.StatusBadRequest {
+				dedup.Release(key)
+			}
+		}()
+	}
+
+	// 非同步模式：寫入持久化佇列，由 worker 透過 NotificationManager 渲染並投遞
+	if async, _ := strconv.ParseBool(c.Query("async")); async {
+		h.enqueue(c, level, "", &req, isRawAlertManager)
+		return
+	}
+
 	var message string
 	if req.Message != "" {
 		message = req.Message
@@ -277,8 +368,10 @@ func (h *Handler) SendMessageToLevel(c *gin.Context) {
 		lang := config.Slack.TemplateLanguage
 		if lang == "" { lang = "eng" }
 		if te != nil {
-			actual := te.GetDefaultLanguage(lang)
-			if msg, err := te.RenderTemplateForPlatform(actual, "slack", data); err == nil {
+			templateName := template.SelectTemplateSet(c.Query("template"), "slack", level)
+			data.Level = level
+			actual := te.GetTemplateSetLanguage(templateName, lang)
+			if msg, err := te.RenderNamedTemplateForPlatform(templateName, actual, "slack", data); err == nil {
 				message = msg
 			} else {
 				message = h.generateBuiltInSlackMessage(&req, data.FiringCount, data.ResolvedCount, data.AlertName, data.Env, data.Severity, data.Namespace)
@@ -291,8 +384,14 @@ func (h *Handler) SendMessageToLevel(c *gin.Context) {
 		message = "AlertManager notification (wrapped format - template integration pending)"
 	}
 
-	// 發送訊息到指定等級
-	if err := h.slackService.SendMessageToLevel(c.Request.Context(), level, message); err != nil {
+	// 發送訊息到指定等級，blocks 模式的版面與觸發中警報的互動按鈕由 SlackService 產生
+	options := &types.SlackMessageOptions{}
+	if req.Message == "" {
+		alertData, _ := toAlertData(&req, isRawAlertManager)
+		options.AlertData = alertData
+		options.TemplateName = c.Query("template")
+	}
+	if err := h.slackService.SendLevelMessage(c.Request.Context(), level, message, options); err != nil {
 		logger.Error("Failed to send Slack message to level", "slack_handler",
 			logger.String("level", level),
 			logger.String("message", message),
@@ -312,6 +411,118 @@ func (h *Handler) SendMessageToLevel(c *gin.Context) {
 	})
 }
 
+// enqueue 將訊息寫入持久化佇列並回傳 202 與投遞 ID；channel 不為空時直接發送到該頻道，否則依 level 選擇頻道
+func (h *Handler) enqueue(c *gin.Context, level, channel string, req *SendMessageRequest, isRawAlertManager bool) {
+	deliveryQueue := queue.GetQueue()
+	if deliveryQueue == nil {
+		c.JSON(http.StatusServiceUnavailable, SendMessageResponse{
+			Success: false,
+			Message: "Async delivery requires the queue to be enabled",
+			Level:   level,
+		})
+		return
+	}
+
+	notificationReq := &types.NotificationRequest{
+		ProviderName: "slack",
+		Level:        level,
+		Channel:      channel,
+		Message:      req.Message,
+		TemplateName: c.Query("template"),
+	}
+	if req.Message == "" {
+		alertData, err := toAlertData(req, isRawAlertManager)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, SendMessageResponse{
+				Success: false,
+				Message: "Invalid alertmanager_data: " + err.Error(),
+				Level:   level,
+			})
+			return
+		}
+		notificationReq.AlertData = alertData
+	}
+
+	receipt, err := deliveryQueue.Submit(notificationReq)
+	if err != nil {
+		logger.Error("Failed to enqueue Slack message", "slack_handler",
+			logger.String("level", level),
+			logger.String("channel", channel),
+			logger.Err(err))
+		c.JSON(http.StatusInternalServerError, SendMessageResponse{
+			Success: false,
+			Message: "Failed to enqueue message: " + err.Error(),
+			Level:   level,
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, SendMessageResponse{
+		Success:    true,
+		Message:    "Message accepted for delivery",
+		Level:      level,
+		DeliveryID: receipt.ID,
+	})
+}
+
+// toAlertData 將原始或包裝格式的 AlertManager 數據轉為統一的 AlertManagerData
+func toAlertData(req *SendMessageRequest, isRawAlertManager bool) (*types.AlertManagerData, error) {
+	if isRawAlertManager {
+		return &types.AlertManagerData{
+			Receiver:          req.Receiver,
+			Status:            req.Status,
+			Alerts:            req.Alerts,
+			GroupLabels:       req.GroupLabels,
+			CommonLabels:      req.CommonLabels,
+			CommonAnnotations: req.CommonAnnotations,
+			ExternalURL:       req.ExternalURL,
+			Version:           req.Version,
+			GroupKey:          req.GroupKey,
+			TruncatedAlerts:   req.TruncatedAlerts,
+		}, nil
+	}
+
+	// 包裝格式：透過 JSON 轉換為統一的 AlertManager 結構
+	alertData := &types.AlertManagerData{}
+	raw, err := json.Marshal(req.AlertManagerData)
+	if err == nil {
+		err = json.Unmarshal(raw, alertData)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return alertData, nil
+}
+
+// applyFilters 移除被本地靜音命中或被抑制的警報並寫回請求，數據無法解析時返回 nil
+func applyFilters(req *SendMessageRequest, isRawAlertManager bool) *alertfilter.Result {
+	alertData, err := toAlertData(req, isRawAlertManager)
+	if err != nil {
+		return nil
+	}
+
+	filtered := alertfilter.Apply(alertData)
+	if filtered.Dropped() > 0 && !filtered.Empty() {
+		if isRawAlertManager {
+			req.Alerts = filtered.Data.Alerts
+			req.Status = filtered.Data.Status
+		} else {
+			req.AlertManagerData["alerts"] = filtered.Data.Alerts
+			req.AlertManagerData["status"] = filtered.Data.Status
+		}
+	}
+	return filtered
+}
+
+// checkDuplicate 檢查 AlertManager 通知是否在去重窗口內已發送到 dest，數據無法解析時不去重
+func checkDuplicate(dest types.Destination, req *SendMessageRequest, isRawAlertManager bool) (string, bool) {
+	alertData, err := toAlertData(req, isRawAlertManager)
+	if err != nil {
+		return "", false
+	}
+	return dedup.Check(dest, alertData)
+}
+
 // SendRichMessage 發送富文本訊息
 // @Summary 發送富文本 Slack 訊息
 // @Description 發送包含附件和字段的富文本訊息到指定 Slack 頻道
@@ -573,8 +784,171 @@ func (h *Handler) ValidateChannel(c *gin.Context) {
 	})
 }
 
-// formatAlertManagerMessage 格式化原始 AlertManager JSON 為 Slack 訊息
-func (h *Handler) formatAlertManagerMessage(req *SendMessageRequest) string {
+// interactionPayload Slack block_actions 互動事件
+type interactionPayload struct {
+	Type string `json:"type"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+		Name     string `json:"name"`
+	} `json:"user"`
+	Container struct {
+		ChannelID string `json:"channel_id"`
+		MessageTS string `json:"message_ts"`
+	} `json:"container"`
+	Message struct {
+		TS     string            `json:"ts"`
+		Text   string            `json:"text"`
+		Blocks []json.RawMessage `json:"blocks"`
+	} `json:"message"`
+	ResponseURL string `json:"response_url"`
+	Actions     []struct {
+		ActionID string `json:"action_id"`
+		BlockID  string `json:"block_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+}
+
+// HandleInteraction 接收 Slack 互動事件
+// @Summary 接收 Slack 互動事件
+// @Description 接收 Slack App 的 block_actions 互動事件（Acknowledge / Silence），以 Signing Secret 驗證 X-Slack-Signature，完成後以 chat.update 更新原始訊息
+// @Tags slack
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param X-Slack-Signature header string true "v0=<HMAC-SHA256>"
+// @Param X-Slack-Request-Timestamp header string true "請求時間戳（秒）"
+// @Param payload formData string true "互動事件 JSON"
+// @Success 200
+// @Failure 400 {object} SendMessageResponse
+// @Failure 401 {object} SendMessageResponse
+// @Router /slack/interactions [post]
+func (h *Handler) HandleInteraction(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxInteractionBodyBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, SendMessageResponse{Success: false, Message: "Failed to read request body"})
+		return
+	}
+
+	if err := actions.VerifySlackSignature(body, c.GetHeader("X-Slack-Request-Timestamp"), c.GetHeader("X-Slack-Signature"), time.Now()); err != nil {
+		logger.Warn("Rejected Slack interaction", "slack_handler",
+			logger.String("client_ip", c.ClientIP()),
+			logger.Err(err))
+		c.JSON(http.StatusUnauthorized, SendMessageResponse{Success: false, Message: "Invalid signature: " + err.Error()})
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, SendMessageResponse{Success: false, Message: "Invalid form body"})
+		return
+	}
+	var payload interactionPayload
+	if err := json.Unmarshal([]byte(form.Get("payload")), &payload); err != nil {
+		c.JSON(http.StatusBadRequest, SendMessageResponse{Success: false, Message: "Invalid payload: " + err.Error()})
+		return
+	}
+
+	// Slack 要求 3 秒內回應，動作（可能呼叫 Alertmanager）在背景執行
+	if payload.Type == "block_actions" {
+		go h.processInteraction(&payload)
+	}
+	c.Status(http.StatusOK)
+}
+
+// processInteraction 執行按鈕動作並以 chat.update 更新原始訊息
+func (h *Handler) processInteraction(payload *interactionPayload) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	user := payload.User.Username
+	if user == "" {
+		user = payload.User.Name
+	}
+	if user == "" {
+		user = payload.User.ID
+	}
+
+	for _, a := range payload.Actions {
+		if a.BlockID != actions.SlackActionsBlockID || a.ActionID == actions.SlackRunbookActionID {
+			continue
+		}
+		action := actions.Action(a.ActionID)
+
+		logger.Info("Slack action received", "slack_handler",
+			logger.String("action", string(action)),
+			logger.String("user", user))
+
+		result, err := actions.Perform(ctx, a.Value, action, user)
+		if err != nil {
+			logger.Error("Failed to perform Slack action", "slack_handler",
+				logger.String("action", string(action)),
+				logger.String("user", user),
+				logger.Err(err))
+			postEphemeralResponse(ctx, payload.ResponseURL, "Failed: "+err.Error())
+			continue
+		}
+		if result.Summary == "" {
+			postEphemeralResponse(ctx, payload.ResponseURL, result.Notice)
+			continue
+		}
+
+		// 使用目前的服務實例，配置重新載入後服務會被重新建立
+		slackService := service.GetServiceManager().GetSlackService()
+		if slackService == nil {
+			slackService = h.slackService
+		}
+		if slackService == nil {
+			continue
+		}
+
+		blocks := actions.SlackUpdatedBlocks(payload.Message.Blocks, result)
+		if err := slackService.UpdateMessage(ctx, payload.Container.ChannelID, payload.Container.MessageTS, payload.Message.Text, blocks); err != nil {
+			postEphemeralResponse(ctx, payload.ResponseURL, result.Notice+", but failed to update the message: "+err.Error())
+			continue
+		}
+		// 後續動作以更新後的 blocks 為基礎
+		payload.Message.Blocks = toRawBlocks(blocks)
+	}
+}
+
+// postEphemeralResponse 透過 response_url 回覆只有按下按鈕的使用者看得到的訊息
+func postEphemeralResponse(ctx context.Context, responseURL, text string) {
+	if responseURL == "" || text == "" {
+		return
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"response_type":    "ephemeral",
+		"replace_original": false,
+		"text":             text,
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		logger.Warn("Failed to post Slack ephemeral response", "slack_handler", logger.Err(err))
+		return
+	}
+	resp.Body.Close()
+}
+
+// toRawBlocks 將 blocks 轉回 JSON 以便下一個動作重建
+func toRawBlocks(blocks []interface{}) []json.RawMessage {
+	raw := make([]json.RawMessage, 0, len(blocks))
+	for _, block := range blocks {
+		if b, err := json.Marshal(block); err == nil {
+			raw = append(raw, b)
+		}
+	}
+	return raw
+}
+
+// formatAlertManagerMessage 以指定的具名模板集（空值為預設模板）格式化原始 AlertManager JSON 為 Slack 訊息
+func (h *Handler) formatAlertManagerMessage(req *SendMessageRequest, templateName string) string {
 	// 使用 Slack 配置中的模板語言
 	templateLanguage := config.Slack.TemplateLanguage
 	if templateLanguage == "" {
@@ -685,14 +1059,14 @@ func (h *Handler) formatAlertManagerMessage(req *SendMessageRequest) string {
 	// 嘗試使用模板引擎渲染
 	if currentTemplateEngine != nil {
 		// 獲取合適的語言（包含回退邏輯）
-		actualLanguage := currentTemplateEngine.GetDefaultLanguage(templateLanguage)
+		actualLanguage := currentTemplateEngine.GetTemplateSetLanguage(templateName, templateLanguage)
 		if actualLanguage != templateLanguage {
 			logger.Info("Language fallback applied for Slack", "slack_handler",
 				logger.String("requested", templateLanguage),
 				logger.String("actual", actualLanguage))
 		}
 
-		message, err := currentTemplateEngine.RenderTemplateForPlatform(actualLanguage, "slack", templateData)
+		message, err := currentTemplateEngine.RenderNamedTemplateForPlatform(templateName, actualLanguage, "slack", templateData)
 		if err == nil {
 			messagePreview := message
 			if len(message) > 100 {
@@ -1,6 +1,8 @@
 package slack
 
 import (
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/logger"
 	"alert-webhooks/pkg/middleware"
 	"alert-webhooks/pkg/service"
 
@@ -20,6 +22,15 @@ func RegisterSlackRoutes(r *gin.RouterGroup, slackService *service.SlackService)
 	// 創建處理器
 	handler := NewHandler(slackService)
 
+	// 互動按鈕入口，以 Signing Secret 驗證而非基本認證
+	if config.Actions.Slack.Enable {
+		if config.Actions.Slack.SigningSecret != "" {
+			r.POST("/slack/interactions", handler.HandleInteraction)
+		} else {
+			logger.Warn("Slack actions require signing_secret, /slack/interactions not registered", "slack_routes")
+		}
+	}
+
 	// Slack 路由群組
 	slackGroup := r.Group("/slack")
 	{
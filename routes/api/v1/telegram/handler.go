@@ -3,6 +3,7 @@ package telegram
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -11,9 +12,15 @@ import (
 	"time"
 
 	"alert-webhooks/config"
+	"alert-webhooks/pkg/actions"
+	"alert-webhooks/pkg/alertfilter"
 	"alert-webhooks/pkg/alertmodel"
+	"alert-webhooks/pkg/dedup"
 	"alert-webhooks/pkg/logger"
+	"alert-webhooks/pkg/notification/types"
+	"alert-webhooks/pkg/queue"
 	"alert-webhooks/pkg/service"
+	"alert-webhooks/pkg/telegramhtml"
 	"alert-webhooks/pkg/template"
 
 	"github.com/gin-gonic/gin"
@@ -23,39 +30,16 @@ import (
 // Handler Telegram 路由處理器
 type Handler struct {
 	telegramService *service.TelegramService
-	templateEngine  *template.TemplateEngine
 }
 
-// NewHandler 創建新的 Telegram 路由處理器
+// NewHandler 創建新的 Telegram 路由處理器；模板引擎由 ServiceManager 管理，每次請求時重新取得，
+// 模板重新載入後立即生效
 func NewHandler(telegramService *service.TelegramService) *Handler {
 	logger.Info("Creating new Telegram handler", "telegram_handler")
 
-	// 從 ServiceManager 獲取模板引擎
-	serviceManager := service.GetServiceManager()
-	templateEngine := serviceManager.GetTemplateEngine()
-
-	if templateEngine == nil {
-		logger.Error("Template engine not available from service manager", "telegram_handler")
-		return &Handler{
-			telegramService: telegramService,
-			templateEngine:  template.NewTemplateEngine(), // 後備方案
-		}
-	}
-
-	logger.Info("Template engine obtained from service manager", "telegram_handler")
-
-
-
-	// 模板引擎現在由 ServiceManager 管理，不需要在 handler 中初始化
-	handler := &Handler{
+	return &Handler{
 		telegramService: telegramService,
-		templateEngine:  templateEngine,
 	}
-
-	logger.Info("Telegram handler created", "telegram_handler",
-		logger.Bool("has_template_engine", templateEngine != nil))
-
-	return handler
 }
 
 // SendMessageRequest send message request structure
@@ -92,9 +76,13 @@ type Alert struct {
 
 // SendMessageResponse send message response structure
 type SendMessageResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Level   int    `json:"level"`
+	Success      bool   `json:"success"`
+	Message      string `json:"message"`
+	Level        int    `json:"level"`
+	DeliveryID   string `json:"delivery_id,omitempty"`  // 非同步模式下的投遞 ID
+	Deduplicated bool   `json:"deduplicated,omitempty"` // 去重窗口內的重複通知，未發送
+	Silenced     int    `json:"silenced,omitempty"`     // 被本地靜音移除的警報數
+	Inhibited    int    `json:"inhibited,omitempty"`    // 被抑制規則移除的警報數
 }
 
 // SendMessage send Telegram message
@@ -105,8 +93,11 @@ type SendMessageResponse struct {
 // @Produce json
 // @Param chatid path string true "聊天等級 (格式: L{0-4})"
 // @Param request body SendMessageRequest true "訊息內容"
+// @Param async query bool false "非同步模式：寫入持久化佇列後立即回傳 202 與投遞 ID（需啟用 queue）"
+// @Param template query string false "具名模板集（templates/alerts 子目錄），優先於路由、level 與提供者配置"
 // @Security BasicAuth
 // @Success 200 {object} SendMessageResponse
+// @Success 202 {object} SendMessageResponse
 // @Failure 400 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /telegram/chatid_{chatid} [post]
@@ -224,6 +215,55 @@ func (h *Handler) SendMessage(c *gin.Context) {
 		return
 	}
 
+	// 渲染前移除被靜音或被抑制的警報，全部被移除時不發送
+	if req.AlertManagerData != nil {
+		filtered := alertfilter.Apply(toAlertData(req.AlertManagerData))
+		if filtered.Empty() {
+			c.JSON(http.StatusOK, SendMessageResponse{
+				Success:   true,
+				Message:   "All alerts silenced or inhibited, notification not sent",
+				Level:     level,
+				Silenced:  filtered.Silenced,
+				Inhibited: filtered.Inhibited,
+			})
+			return
+		}
+		if filtered.Dropped() > 0 {
+			alerts := make([]Alert, 0, len(filtered.Keep))
+			for _, i := range filtered.Keep {
+				alerts = append(alerts, req.AlertManagerData.Alerts[i])
+			}
+			req.AlertManagerData.Alerts = alerts
+			req.AlertManagerData.Status = filtered.Data.Status
+		}
+	}
+
+	// 去重：窗口內相同 groupKey、狀態與 fingerprint 的通知只發送一次，發送失敗時釋放鍵值讓重試通過
+	if req.AlertManagerData != nil {
+		dest := types.Destination{Provider: "telegram", Level: fmt.Sprintf("L%d", level)}
+		key, duplicate := dedup.Check(dest, toAlertData(req.AlertManagerData))
+		if duplicate {
+			c.JSON(http.StatusOK, SendMessageResponse{
+				Success:      true,
+				Message:      "Duplicate notification suppressed within dedup window",
+				Level:        level,
+				Deduplicated: true,
+			})
+			return
+		}
+		defer func() {
+			if c.Writer.Status() >= http.StatusBadRequest {
+				dedup.Release(key)
+			}
+		}()
+	}
+
+	// 非同步模式：寫入持久化佇列，由 worker 透過 NotificationManager 渲染並投遞
+	if async, _ := strconv.ParseBool(c.Query("async")); async {
+		h.enqueue(c, level, &req)
+		return
+	}
+
 	// 處理訊息內容
 	if req.AlertManagerData != nil {
 		// 使用請求中的模板語言，如果沒有則使用配置檔案中的預設語言
@@ -235,10 +275,11 @@ func (h *Handler) SendMessage(c *gin.Context) {
 			templateLanguage = "eng"
 		}
 
-		// 使用共用 model 產生模板資料，並透過模板引擎渲染
+		// 使用共用 model 產生模板資料，並透過模板引擎渲染；每次請求重新取得模板引擎，模板重新載入後立即生效
+		templateEngine := service.GetServiceManager().GetTemplateEngine()
 		var formatOptions template.FormatOptions
-		if h.templateEngine != nil {
-			formatOptions = h.templateEngine.GetCurrentFormatOptions()
+		if templateEngine != nil {
+			formatOptions = templateEngine.GetCurrentFormatOptions()
 		} else {
 			formatOptions = h.getFormatOptionsForTelegram()
 		}
@@ -253,16 +294,19 @@ func (h *Handler) SendMessage(c *gin.Context) {
 			formatOptions,
 		)
 
-		// 透過模板引擎渲染（含語言回退）
+		// 透過模板引擎渲染（含語言回退）；具名模板集依 ?template=、level 與提供者配置選擇
 		actualLanguage := templateLanguage
-		if h.templateEngine != nil {
-			actualLanguage = h.templateEngine.GetDefaultLanguage(templateLanguage)
+		templateName := template.SelectTemplateSet(c.Query("template"), "telegram", fmt.Sprintf("L%d", level))
+		data.Level = fmt.Sprintf("L%d", level)
+		if templateEngine != nil {
+			actualLanguage = templateEngine.GetTemplateSetLanguage(templateName, templateLanguage)
 
 			logger.Debug("About to render template", "telegram_handler",
 				logger.String("language", actualLanguage),
+				logger.String("template_set", templateName),
 				logger.String("platform", "telegram"))
 
-			msg, rerr := h.templateEngine.RenderTemplateForPlatform(actualLanguage, "telegram", data)
+			msg, rerr := templateEngine.RenderNamedTemplateForPlatform(templateName, actualLanguage, "telegram", data)
 
 			// 立即記錄渲染結果
 			logger.Debug("Template render returned", "telegram_handler",
@@ -281,7 +325,10 @@ func (h *Handler) SendMessage(c *gin.Context) {
 					logger.String("language", actualLanguage),
 					logger.Int("message_length", len(msg)))
 
-				if sendErr := h.telegramService.SendMessage(c.Request.Context(), level, msg); sendErr != nil {
+				// 觸發中的警報附加 Ack / Silence / Runbook 按鈕
+				alertData := toAlertData(req.AlertManagerData)
+				opts := &types.TelegramMessageOptions{ReplyMarkup: actions.TelegramKeyboard(alertData), AlertData: alertData}
+				if _, sendErr := h.telegramService.SendMessageWithOptions(c.Request.Context(), level, msg, opts); sendErr != nil {
 					logger.Error("Failed to send message via template engine path", "telegram_handler",
 						logger.Int("level", level),
 						logger.String("error_detail", sendErr.Error()),
@@ -303,7 +350,7 @@ func (h *Handler) SendMessage(c *gin.Context) {
 		}
 
 		// 若模板引擎不可用或渲染失敗，使用既有的分離發送備援
-		err = h.sendSeparateAlertMessages(c.Request.Context(), req.AlertManagerData, templateLanguage, level)
+		err = h.sendSeparateAlertMessages(c.Request.Context(), req.AlertManagerData, templateLanguage, templateName, level)
 		if err != nil {
 			logger.Error("Failed to send alert messages", "telegram_handler",
 				logger.Int("level", level),
@@ -337,6 +384,66 @@ func (h *Handler) SendMessage(c *gin.Context) {
 		Level:   level,
 	})
 }
+
+// enqueue 將訊息寫入持久化佇列並回傳 202 與投遞 ID
+func (h *Handler) enqueue(c *gin.Context, level int, req *SendMessageRequest) {
+	deliveryQueue := queue.GetQueue()
+	if deliveryQueue == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"message": "Async delivery requires the queue to be enabled",
+		})
+		return
+	}
+
+	notificationReq := &types.NotificationRequest{
+		ProviderName:     "telegram",
+		Level:            fmt.Sprintf("L%d", level),
+		Message:          req.Message,
+		TemplateLanguage: req.TemplateLanguage,
+		TemplateName:     c.Query("template"),
+	}
+	if req.AlertManagerData != nil {
+		notificationReq.Message = ""
+		notificationReq.AlertData = toAlertData(req.AlertManagerData)
+	}
+
+	receipt, err := deliveryQueue.Submit(notificationReq)
+	if err != nil {
+		logger.Error("Failed to enqueue Telegram message", "telegram_handler",
+			logger.Int("level", level),
+			logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to enqueue message: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, SendMessageResponse{
+		Success:    true,
+		Message:    "Message accepted for delivery",
+		Level:      level,
+		DeliveryID: receipt.ID,
+	})
+}
+
+// toAlertData 將 Telegram 端點的 AlertManager 結構轉為通用的 AlertManagerData
+func toAlertData(webhook *AlertManagerWebhook) *types.AlertManagerData {
+	return &types.AlertManagerData{
+		Receiver:          webhook.Receiver,
+		Status:            webhook.Status,
+		Alerts:            convertAlertSliceToMap(webhook.Alerts),
+		GroupLabels:       convertStringMapToInterface(webhook.GroupLabels),
+		CommonLabels:      convertStringMapToInterface(webhook.CommonLabels),
+		CommonAnnotations: convertStringMapToInterface(webhook.CommonAnnotations),
+		ExternalURL:       webhook.ExternalURL,
+		Version:           webhook.Version,
+		GroupKey:          webhook.GroupKey,
+		TruncatedAlerts:   webhook.TruncatedAlerts,
+	}
+}
+
 // convertAlertSliceToMap 將 Alert 結構切片轉為通用 map 切片
 func convertAlertSliceToMap(alerts []Alert) []map[string]interface{} {
     res := make([]map[string]interface{}, 0, len(alerts))
@@ -411,8 +518,47 @@ func (h *Handler) GetBotInfo(c *gin.Context) {
 	})
 }
 
-// generateAlertManagerMessage 生成 AlertManager 模板訊息
-func (h *Handler) generateAlertManagerMessage(webhook *AlertManagerWebhook, language string) string {
+// HandleUpdate 接收 Telegram webhook 推送的更新
+// @Summary 接收 Telegram 更新
+// @Description 接收 Telegram webhook 推送的 callback query，處理互動按鈕（Ack / Silence）。以 X-Telegram-Bot-Api-Secret-Token 標頭驗證
+// @Tags telegram
+// @Accept json
+// @Produce json
+// @Param X-Telegram-Bot-Api-Secret-Token header string true "actions.telegram.webhook_secret"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /telegram/updates [post]
+func (h *Handler) HandleUpdate(c *gin.Context) {
+	secret := config.Actions.Telegram.WebhookSecret
+	header := c.GetHeader("X-Telegram-Bot-Api-Secret-Token")
+	if secret == "" || subtle.ConstantTimeCompare([]byte(header), []byte(secret)) != 1 {
+		logger.Warn("Rejected Telegram update with invalid secret token", "telegram_handler",
+			logger.String("client_ip", c.ClientIP()))
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Invalid secret token"})
+		return
+	}
+
+	var update models.Update
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid update: " + err.Error()})
+		return
+	}
+
+	// 使用目前的服務實例，配置重新載入後 bot 會被重新建立
+	telegramService := service.GetServiceManager().GetTelegramService()
+	if telegramService == nil {
+		telegramService = h.telegramService
+	}
+	if b := telegramService.GetBot(); b != nil && update.CallbackQuery != nil {
+		actions.HandleTelegramCallback(c.Request.Context(), b, &update)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// generateAlertManagerMessage 以具名模板集生成 AlertManager 模板訊息，level 用於選擇 time_format 設定
+func (h *Handler) generateAlertManagerMessage(webhook *AlertManagerWebhook, language, templateName string, level int) string {
 	// 統計警報
 	firingCount := 0
 	resolvedCount := 0
@@ -466,21 +612,23 @@ func (h *Handler) generateAlertManagerMessage(webhook *AlertManagerWebhook, lang
 		ResolvedCount: resolvedCount,
 		Alerts:        alertData,
 		ExternalURL:   webhook.ExternalURL,
+		Level:         fmt.Sprintf("L%d", level),
 	}
 
 	// 使用模板引擎目前的 FormatOptions，確保與配置檔一致
-	if h.templateEngine != nil {
-		templateData.FormatOptions = h.templateEngine.GetCurrentFormatOptions()
+	templateEngine := service.GetServiceManager().GetTemplateEngine()
+	if templateEngine != nil {
+		templateData.FormatOptions = templateEngine.GetCurrentFormatOptions()
 	}
 
 	// 嘗試使用模板引擎渲染
 	logger.Debug("Attempting to use template engine", "telegram_handler",
-		logger.Bool("has_template_engine", h.templateEngine != nil),
+		logger.Bool("has_template_engine", templateEngine != nil),
 		logger.String("language", language))
 
-	if h.templateEngine != nil {
-		// 獲取合適的語言（包含回退邏輯）
-		actualLanguage := h.templateEngine.GetDefaultLanguage(language)
+	if templateEngine != nil {
+		// 獲取模板集中合適的語言（包含回退邏輯）
+		actualLanguage := templateEngine.GetTemplateSetLanguage(templateName, language)
 		if actualLanguage != language {
 			logger.Info("Language fallback applied", "telegram_handler",
 				logger.String("requested", language),
@@ -489,11 +637,12 @@ func (h *Handler) generateAlertManagerMessage(webhook *AlertManagerWebhook, lang
 
 		logger.Debug("Calling template engine with data", "telegram_handler",
 			logger.String("actualLanguage", actualLanguage),
+			logger.String("template_set", templateName),
 			logger.String("platform", "telegram"),
 			logger.Bool("formatOptions.ShowGeneratorURL", templateData.FormatOptions.ShowGeneratorURL.Enabled),
 			logger.Bool("formatOptions.ShowExternalURL", templateData.FormatOptions.ShowExternalURL.Enabled))
 
-		message, err := h.templateEngine.RenderTemplateForPlatform(actualLanguage, "telegram", templateData)
+		message, err := templateEngine.RenderNamedTemplateForPlatform(templateName, actualLanguage, "telegram", templateData)
 		if err == nil {
 			messagePreview := message
 			if len(message) > 100 {
@@ -501,7 +650,7 @@ func (h *Handler) generateAlertManagerMessage(webhook *AlertManagerWebhook, lang
 			}
 			logger.Info("Template rendered successfully", "telegram_handler",
 				logger.String("language", actualLanguage),
-				logger.String("available_languages", fmt.Sprintf("%v", h.templateEngine.GetAvailableLanguages())),
+				logger.String("available_languages", fmt.Sprintf("%v", templateEngine.GetAvailableLanguages())),
 				logger.String("message_preview", messagePreview))
 			return message
 		}
@@ -516,8 +665,8 @@ func (h *Handler) generateAlertManagerMessage(webhook *AlertManagerWebhook, lang
 	return h.generateBuiltInMessage(webhook, language, firingCount, resolvedCount, alertName, env, severity, namespace)
 }
 
-// sendSeparateAlertMessages 分別發送觸發中和已解決的警報
-func (h *Handler) sendSeparateAlertMessages(ctx context.Context, webhook *AlertManagerWebhook, language string, level int) error {
+// sendSeparateAlertMessages 分別發送觸發中和已解決的警報，兩者使用同一個具名模板集
+func (h *Handler) sendSeparateAlertMessages(ctx context.Context, webhook *AlertManagerWebhook, language, templateName string, level int) error {
 	// 分離觸發中和已解決的警報
 	var firingAlerts []Alert
 	var resolvedAlerts []Alert
@@ -550,7 +699,7 @@ func (h *Handler) sendSeparateAlertMessages(ctx context.Context, webhook *AlertM
 			logger.Int("firing_count", len(firingAlerts)),
 			logger.String("language", language))
 
-		firingMessage := h.generateAlertManagerMessage(firingWebhook, language)
+		firingMessage := h.generateAlertManagerMessage(firingWebhook, language, templateName, level)
 
 		logger.Debug("Attempting to send firing alerts", "telegram_handler",
 			logger.Int("level", level),
@@ -588,7 +737,7 @@ func (h *Handler) sendSeparateAlertMessages(ctx context.Context, webhook *AlertM
 			logger.Int("resolved_count", len(resolvedAlerts)),
 			logger.String("language", language))
 
-		resolvedMessage := h.generateAlertManagerMessage(resolvedWebhook, language)
+		resolvedMessage := h.generateAlertManagerMessage(resolvedWebhook, language, templateName, level)
 
 		logger.Debug("Attempting to send resolved alerts", "telegram_handler",
 			logger.Int("level", level),
@@ -609,28 +758,16 @@ func (h *Handler) sendSeparateAlertMessages(ctx context.Context, webhook *AlertM
 	return nil
 }
 
-// generateBuiltInMessage 生成內建的訊息模板（備用方案）
+// generateBuiltInMessage 生成內建的訊息模板（備用方案），輸出 Telegram HTML，使用者資料皆已轉義
 func (h *Handler) generateBuiltInMessage(webhook *AlertManagerWebhook, language string, firingCount, resolvedCount int, alertName, env, severity, namespace string) string {
-	var message strings.Builder
-
-	// 為 Telegram MarkdownV2 創建轉義函數
-	escapeText := func(text string) string {
-		// 轉義 MarkdownV2 特殊字符
-		specialChars := []string{
-			"_", "*", "[", "]", "(", ")", "~", "`", ">", "#", "+", "-", "=", "|", "{", "}", ".", "!",
-		}
+	templateEngine := service.GetServiceManager().GetTemplateEngine()
 
-		result := text
-		for _, char := range specialChars {
-			result = strings.ReplaceAll(result, char, "\\"+char)
-		}
-		return result
-	}
+	var message strings.Builder
 
 	// 讀取當前平台顯示開關（優先使用模板引擎載入的配置）
 	var formatOptions template.FormatOptions
-	if h.templateEngine != nil {
-		formatOptions = h.templateEngine.GetCurrentFormatOptions()
+	if templateEngine != nil {
+		formatOptions = templateEngine.GetCurrentFormatOptions()
 	} else {
 		formatOptions = h.getFormatOptionsForTelegram()
 	}
@@ -638,146 +775,146 @@ func (h *Handler) generateBuiltInMessage(webhook *AlertManagerWebhook, language
 	if language == "tw" {
 		// 繁體中文模板
 		if firingCount > 0 {
-			message.WriteString("🚨 *警報通知*\n\n")
+			message.WriteString("🚨 <b>警報通知</b>\n\n")
 		} else if resolvedCount > 0 {
-			message.WriteString("✅ *警報已解決*\n\n")
+			message.WriteString("✅ <b>警報已解決</b>\n\n")
 		}
 
-		message.WriteString(fmt.Sprintf("*狀態:* %s\n", escapeText(webhook.Status)))
-		message.WriteString(fmt.Sprintf("*警報名稱:* %s\n", escapeText(alertName)))
-		message.WriteString(fmt.Sprintf("*環境:* %s\n", escapeText(env)))
-		message.WriteString(fmt.Sprintf("*嚴重程度:* %s\n", escapeText(severity)))
-		message.WriteString(fmt.Sprintf("*命名空間:* %s\n", escapeText(namespace)))
-		message.WriteString(fmt.Sprintf("*總警報數:* %d\n", len(webhook.Alerts)))
+		message.WriteString(fmt.Sprintf("<b>狀態:</b> %s\n", telegramhtml.Escape(webhook.Status)))
+		message.WriteString(fmt.Sprintf("<b>警報名稱:</b> %s\n", telegramhtml.Escape(alertName)))
+		message.WriteString(fmt.Sprintf("<b>環境:</b> %s\n", telegramhtml.Escape(env)))
+		message.WriteString(fmt.Sprintf("<b>嚴重程度:</b> %s\n", telegramhtml.Escape(severity)))
+		message.WriteString(fmt.Sprintf("<b>命名空間:</b> %s\n", telegramhtml.Escape(namespace)))
+		message.WriteString(fmt.Sprintf("<b>總警報數:</b> %d\n", len(webhook.Alerts)))
 
 		if firingCount > 0 {
-			message.WriteString(fmt.Sprintf("*觸發中:* %d\n", firingCount))
+			message.WriteString(fmt.Sprintf("<b>觸發中:</b> %d\n", firingCount))
 		}
 		if resolvedCount > 0 {
-			message.WriteString(fmt.Sprintf("*已解決:* %d\n", resolvedCount))
+			message.WriteString(fmt.Sprintf("<b>已解決:</b> %d\n", resolvedCount))
 		}
 
 		// 詳細警報列表
 		if firingCount > 0 {
-			message.WriteString("\n*🚨 觸發中的警報:*\n")
+			message.WriteString("\n<b>🚨 觸發中的警報:</b>\n")
 			for i, alert := range webhook.Alerts {
 				if alert.Status == "firing" {
-					message.WriteString(fmt.Sprintf("\n*警報 %d:*\n", i+1))
-					message.WriteString(fmt.Sprintf("• 摘要: %s\n", escapeText(alert.Annotations["summary"])))
+					message.WriteString(fmt.Sprintf("\n<b>警報 %d:</b>\n", i+1))
+					message.WriteString(fmt.Sprintf("• 摘要: %s\n", telegramhtml.Escape(alert.Annotations["summary"])))
 					// 若提供 description，則補充描述
 					if desc, ok := alert.Annotations["description"]; ok && desc != "" {
-						message.WriteString(fmt.Sprintf("• 描述: %s\n", escapeText(desc)))
+						message.WriteString(fmt.Sprintf("• 描述: %s\n", telegramhtml.Escape(desc)))
 					}
 					if pod := alert.Labels["pod"]; pod != "" {
-						message.WriteString(fmt.Sprintf("• Pod: %s\n", escapeText(pod)))
+						message.WriteString(fmt.Sprintf("• Pod: %s\n", telegramhtml.Escape(pod)))
 					}
-					message.WriteString(fmt.Sprintf("• 開始時間: %s\n", escapeText(h.formatTime(alert.StartsAt))))
+					message.WriteString(fmt.Sprintf("• 開始時間: %s\n", telegramhtml.Escape(h.formatTime(alert.StartsAt))))
 					if alert.EndsAt != "0001-01-01T00:00:00Z" {
-						message.WriteString(fmt.Sprintf("• 結束時間: %s\n", escapeText(h.formatTime(alert.EndsAt))))
+						message.WriteString(fmt.Sprintf("• 結束時間: %s\n", telegramhtml.Escape(h.formatTime(alert.EndsAt))))
 					}
 					if formatOptions.ShowGeneratorURL.Enabled && alert.GeneratorURL != "" {
-						message.WriteString(fmt.Sprintf("• [查看詳情](%s)\n", alert.GeneratorURL))
+						message.WriteString(fmt.Sprintf("• <a href=\"%s\">查看詳情</a>\n", telegramhtml.EscapeAttr(alert.GeneratorURL)))
 					}
 				}
 			}
 		}
 
 		if resolvedCount > 0 {
-			message.WriteString("\n*✅ 已解決的警報:*\n")
+			message.WriteString("\n<b>✅ 已解決的警報:</b>\n")
 			for i, alert := range webhook.Alerts {
 				if alert.Status == "resolved" {
-					message.WriteString(fmt.Sprintf("\n*警報 %d:*\n", i+1))
-					message.WriteString(fmt.Sprintf("• 摘要: %s\n", escapeText(alert.Annotations["summary"])))
+					message.WriteString(fmt.Sprintf("\n<b>警報 %d:</b>\n", i+1))
+					message.WriteString(fmt.Sprintf("• 摘要: %s\n", telegramhtml.Escape(alert.Annotations["summary"])))
 					// 若提供 description，則補充描述
 					if desc, ok := alert.Annotations["description"]; ok && desc != "" {
-						message.WriteString(fmt.Sprintf("• 描述: %s\n", escapeText(desc)))
+						message.WriteString(fmt.Sprintf("• 描述: %s\n", telegramhtml.Escape(desc)))
 					}
 					if pod := alert.Labels["pod"]; pod != "" {
-						message.WriteString(fmt.Sprintf("• Pod: %s\n", escapeText(pod)))
+						message.WriteString(fmt.Sprintf("• Pod: %s\n", telegramhtml.Escape(pod)))
 					}
-					message.WriteString(fmt.Sprintf("• 開始時間: %s\n", escapeText(h.formatTime(alert.StartsAt))))
-					message.WriteString(fmt.Sprintf("• 結束時間: %s\n", escapeText(h.formatTime(alert.EndsAt))))
+					message.WriteString(fmt.Sprintf("• 開始時間: %s\n", telegramhtml.Escape(h.formatTime(alert.StartsAt))))
+					message.WriteString(fmt.Sprintf("• 結束時間: %s\n", telegramhtml.Escape(h.formatTime(alert.EndsAt))))
 					if formatOptions.ShowGeneratorURL.Enabled && alert.GeneratorURL != "" {
-						message.WriteString(fmt.Sprintf("• [查看詳情](%s)\n", alert.GeneratorURL))
+						message.WriteString(fmt.Sprintf("• <a href=\"%s\">查看詳情</a>\n", telegramhtml.EscapeAttr(alert.GeneratorURL)))
 					}
 				}
 			}
 		}
 
 		if formatOptions.ShowExternalURL.Enabled && webhook.ExternalURL != "" {
-			message.WriteString(fmt.Sprintf("\n[查看所有警報詳情](%s)", webhook.ExternalURL))
+			message.WriteString(fmt.Sprintf("\n<a href=\"%s\">查看所有警報詳情</a>", telegramhtml.EscapeAttr(webhook.ExternalURL)))
 		}
 	} else {
 		// 英文模板
 		if firingCount > 0 {
-			message.WriteString("🚨 *Alert Notification*\n\n")
+			message.WriteString("🚨 <b>Alert Notification</b>\n\n")
 		} else if resolvedCount > 0 {
-			message.WriteString("✅ *Alert Resolved*\n\n")
+			message.WriteString("✅ <b>Alert Resolved</b>\n\n")
 		}
 
-		message.WriteString(fmt.Sprintf("*Status:* %s\n", escapeText(webhook.Status)))
-		message.WriteString(fmt.Sprintf("*Alert Name:* %s\n", escapeText(alertName)))
-		message.WriteString(fmt.Sprintf("*Environment:* %s\n", escapeText(env)))
-		message.WriteString(fmt.Sprintf("*Severity:* %s\n", escapeText(severity)))
-		message.WriteString(fmt.Sprintf("*Namespace:* %s\n", escapeText(namespace)))
-		message.WriteString(fmt.Sprintf("*Total Alerts:* %d\n", len(webhook.Alerts)))
+		message.WriteString(fmt.Sprintf("<b>Status:</b> %s\n", telegramhtml.Escape(webhook.Status)))
+		message.WriteString(fmt.Sprintf("<b>Alert Name:</b> %s\n", telegramhtml.Escape(alertName)))
+		message.WriteString(fmt.Sprintf("<b>Environment:</b> %s\n", telegramhtml.Escape(env)))
+		message.WriteString(fmt.Sprintf("<b>Severity:</b> %s\n", telegramhtml.Escape(severity)))
+		message.WriteString(fmt.Sprintf("<b>Namespace:</b> %s\n", telegramhtml.Escape(namespace)))
+		message.WriteString(fmt.Sprintf("<b>Total Alerts:</b> %d\n", len(webhook.Alerts)))
 
 		if firingCount > 0 {
-			message.WriteString(fmt.Sprintf("*Firing:* %d\n", firingCount))
+			message.WriteString(fmt.Sprintf("<b>Firing:</b> %d\n", firingCount))
 		}
 		if resolvedCount > 0 {
-			message.WriteString(fmt.Sprintf("*Resolved:* %d\n", resolvedCount))
+			message.WriteString(fmt.Sprintf("<b>Resolved:</b> %d\n", resolvedCount))
 		}
 
 		// 詳細警報列表
 		if firingCount > 0 {
-			message.WriteString("\n*🚨 Firing Alerts:*\n")
+			message.WriteString("\n<b>🚨 Firing Alerts:</b>\n")
 			for i, alert := range webhook.Alerts {
 				if alert.Status == "firing" {
-					message.WriteString(fmt.Sprintf("\n*Alert %d:*\n", i+1))
-					message.WriteString(fmt.Sprintf("• Summary: %s\n", escapeText(alert.Annotations["summary"])))
+					message.WriteString(fmt.Sprintf("\n<b>Alert %d:</b>\n", i+1))
+					message.WriteString(fmt.Sprintf("• Summary: %s\n", telegramhtml.Escape(alert.Annotations["summary"])))
 					// If description provided, add it
 					if desc, ok := alert.Annotations["description"]; ok && desc != "" {
-						message.WriteString(fmt.Sprintf("• Description: %s\n", escapeText(desc)))
+						message.WriteString(fmt.Sprintf("• Description: %s\n", telegramhtml.Escape(desc)))
 					}
 					if pod := alert.Labels["pod"]; pod != "" {
-						message.WriteString(fmt.Sprintf("• Pod: %s\n", escapeText(pod)))
+						message.WriteString(fmt.Sprintf("• Pod: %s\n", telegramhtml.Escape(pod)))
 					}
-					message.WriteString(fmt.Sprintf("• Started: %s\n", escapeText(h.formatTime(alert.StartsAt))))
+					message.WriteString(fmt.Sprintf("• Started: %s\n", telegramhtml.Escape(h.formatTime(alert.StartsAt))))
 					if alert.EndsAt != "0001-01-01T00:00:00Z" {
-						message.WriteString(fmt.Sprintf("• Ended: %s\n", escapeText(h.formatTime(alert.EndsAt))))
+						message.WriteString(fmt.Sprintf("• Ended: %s\n", telegramhtml.Escape(h.formatTime(alert.EndsAt))))
 					}
 					if formatOptions.ShowGeneratorURL.Enabled && alert.GeneratorURL != "" {
-						message.WriteString(fmt.Sprintf("• [View Details](%s)\n", alert.GeneratorURL))
+						message.WriteString(fmt.Sprintf("• <a href=\"%s\">View Details</a>\n", telegramhtml.EscapeAttr(alert.GeneratorURL)))
 					}
 				}
 			}
 		}
 
 		if resolvedCount > 0 {
-			message.WriteString("\n*✅ Resolved Alerts:*\n")
+			message.WriteString("\n<b>✅ Resolved Alerts:</b>\n")
 			for i, alert := range webhook.Alerts {
 				if alert.Status == "resolved" {
-					message.WriteString(fmt.Sprintf("\n*Alert %d:*\n", i+1))
-					message.WriteString(fmt.Sprintf("• Summary: %s\n", escapeText(alert.Annotations["summary"])))
+					message.WriteString(fmt.Sprintf("\n<b>Alert %d:</b>\n", i+1))
+					message.WriteString(fmt.Sprintf("• Summary: %s\n", telegramhtml.Escape(alert.Annotations["summary"])))
 					// If description provided, add it
 					if desc, ok := alert.Annotations["description"]; ok && desc != "" {
-						message.WriteString(fmt.Sprintf("• Description: %s\n", escapeText(desc)))
+						message.WriteString(fmt.Sprintf("• Description: %s\n", telegramhtml.Escape(desc)))
 					}
 					if pod := alert.Labels["pod"]; pod != "" {
-						message.WriteString(fmt.Sprintf("• Pod: %s\n", escapeText(pod)))
+						message.WriteString(fmt.Sprintf("• Pod: %s\n", telegramhtml.Escape(pod)))
 					}
-					message.WriteString(fmt.Sprintf("• Started: %s\n", escapeText(h.formatTime(alert.StartsAt))))
-					message.WriteString(fmt.Sprintf("• Ended: %s\n", escapeText(h.formatTime(alert.EndsAt))))
+					message.WriteString(fmt.Sprintf("• Started: %s\n", telegramhtml.Escape(h.formatTime(alert.StartsAt))))
+					message.WriteString(fmt.Sprintf("• Ended: %s\n", telegramhtml.Escape(h.formatTime(alert.EndsAt))))
 					if formatOptions.ShowGeneratorURL.Enabled && alert.GeneratorURL != "" {
-						message.WriteString(fmt.Sprintf("• [View Details](%s)\n", alert.GeneratorURL))
+						message.WriteString(fmt.Sprintf("• <a href=\"%s\">View Details</a>\n", telegramhtml.EscapeAttr(alert.GeneratorURL)))
 					}
 				}
 			}
 		}
 
 		if formatOptions.ShowExternalURL.Enabled && webhook.ExternalURL != "" {
-			message.WriteString(fmt.Sprintf("\n[View All Alert Details](%s)", webhook.ExternalURL))
+			message.WriteString(fmt.Sprintf("\n<a href=\"%s\">View All Alert Details</a>", telegramhtml.EscapeAttr(webhook.ExternalURL)))
 		}
 	}
 
@@ -803,6 +940,8 @@ func (h *Handler) formatTime(timeStr string) string {
 
 // getFormatOptionsForTelegram 根據 Telegram 配置返回對應的 FormatOptions
 func (h *Handler) getFormatOptionsForTelegram() template.FormatOptions {
+	templateEngine := service.GetServiceManager().GetTemplateEngine()
+
 	templateMode := config.Conf.Telegram.TemplateMode
 	if templateMode == "" {
 		templateMode = "full" // Default to full mode
@@ -810,8 +949,8 @@ func (h *Handler) getFormatOptionsForTelegram() template.FormatOptions {
 
 	if templateMode == "minimal" {
 		// 從 template engine 載入 minimal 配置，而不是硬編碼
-		if h.templateEngine != nil {
-			minimalConfig := h.templateEngine.GetMinimalDefaultConfig()
+		if templateEngine != nil {
+			minimalConfig := templateEngine.GetMinimalDefaultConfig()
 			if minimalConfig != nil {
 				logger.Debug("Using minimal config FormatOptions for Telegram", "TelegramHandler",
 					logger.Bool("ShowEmoji", minimalConfig.FormatOptions.ShowEmoji.Enabled),
@@ -2,7 +2,10 @@
 package telegram
 
 import (
-	//"alert-webhooks/pkg/logger"
+	"strings"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/logger"
 	"alert-webhooks/pkg/middleware"
 	"alert-webhooks/pkg/service"
 
@@ -24,6 +27,15 @@ func RegisterRoutes(router *gin.RouterGroup, telegramService *service.TelegramSe
 		// Telegram 相關路由（需要基本認證）
 		router.POST("/telegram/chatid_:chatid", middleware.BasicAuth(), handler.SendMessage)
 		router.GET("/telegram/info", middleware.BasicAuth(), handler.GetBotInfo)
+
+		// 互動按鈕的 webhook 入口，以 secret token 驗證而非基本認證
+		if config.Actions.Telegram.Enable && strings.ToLower(config.Actions.Telegram.Mode) == "webhook" {
+			if config.Actions.Telegram.WebhookSecret != "" {
+				router.POST("/telegram/updates", handler.HandleUpdate)
+			} else {
+				logger.Warn("Telegram actions webhook mode requires webhook_secret, /telegram/updates not registered", "telegram_routes")
+			}
+		}
 		
 	// 	logger.Info("Telegram routes registered successfully", "telegram_routes")
 	// } else {
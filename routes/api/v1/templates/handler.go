@@ -0,0 +1,282 @@
+// Package templates 提供模板預覽端點：以範例 Alertmanager 負載渲染模板，不發送任何訊息
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"alert-webhooks/config"
+	"alert-webhooks/pkg/alertmodel"
+	"alert-webhooks/pkg/notification/providers"
+	"alert-webhooks/pkg/notification/types"
+	"alert-webhooks/pkg/service"
+	"alert-webhooks/pkg/template"
+
+	"github.com/gin-gonic/gin"
+)
+
+// samplePayloadPaths 預設範例負載 raw_alertmanager.json 的搜尋路徑
+var samplePayloadPaths = []string{
+	"raw_alertmanager.json",
+	"./raw_alertmanager.json",
+	"../raw_alertmanager.json",
+}
+
+// Handler 模板預覽處理器
+type Handler struct{}
+
+// NewHandler 創建新的模板預覽處理器
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// Response 模板預覽端點的通用響應結構
+type Response struct {
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+}
+
+// FormatOverrides 覆寫格式化選項，未提供的欄位沿用 template_mode 對應的設定
+type FormatOverrides struct {
+	ShowLinks        *bool `json:"show_links,omitempty"`
+	ShowTimestamps   *bool `json:"show_timestamps,omitempty"`
+	ShowExternalURL  *bool `json:"show_external_url,omitempty"`
+	ShowGeneratorURL *bool `json:"show_generator_url,omitempty"`
+	ShowEmoji        *bool `json:"show_emoji,omitempty"`
+	CompactMode      *bool `json:"compact_mode,omitempty"`
+	MaxSummaryLength *int  `json:"max_summary_length,omitempty"`
+}
+
+// RenderRequest 模板預覽請求；source 與 name 擇一，都未提供時使用平台配置選擇的模板
+type RenderRequest struct {
+	Source        string                  `json:"source,omitempty"`         // 模板原始碼
+	Syntax        string                  `json:"syntax,omitempty"`         // 原始碼語法：go（預設）或 jinja2
+	Name          string                  `json:"name,omitempty"`           // 具名模板集，空值依平台與 level 配置選擇
+	Language      string                  `json:"language,omitempty"`       // 模板語言，空值使用平台配置的語言
+	Platform      string                  `json:"platform,omitempty"`       // telegram（預設）、slack 或 discord
+	Level         string                  `json:"level,omitempty"`          // 用於選擇 level_templates 與 time_format 的 level 設定
+	TemplateMode  string                  `json:"template_mode,omitempty"`  // full 或 minimal，空值使用平台配置
+	FormatOptions *FormatOverrides        `json:"format_options,omitempty"` // 覆寫格式化選項
+	Payload       *types.AlertManagerData `json:"payload,omitempty"`        // Alertmanager 負載，空值使用 raw_alertmanager.json
+}
+
+// RenderResponse 模板預覽響應
+type RenderResponse struct {
+	Success      bool                   `json:"success"`
+	Output       string                 `json:"output"`
+	Length       int                    `json:"length"`
+	MaxLength    int                    `json:"max_length,omitempty"`
+	ExceedsLimit bool                   `json:"exceeds_limit"`
+	Template     string                 `json:"template,omitempty"`
+	Language     string                 `json:"language,omitempty"`
+	Platform     string                 `json:"platform"`
+	Errors       []template.RenderError `json:"errors,omitempty"`
+	Data         template.TemplateData  `json:"data"`
+}
+
+// Render 預覽模板渲染結果
+// @Summary Render template preview
+// @Description 以範例或提供的 Alertmanager 負載渲染模板原始碼或已載入的模板，返回輸出、含行號的解析/執行錯誤與計算出的 TemplateData，不發送任何訊息
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param request body RenderRequest true "預覽內容"
+// @Success 200 {object} RenderResponse
+// @Failure 400 {object} Response
+// @Failure 422 {object} RenderResponse
+// @Failure 503 {object} Response
+// @Router /templates/render [post]
+func (h *Handler) Render(c *gin.Context) {
+	engine := service.GetServiceManager().GetTemplateEngine()
+	if engine == nil {
+		c.JSON(http.StatusServiceUnavailable, Response{Success: false, Message: "Template engine not initialized"})
+		return
+	}
+
+	var req RenderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Success: false, Message: "Invalid request format: " + err.Error()})
+		return
+	}
+
+	platform := strings.ToLower(strings.TrimSpace(req.Platform))
+	if platform == "" {
+		platform = "telegram"
+	}
+	limits := providers.MessageLengthLimits()
+	if _, ok := limits[platform]; !ok {
+		c.JSON(http.StatusBadRequest, Response{Success: false, Message: "Invalid platform, must be telegram, slack or discord"})
+		return
+	}
+	if req.Source != "" && req.Name != "" {
+		c.JSON(http.StatusBadRequest, Response{Success: false, Message: "source and name are mutually exclusive"})
+		return
+	}
+	switch strings.ToLower(strings.TrimSpace(req.Syntax)) {
+	case "", template.SyntaxGo, template.SyntaxJinja2:
+	default:
+		c.JSON(http.StatusBadRequest, Response{Success: false, Message: "Invalid syntax, must be go or jinja2"})
+		return
+	}
+	if req.Name != "" && !engine.HasTemplateSet(req.Name) {
+		c.JSON(http.StatusBadRequest, Response{Success: false, Message: fmt.Sprintf("Template set %q not found, available: %s", req.Name, strings.Join(engine.GetTemplateSets(), ", "))})
+		return
+	}
+
+	payload := req.Payload
+	if payload == nil {
+		sample, err := loadSamplePayload()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, Response{Success: false, Message: "No payload provided and " + err.Error()})
+			return
+		}
+		payload = sample
+	}
+
+	formatOptions := engine.GetCurrentFormatOptions()
+	if templateMode(req.TemplateMode, platform) == "minimal" {
+		formatOptions = engine.GetMinimalDefaultConfig().FormatOptions
+	}
+	req.FormatOptions.apply(&formatOptions)
+
+	data := alertmodel.BuildTemplateData(
+		payload.Status,
+		payload.Alerts,
+		payload.GroupLabels,
+		payload.CommonLabels,
+		payload.CommonAnnotations,
+		payload.ExternalURL,
+		formatOptions,
+	)
+	data.Platform = platform
+	data.Language = providerLanguage(req.Language, platform)
+	data.Level = req.Level
+
+	resp := RenderResponse{
+		Platform:  platform,
+		MaxLength: limits[platform],
+		Data:      data,
+	}
+
+	var output string
+	var renderErr *template.RenderError
+	if req.Source != "" {
+		resp.Language = data.Language
+		tmpl, err := engine.ParseSource("inline", req.Source, req.Syntax)
+		if err != nil {
+			e := template.NewRenderError(template.StageParse, err)
+			renderErr = &e
+		} else if output, err = engine.ExecuteForPlatform(tmpl, platform, data); err != nil {
+			e := template.NewRenderError(template.StageExec, err)
+			renderErr = &e
+		}
+	} else {
+		resp.Template = template.SelectTemplateSet(req.Name, platform, req.Level)
+		if resp.Template == "" {
+			resp.Template = template.DefaultTemplateSet
+		}
+		resp.Language = engine.GetTemplateSetLanguage(resp.Template, data.Language)
+
+		var err error
+		if output, err = engine.RenderNamedTemplateForPlatform(resp.Template, resp.Language, platform, data); err != nil {
+			e := template.NewRenderError(template.StageExec, err)
+			renderErr = &e
+		}
+	}
+
+	if renderErr != nil {
+		resp.Errors = []template.RenderError{*renderErr}
+		c.JSON(http.StatusUnprocessableEntity, resp)
+		return
+	}
+
+	resp.Success = true
+	resp.Output = output
+	resp.Length = utf8.RuneCountInString(output)
+	resp.ExceedsLimit = resp.Length > resp.MaxLength
+	c.JSON(http.StatusOK, resp)
+}
+
+// apply 將覆寫值套用到格式化選項
+func (o *FormatOverrides) apply(options *template.FormatOptions) {
+	if o == nil {
+		return
+	}
+	if o.ShowLinks != nil {
+		options.ShowLinks.Enabled = *o.ShowLinks
+	}
+	if o.ShowTimestamps != nil {
+		options.ShowTimestamps.Enabled = *o.ShowTimestamps
+	}
+	if o.ShowExternalURL != nil {
+		options.ShowExternalURL.Enabled = *o.ShowExternalURL
+	}
+	if o.ShowGeneratorURL != nil {
+		options.ShowGeneratorURL.Enabled = *o.ShowGeneratorURL
+	}
+	if o.ShowEmoji != nil {
+		options.ShowEmoji.Enabled = *o.ShowEmoji
+	}
+	if o.CompactMode != nil {
+		options.CompactMode.Enabled = *o.CompactMode
+	}
+	if o.MaxSummaryLength != nil {
+		options.MaxSummaryLength.Value = *o.MaxSummaryLength
+	}
+}
+
+// templateMode 取得 template_mode，請求未指定時使用平台配置
+func templateMode(requested, platform string) string {
+	if requested != "" {
+		return requested
+	}
+	switch platform {
+	case "telegram":
+		return config.Telegram.TemplateMode
+	case "slack":
+		return config.Slack.TemplateMode
+	case "discord":
+		return config.Conf.Discord.TemplateMode
+	}
+	return ""
+}
+
+// providerLanguage 取得模板語言，請求未指定時使用平台配置，皆未設定時為 eng
+func providerLanguage(requested, platform string) string {
+	language := requested
+	if language == "" {
+		switch platform {
+		case "telegram":
+			language = config.Telegram.TemplateLanguage
+		case "slack":
+			language = config.Slack.TemplateLanguage
+		case "discord":
+			language = config.Conf.Discord.TemplateLanguage
+		}
+	}
+	if language == "" {
+		language = "eng"
+	}
+	return language
+}
+
+// loadSamplePayload 讀取 raw_alertmanager.json 作為預設範例負載
+func loadSamplePayload() (*types.AlertManagerData, error) {
+	for _, path := range samplePayloadPaths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var payload types.AlertManagerData
+		if err := json.Unmarshal(content, &payload); err != nil {
+			return nil, fmt.Errorf("failed to parse sample payload %s: %v", path, err)
+		}
+		return &payload, nil
+	}
+	return nil, fmt.Errorf("sample payload raw_alertmanager.json not found")
+}
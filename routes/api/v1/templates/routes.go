@@ -0,0 +1,18 @@
+package templates
+
+import (
+	"alert-webhooks/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes 註冊模板預覽路由
+func RegisterRoutes(router *gin.RouterGroup) {
+	handler := NewHandler()
+
+	// 模板預覽端點（需要基本認證）
+	templates := router.Group("/templates", middleware.BasicAuth())
+	{
+		templates.POST("/render", handler.Render)
+	}
+}